@@ -0,0 +1,154 @@
+// Package apperror berisi error domain yang dipakai bersama oleh repository,
+// service, controller REST, dan server gRPC. Setiap error punya satu kategori
+// (ErrNotFound, ErrAlreadyExists, ...) yang menentukan status HTTP dan kode gRPC,
+// sehingga layer transport tidak perlu menebak dari isi pesan.
+package apperror
+
+import "errors"
+
+// Kategori error. Gunakan errors.Is(err, apperror.ErrNotFound) untuk mengecek kategori.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrValidation    = errors.New("validation failed")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrConflict      = errors.New("conflict")
+
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// kinds adalah daftar kategori untuk KindOf.
+// ErrPreconditionFailed dicek sebelum ErrConflict karena bisa membungkus konflik versi.
+var kinds = []error{ErrNotFound, ErrAlreadyExists, ErrValidation, ErrUnauthorized, ErrForbidden,
+	ErrPreconditionFailed, ErrPreconditionRequired, ErrUnsupportedMediaType, ErrConflict}
+
+// FieldViolation menjelaskan satu field input yang tidak valid.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Error adalah error domain dengan kategori, pesan untuk client, dan detail field
+// untuk error validasi. Cause (jika ada) tetap bisa dicek dengan errors.Is,
+// misalnya sentinel service yang dibungkus Wrap.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldViolation
+	Cause   error
+}
+
+// Error mengembalikan pesan untuk client.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap membuat errors.Is mengenali kategori dan cause sekaligus.
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// New membuat error dengan kategori kind.
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// NotFound membuat error untuk data yang tidak ditemukan.
+func NotFound(message string) *Error {
+	return New(ErrNotFound, message)
+}
+
+// AlreadyExists membuat error untuk data yang bentrok dengan data unik lain.
+func AlreadyExists(message string) *Error {
+	return New(ErrAlreadyExists, message)
+}
+
+// Validation membuat error untuk input yang tidak valid, dengan detail per field (opsional).
+func Validation(message string, fields ...FieldViolation) *Error {
+	return &Error{Kind: ErrValidation, Message: message, Fields: fields}
+}
+
+// Unauthorized membuat error untuk credential yang tidak valid.
+func Unauthorized(message string) *Error {
+	return New(ErrUnauthorized, message)
+}
+
+// Forbidden membuat error untuk akses yang tidak diizinkan.
+func Forbidden(message string) *Error {
+	return New(ErrForbidden, message)
+}
+
+// Conflict membuat error untuk operasi yang bentrok dengan state data saat ini.
+func Conflict(message string) *Error {
+	return New(ErrConflict, message)
+}
+
+// PreconditionFailed membuat error untuk precondition request (If-Match) yang
+// tidak terpenuhi. cause (boleh nil) tetap bisa dicek dengan errors.Is, misalnya
+// konflik versi dari repository.
+func PreconditionFailed(message string, cause error) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Message: message, Cause: cause}
+}
+
+// PreconditionRequired membuat error untuk request tulis yang wajib membawa precondition.
+func PreconditionRequired(message string) *Error {
+	return New(ErrPreconditionRequired, message)
+}
+
+// UnsupportedMediaType membuat error untuk Content-Type request yang tidak didukung.
+func UnsupportedMediaType(message string) *Error {
+	return New(ErrUnsupportedMediaType, message)
+}
+
+// Wrap menambahkan detail ke cause dengan pesan "<cause>: <detail>". Kategori
+// diwarisi dari cause, dan cause tetap bisa dicek dengan errors.Is.
+func Wrap(cause error, detail string, fields ...FieldViolation) *Error {
+	return &Error{Kind: KindOf(cause), Message: cause.Error() + ": " + detail, Fields: fields, Cause: cause}
+}
+
+// Field membuat error validasi untuk satu field, misalnya Field(ErrInvalidUser, "age", "must not be negative")
+// menghasilkan "invalid user: age must not be negative".
+func Field(cause error, field, description string) *Error {
+	return Wrap(cause, field+" "+description, FieldViolation{Field: field, Description: description})
+}
+
+// KindOf mengembalikan kategori err, atau nil jika err bukan error domain
+// (error internal yang tidak boleh dibocorkan ke client sebagai error input).
+func KindOf(err error) error {
+	for _, kind := range kinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+// FieldsOf mengumpulkan detail field dari semua error domain di rantai err.
+func FieldsOf(err error) []FieldViolation {
+	var fields []FieldViolation
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+			return
+		case *Error:
+			fields = append(fields, e.Fields...)
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(u.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, inner := range u.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+	walk(err)
+	return fields
+}
@@ -0,0 +1,64 @@
+package apperror_test
+
+import (
+	"api-user-crud-go/apperror"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+var errInvalidUser = apperror.Validation("invalid user")
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"not found", apperror.NotFound("user not found"), apperror.ErrNotFound},
+		{"already exists", apperror.AlreadyExists("email already registered"), apperror.ErrAlreadyExists},
+		{"unauthorized", apperror.Unauthorized("invalid api key"), apperror.ErrUnauthorized},
+		{"forbidden", apperror.Forbidden("forbidden"), apperror.ErrForbidden},
+		{"conflict", apperror.Conflict("user was modified"), apperror.ErrConflict},
+		{"precondition failed over conflict", apperror.PreconditionFailed("user was modified", apperror.Conflict("user was modified")), apperror.ErrPreconditionFailed},
+		{"precondition required", apperror.PreconditionRequired("If-Match header is required"), apperror.ErrPreconditionRequired},
+		{"unsupported media type", apperror.UnsupportedMediaType("Content-Type must be JSON"), apperror.ErrUnsupportedMediaType},
+		{"wrapped with fmt", fmt.Errorf("%w: page size too large", errInvalidUser), apperror.ErrValidation},
+		{"field of sentinel", apperror.Field(errInvalidUser, "age", "must not be negative"), apperror.ErrValidation},
+		{"plain error", errors.New("database is locked"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apperror.KindOf(tt.err); got != tt.kind {
+				t.Errorf("expected kind %v, got %v", tt.kind, got)
+			}
+		})
+	}
+}
+
+func TestField_KeepsCauseAndMessage(t *testing.T) {
+	err := apperror.Field(errInvalidUser, "age", "must not be negative")
+
+	if err.Error() != "invalid user: age must not be negative" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, errInvalidUser) {
+		t.Error("expected error to match its cause")
+	}
+
+	want := []apperror.FieldViolation{{Field: "age", Description: "must not be negative"}}
+	if got := apperror.FieldsOf(fmt.Errorf("batch item 2: %w", err)); !slices.Equal(got, want) {
+		t.Errorf("expected fields %v, got %v", want, got)
+	}
+}
+
+func TestFieldsOf_NoDetails(t *testing.T) {
+	if got := apperror.FieldsOf(apperror.NotFound("user not found")); len(got) != 0 {
+		t.Errorf("expected no fields, got %v", got)
+	}
+	if got := apperror.FieldsOf(nil); len(got) != 0 {
+		t.Errorf("expected no fields for nil, got %v", got)
+	}
+}
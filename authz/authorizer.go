@@ -0,0 +1,38 @@
+package authz
+
+import (
+	"api-user-crud-go/apperror"
+	"slices"
+)
+
+// ErrForbidden dikembalikan ketika role tidak memiliki akses yang dibutuhkan.
+var ErrForbidden = apperror.Forbidden("forbidden: insufficient permissions")
+
+// Subject adalah identitas yang melakukan request.
+type Subject struct {
+	UserID uint
+	Role   string
+	// Scopes membatasi permission subject (misalnya API key dengan scope);
+	// nil berarti semua permission role berlaku.
+	Scopes []Permission
+}
+
+// Authorize mengecek apakah subject boleh menjalankan permission terhadap target.
+// targetID adalah ID user yang disentuh; 0 berarti aksi tidak terikat ke satu
+// record (misalnya create atau list) sehingga membutuhkan ScopeAny.
+// Scope tidak pernah menambah akses di luar role, hanya mempersempitnya.
+func Authorize(subject Subject, perm Permission, targetID uint) error {
+	if subject.Scopes != nil && !slices.Contains(subject.Scopes, perm) {
+		return ErrForbidden
+	}
+
+	switch rolePermissions[subject.Role][perm] {
+	case ScopeAny:
+		return nil
+	case ScopeOwn:
+		if targetID != 0 && targetID == subject.UserID {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,67 @@
+package authz_test
+
+import (
+	"api-user-crud-go/authz"
+	"api-user-crud-go/entity"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	admin := authz.Subject{UserID: 1, Role: entity.RoleAdmin}
+	user := authz.Subject{UserID: 2, Role: entity.RoleUser}
+	scopedAdmin := authz.Subject{UserID: 1, Role: entity.RoleAdmin, Scopes: []authz.Permission{authz.PermUserList, authz.PermUserRead}}
+	scopedUser := authz.Subject{UserID: 2, Role: entity.RoleUser, Scopes: []authz.Permission{authz.PermUserRead, authz.PermUserList}}
+
+	tests := []struct {
+		name     string
+		subject  authz.Subject
+		perm     authz.Permission
+		targetID uint
+		allowed  bool
+	}{
+		{"admin creates user", admin, authz.PermUserCreate, 0, true},
+		{"admin deletes other user", admin, authz.PermUserDelete, 2, true},
+		{"admin sets role", admin, authz.PermUserSetRole, 0, true},
+		{"user reads self", user, authz.PermUserRead, 2, true},
+		{"user updates self", user, authz.PermUserUpdate, 2, true},
+		{"user reads other", user, authz.PermUserRead, 1, false},
+		{"user updates other", user, authz.PermUserUpdate, 1, false},
+		{"user lists users", user, authz.PermUserList, 0, false},
+		{"user creates user", user, authz.PermUserCreate, 0, false},
+		{"user deletes self", user, authz.PermUserDelete, 2, false},
+		{"user sets role", user, authz.PermUserSetRole, 0, false},
+		{"admin unlocks user", admin, authz.PermUserUnlock, 2, true},
+		{"user unlocks self", user, authz.PermUserUnlock, 2, false},
+		{"admin manages user sessions", admin, authz.PermUserSessions, 2, true},
+		{"user manages sessions via admin route", user, authz.PermUserSessions, 2, false},
+		{"admin manages oauth clients", admin, authz.PermOAuthClientManage, 0, true},
+		{"user manages oauth clients", user, authz.PermOAuthClientManage, 0, false},
+		{"scoped admin lists users", scopedAdmin, authz.PermUserList, 0, true},
+		{"scoped admin deletes user", scopedAdmin, authz.PermUserDelete, 2, false},
+		{"scoped user reads self", scopedUser, authz.PermUserRead, 2, true},
+		{"scope does not extend role", scopedUser, authz.PermUserList, 0, false},
+		{"empty scopes deny everything", authz.Subject{UserID: 1, Role: entity.RoleAdmin, Scopes: []authz.Permission{}}, authz.PermUserRead, 2, false},
+		{"unknown role", authz.Subject{UserID: 3, Role: "guest"}, authz.PermUserRead, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authz.Authorize(tt.subject, tt.perm, tt.targetID)
+			if tt.allowed && err != nil {
+				t.Errorf("expected access, got error: %v", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("expected access to be denied, got nil")
+			}
+		})
+	}
+}
+
+func TestIsKnownPermission(t *testing.T) {
+	if !authz.IsKnownPermission(authz.PermUserRead) {
+		t.Error("expected users:read to be known")
+	}
+	if authz.IsKnownPermission("users:everything") {
+		t.Error("expected unknown permission to be rejected")
+	}
+}
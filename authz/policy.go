@@ -0,0 +1,113 @@
+package authz
+
+import (
+	"api-user-crud-go/entity"
+	"api-user-crud-go/proto"
+)
+
+// Permission adalah aksi yang bisa dilakukan terhadap resource.
+type Permission string
+
+// Daftar permission untuk resource User.
+const (
+	PermUserCreate  Permission = "users:create"
+	PermUserList    Permission = "users:list"
+	PermUserRead    Permission = "users:read"
+	PermUserUpdate  Permission = "users:update"
+	PermUserDelete  Permission = "users:delete"
+	PermUserSetRole Permission = "users:set_role"
+	PermUserUnlock  Permission = "users:unlock"
+	// PermUserSessions mengizinkan melihat dan mencabut session login user lain.
+	PermUserSessions Permission = "users:sessions"
+	// PermUserRestore mengizinkan mengembalikan user yang sudah di-soft-delete.
+	PermUserRestore Permission = "users:restore"
+	// PermUserPurge mengizinkan menghapus user secara permanen (DELETE ?hard=true).
+	PermUserPurge Permission = "users:purge"
+)
+
+// Permission untuk registry client OpenID Connect.
+const (
+	PermOAuthClientManage Permission = "oauth_clients:manage"
+)
+
+// Scope menentukan record mana yang boleh disentuh oleh sebuah permission.
+type Scope int
+
+const (
+	// ScopeNone berarti tidak ada akses.
+	ScopeNone Scope = iota
+	// ScopeOwn berarti hanya boleh mengakses record milik sendiri.
+	ScopeOwn
+	// ScopeAny berarti boleh mengakses semua record.
+	ScopeAny
+)
+
+// rolePermissions memetakan role ke permission yang dimilikinya.
+var rolePermissions = map[string]map[Permission]Scope{
+	entity.RoleAdmin: {
+		PermUserCreate:   ScopeAny,
+		PermUserList:     ScopeAny,
+		PermUserRead:     ScopeAny,
+		PermUserUpdate:   ScopeAny,
+		PermUserDelete:   ScopeAny,
+		PermUserSetRole:  ScopeAny,
+		PermUserUnlock:   ScopeAny,
+		PermUserSessions: ScopeAny,
+		PermUserRestore:  ScopeAny,
+		PermUserPurge:    ScopeAny,
+
+		PermOAuthClientManage: ScopeAny,
+	},
+	entity.RoleUser: {
+		PermUserRead:   ScopeOwn,
+		PermUserUpdate: ScopeOwn,
+	},
+}
+
+// IsKnownPermission mengecek apakah permission dimiliki setidaknya satu role.
+// Dipakai untuk memvalidasi scope API key.
+func IsKnownPermission(perm Permission) bool {
+	for _, perms := range rolePermissions {
+		if _, ok := perms[perm]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutePermissions memetakan route REST ("METHOD /path") ke permission yang dibutuhkan.
+// Path menggunakan pola route Gin (c.FullPath()).
+var RoutePermissions = map[string]Permission{
+	"POST /users":                            PermUserCreate,
+	"GET /users":                             PermUserList,
+	"POST /users:batchCreate":                PermUserCreate,
+	"PATCH /users:batchUpdate":               PermUserUpdate,
+	"POST /users:batchDelete":                PermUserDelete,
+	"GET /users/export":                      PermUserList,
+	"POST /users/import":                     PermUserCreate,
+	"GET /users/import/:job_id":              PermUserCreate,
+	"GET /users/:id":                         PermUserRead,
+	"PUT /users/:id":                         PermUserUpdate,
+	"PATCH /users/:id":                       PermUserUpdate,
+	"DELETE /users/:id":                      PermUserDelete,
+	"POST /users/:id/unlock":                 PermUserUnlock,
+	"POST /users/:id/restore":                PermUserRestore,
+	"GET /users/:id/sessions":                PermUserSessions,
+	"DELETE /users/:id/sessions/:session_id": PermUserSessions,
+
+	"POST /oauth/clients":              PermOAuthClientManage,
+	"GET /oauth/clients":               PermOAuthClientManage,
+	"DELETE /oauth/clients/:client_id": PermOAuthClientManage,
+}
+
+// RPCPermissions memetakan full method gRPC ke permission yang dibutuhkan.
+var RPCPermissions = map[string]Permission{
+	proto.UserService_CreateUser_FullMethodName:       PermUserCreate,
+	proto.UserService_BatchCreateUsers_FullMethodName: PermUserCreate,
+	proto.UserService_GetAllUsers_FullMethodName:      PermUserList,
+	proto.UserService_GetUser_FullMethodName:          PermUserRead,
+	proto.UserService_UpdateUser_FullMethodName:       PermUserUpdate,
+	proto.UserService_DeleteUser_FullMethodName:       PermUserDelete,
+	proto.UserService_UnlockUser_FullMethodName:       PermUserUnlock,
+	proto.UserService_RestoreUser_FullMethodName:      PermUserRestore,
+}
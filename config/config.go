@@ -4,29 +4,94 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config menyimpan semua konfigurasi aplikasi
 type Config struct {
-	HTTPPort       string
-	GRPCPort       string
-	DBDriver       string
-	DBPath         string
-	JWTSecret      string
-	JWTExpiryHours int
-	Environment    string
+	HTTPPort                     string
+	GRPCPort                     string
+	DBDriver                     string
+	DBPath                       string
+	JWTSecret                    string
+	JWTSigningKeyFile            string // private key PEM untuk sign access token (kosong = HS256 dengan JWTSecret)
+	JWTVerificationKeyFiles      string // file PEM tambahan (dipisah koma) yang masih diterima untuk verifikasi
+	JWTExpiryMinutes             int    // umur access token (pendek)
+	RefreshTokenExpiryHours      int    // umur refresh token yang disimpan di server
+	TokenCleanupMinutes          int    // interval job pembersihan token expired
+	DeletedUserRetentionDays     int    // umur user yang di-soft-delete sebelum dihapus permanen (0 = simpan selamanya)
+	BootstrapAdminEmail          string // email yang otomatis menjadi admin saat registrasi
+	CursorSecret                 string // kunci HMAC untuk cursor pagination (default: JWTSecret)
+	PasswordResetExpiryMinutes   int    // umur token reset password
+	NotifierDriver               string // "log" atau "file"
+	NotifierFilePath             string // path file untuk NotifierDriver "file"
+	RequireEmailVerification     bool   // tolak akses ke /users untuk user yang belum verifikasi email
+	EmailVerificationExpiryHours int    // umur link verifikasi email
+	PublicBaseURL                string // URL publik API, dipakai untuk link di email
+	MFAIssuer                    string // nama issuer yang tampil di aplikasi authenticator
+	MFAPendingTokenMinutes       int    // umur token "mfa pending" setelah password benar
+	RequireAdminMFA              bool   // tolak akses admin ke /users sebelum login dengan 2FA
+	LoginMaxAttempts             int    // login gagal per akun sebelum dikunci (0 = nonaktif)
+	LoginMaxAttemptsPerIP        int    // login gagal per IP client sebelum dikunci (0 = nonaktif)
+	LoginLockoutMinutes          int    // lama lockout; juga jendela hitungan login gagal
+	LoginBackoffBaseSeconds      int    // jeda awal exponential backoff per akun (0 = nonaktif)
+	RateLimitAuth                string // rate limit endpoint auth publik per IP, format "20/1m" ("0" = nonaktif)
+	RateLimitAPI                 string // rate limit endpoint ber-JWT per user ID, format "120/1m" ("0" = nonaktif)
+	IdempotencyKeyTTLHours       int    // lama response disimpan untuk replay Idempotency-Key
+	RequireIfMatch               bool   // wajibkan If-Match (REST) / etag (gRPC) saat update & delete user
+	UserCacheControl             string // header Cache-Control untuk GET /users dan /users/:id (kosong = tidak dikirim)
+	ImportMaxUploadMB            int    // ukuran maksimal file upload POST /users/import
+	ImportSyncMaxRows            int    // jumlah baris import maksimal yang diproses langsung; lebih dari itu jadi job background
+	ImportJobTTLHours            int    // lama status & laporan job import disimpan
+	RequestTimeoutSeconds        int    // batas waktu request REST; query dihentikan dan dijawab 504 (0 = nonaktif)
+	TrustedProxies               string // IP/CIDR proxy (dipisah koma) yang boleh mengisi X-Forwarded-For (kosong = tidak ada)
+	OIDCIssuer                   string // issuer OpenID Connect (default: PublicBaseURL)
+	OAuthCodeExpiryMinutes       int    // umur authorization code OAuth
+	Environment                  string
 }
 
 // LoadConfig membaca konfigurasi dari environment variables
 func LoadConfig() *Config {
 	return &Config{
-		HTTPPort:       getEnv("HTTP_PORT", "8080"),
-		GRPCPort:       getEnv("GRPC_PORT", "50051"),
-		DBDriver:       getEnv("DB_DRIVER", "sqlite"),
-		DBPath:         getEnv("DB_PATH", "test.db"),
-		JWTSecret:      getEnv("JWT_SECRET", "default-secret-key-change-in-production"),
-		JWTExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
-		Environment:    getEnv("ENV", "development"),
+		HTTPPort:                     getEnv("HTTP_PORT", "8080"),
+		GRPCPort:                     getEnv("GRPC_PORT", "50051"),
+		DBDriver:                     getEnv("DB_DRIVER", "sqlite"),
+		DBPath:                       getEnv("DB_PATH", "test.db"),
+		JWTSecret:                    getEnv("JWT_SECRET", "default-secret-key-change-in-production"),
+		JWTSigningKeyFile:            getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles:      getEnv("JWT_VERIFICATION_KEY_FILES", ""),
+		JWTExpiryMinutes:             getEnvAsInt("JWT_EXPIRY_MINUTES", 15),
+		RefreshTokenExpiryHours:      getEnvAsInt("REFRESH_TOKEN_EXPIRY_HOURS", 720),
+		TokenCleanupMinutes:          getEnvAsInt("TOKEN_CLEANUP_MINUTES", 10),
+		DeletedUserRetentionDays:     getEnvAsInt("DELETED_USER_RETENTION_DAYS", 0),
+		BootstrapAdminEmail:          getEnv("BOOTSTRAP_ADMIN_EMAIL", ""),
+		CursorSecret:                 getEnv("CURSOR_SECRET", ""),
+		PasswordResetExpiryMinutes:   getEnvAsInt("PASSWORD_RESET_EXPIRY_MINUTES", 30),
+		NotifierDriver:               getEnv("NOTIFIER_DRIVER", "log"),
+		NotifierFilePath:             getEnv("NOTIFIER_FILE_PATH", "notifications.log"),
+		RequireEmailVerification:     getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationExpiryHours: getEnvAsInt("EMAIL_VERIFICATION_EXPIRY_HOURS", 24),
+		PublicBaseURL:                getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		MFAIssuer:                    getEnv("MFA_ISSUER", "api-user-crud-go"),
+		MFAPendingTokenMinutes:       getEnvAsInt("MFA_PENDING_TOKEN_MINUTES", 5),
+		RequireAdminMFA:              getEnvAsBool("REQUIRE_ADMIN_MFA", false),
+		LoginMaxAttempts:             getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginMaxAttemptsPerIP:        getEnvAsInt("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+		LoginLockoutMinutes:          getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+		LoginBackoffBaseSeconds:      getEnvAsInt("LOGIN_BACKOFF_BASE_SECONDS", 1),
+		RateLimitAuth:                getEnv("RATE_LIMIT_AUTH", "20/1m"),
+		RateLimitAPI:                 getEnv("RATE_LIMIT_API", "120/1m"),
+		IdempotencyKeyTTLHours:       getEnvAsInt("IDEMPOTENCY_KEY_TTL_HOURS", 24),
+		RequireIfMatch:               getEnvAsBool("REQUIRE_IF_MATCH", false),
+		UserCacheControl:             getEnv("USER_CACHE_CONTROL", "private, no-cache"),
+		ImportMaxUploadMB:            getEnvAsInt("IMPORT_MAX_UPLOAD_MB", 10),
+		ImportSyncMaxRows:            getEnvAsInt("IMPORT_SYNC_MAX_ROWS", 500),
+		ImportJobTTLHours:            getEnvAsInt("IMPORT_JOB_TTL_HOURS", 24),
+		RequestTimeoutSeconds:        getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 30),
+		TrustedProxies:               getEnv("TRUSTED_PROXIES", ""),
+		OIDCIssuer:                   getEnv("OIDC_ISSUER", ""),
+		OAuthCodeExpiryMinutes:       getEnvAsInt("OAUTH_CODE_EXPIRY_MINUTES", 5),
+		Environment:                  getEnv("ENV", "development"),
 	}
 }
 
@@ -47,6 +112,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBool membaca environment variable sebagai boolean
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 // IsDevelopment mengecek apakah environment adalah development
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -57,6 +131,18 @@ func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
 }
 
+// TrustedProxyList mengembalikan daftar proxy tepercaya. Nil berarti tidak ada proxy
+// yang dipercaya, sehingga IP client selalu diambil dari alamat koneksi.
+func (c *Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, p := range strings.Split(c.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
 // ValidateConfig memvalidasi konfigurasi yang diperlukan
 func (c *Config) ValidateConfig() {
 	if c.JWTSecret == "default-secret-key-change-in-production" && c.IsProduction() {
@@ -3,6 +3,7 @@ package config
 import (
 	"api-user-crud-go/entity"
 	"log"
+	"strings"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -12,20 +13,62 @@ import (
 // Fungsi ini mengembalikan instance *gorm.DB untuk digunakan di layer lain.
 func InitDB() *gorm.DB {
 	cfg := LoadConfig()
-	
+
+	// Transaksi langsung mengambil write lock (BEGIN IMMEDIATE) dan menunggu jika
+	// database sedang dipakai, sehingga transaksi paralel antre alih-alih gagal
+	// dengan "database is locked" saat upgrade dari read ke write lock
+	dsn := cfg.DBPath
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_txlock=immediate&_busy_timeout=5000"
+	}
+
 	// Membuka koneksi ke SQLite database
-	db, err := gorm.Open(sqlite.Open(cfg.DBPath), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Gagal koneksi ke database:", err)
 	}
 
 	// Auto-migrate schema
 	// GORM akan membuat/update tabel berdasarkan struct entity
-	err = db.AutoMigrate(&entity.User{})
+	err = db.AutoMigrate(
+		&entity.User{},
+		&entity.RefreshToken{},
+		&entity.RevokedToken{},
+		&entity.UserTokenRevocation{},
+		&entity.PasswordResetToken{},
+		&entity.RecoveryCode{},
+		&entity.LoginAttempt{},
+		&entity.IdempotencyKey{},
+		&entity.OAuthClient{},
+		&entity.OAuthAuthorizationCode{},
+		&entity.APIKey{},
+		&entity.Session{},
+		&entity.ImportJob{},
+	)
 	if err != nil {
 		log.Fatal("Gagal melakukan migrasi database:", err)
 	}
 
+	// Unique index email lama (tanpa WHERE) digantikan idx_users_email_active,
+	// agar email user yang sudah di-soft-delete bisa didaftarkan ulang
+	if db.Migrator().HasIndex(&entity.User{}, "idx_users_email") {
+		if err := db.Migrator().DropIndex(&entity.User{}, "idx_users_email"); err != nil {
+			log.Fatal("Gagal menghapus index email lama:", err)
+		}
+	}
+
+	// Index untuk keyset pagination pada urutan default (created_at, id)
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id)").Error
+	if err != nil {
+		log.Fatal("Gagal membuat index users:", err)
+	}
+
+	// Index untuk freshness check listing (ETag/Last-Modified)
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_users_updated_at ON users (updated_at)").Error
+	if err != nil {
+		log.Fatal("Gagal membuat index users:", err)
+	}
+
 	log.Println("✓ Database terkoneksi & migrasi berhasil!")
 	return db
 }
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"api-user-crud-go/dto"
+	"api-user-crud-go/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController menangani endpoint API key milik user yang sedang login.
+type APIKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyController membuat instance baru APIKeyController.
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+// CreateAPIKey handler untuk POST /auth/api-keys - Membuat API key baru.
+// Key lengkap hanya ditampilkan di response ini.
+func (ctrl *APIKeyController) CreateAPIKey(c *gin.Context) {
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	key, err := ctrl.apiKeyService.CreateAPIKey(c.Request.Context(), c.GetUint("user_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys handler untuk GET /auth/api-keys - Daftar API key milik user.
+func (ctrl *APIKeyController) ListAPIKeys(c *gin.Context) {
+	keys, err := ctrl.apiKeyService.ListAPIKeys(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// RevokeAPIKey handler untuk DELETE /auth/api-keys/:id - Mencabut API key.
+func (ctrl *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+
+	if err := ctrl.apiKeyService.RevokeAPIKey(c.Request.Context(), c.GetUint("user_id"), uint(id)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -3,7 +3,10 @@ package controller
 import (
 	"api-user-crud-go/dto"
 	"api-user-crud-go/service"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,13 +26,13 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	resp, err := ctrl.authService.Register(req)
+	resp, err := ctrl.authService.Register(c.Request.Context(), c.ClientIP(), c.Request.UserAgent(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -41,15 +44,313 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 	var req dto.LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	resp, err := ctrl.authService.Login(req)
+	resp, err := ctrl.authService.Login(c.Request.Context(), c.ClientIP(), c.Request.UserAgent(), req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondLoginError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// Refresh menukar refresh token dengan access token baru
+func (ctrl *AuthController) Refresh(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	resp, err := ctrl.authService.Refresh(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout mencabut access token yang sedang dipakai (dan refresh token jika dikirim)
+func (ctrl *AuthController) Logout(c *gin.Context) {
+	var req dto.LogoutRequest
+
+	// Body opsional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	err := ctrl.authService.Logout(c.Request.Context(), c.GetUint("user_id"), c.GetString("jti"), c.GetTime("token_expires_at"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll mencabut semua token milik user yang sedang login
+func (ctrl *AuthController) LogoutAll(c *gin.Context) {
+	var req dto.LogoutAllRequest
+
+	// Body opsional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := ctrl.authService.LogoutAll(c.Request.Context(), c.GetUint("user_id"), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions logged out successfully"})
+}
+
+// ChangePassword mengganti password user yang sedang login
+func (ctrl *AuthController) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	resp, err := ctrl.authService.ChangePassword(c.Request.Context(), c.GetUint("user_id"), c.GetUint("session_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ForgotPassword mengirim token reset password ke email user
+func (ctrl *AuthController) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Detail error tidak dikirim agar tidak membocorkan apakah email terdaftar
+	if err := ctrl.authService.ForgotPassword(c.Request.Context(), req); err != nil {
+		c.Error(errors.New("failed to process request"))
+		return
+	}
+
+	// Response sama untuk email terdaftar maupun tidak
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a reset token has been sent"})
+}
+
+// ResetPassword mengganti password menggunakan token reset
+func (ctrl *AuthController) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := ctrl.authService.ResetPassword(c.Request.Context(), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
+}
+
+// VerifyEmail memverifikasi email user dari link yang dikirim lewat notifier
+func (ctrl *AuthController) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Error(invalidParam("token", "is required"))
+		return
+	}
+
+	user, err := ctrl.authService.VerifyEmail(c.Request.Context(), token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "user": user})
+}
+
+// ResendVerification mengirim ulang link verifikasi email
+func (ctrl *AuthController) ResendVerification(c *gin.Context) {
+	if err := ctrl.authService.ResendVerification(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// EnrollMFA memulai enrollment 2FA dan mengembalikan secret serta provisioning URI
+func (ctrl *AuthController) EnrollMFA(c *gin.Context) {
+	resp, err := ctrl.authService.EnrollMFA(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ConfirmMFA mengaktifkan 2FA dengan kode dari aplikasi authenticator
+func (ctrl *AuthController) ConfirmMFA(c *gin.Context) {
+	var req dto.MFACodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	resp, err := ctrl.authService.ConfirmMFA(c.Request.Context(), c.GetUint("user_id"), c.GetUint("session_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DisableMFA menonaktifkan 2FA
+func (ctrl *AuthController) DisableMFA(c *gin.Context) {
+	var req dto.MFACodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := ctrl.authService.DisableMFA(c.Request.Context(), c.GetUint("user_id"), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// VerifyMFA menyelesaikan login 2FA dan mengembalikan token
+func (ctrl *AuthController) VerifyMFA(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	resp, err := ctrl.authService.VerifyMFA(c.Request.Context(), c.ClientIP(), c.Request.UserAgent(), req)
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UnlockUser membuka lockout login user (admin)
+func (ctrl *AuthController) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+
+	if err := ctrl.authService.UnlockAccount(c.Request.Context(), c.GetUint("user_id"), uint(id)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+// ListSessions handler untuk GET /auth/sessions - Daftar session aktif user.
+func (ctrl *AuthController) ListSessions(c *gin.Context) {
+	sessions, err := ctrl.authService.ListSessions(c.Request.Context(), c.GetUint("user_id"), c.GetUint("session_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeSession handler untuk DELETE /auth/sessions/:id - Mencabut session user.
+func (ctrl *AuthController) RevokeSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+
+	if err := ctrl.authService.RevokeSession(c.Request.Context(), c.GetUint("user_id"), uint(sessionID)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// ListUserSessions handler untuk GET /users/:id/sessions - Daftar session user (admin).
+func (ctrl *AuthController) ListUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+
+	sessions, err := ctrl.authService.ListSessions(c.Request.Context(), uint(id), c.GetUint("session_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeUserSession handler untuk DELETE /users/:id/sessions/:session_id -
+// Mencabut session user, misalnya saat perangkatnya hilang (admin).
+func (ctrl *AuthController) RevokeUserSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+	sessionID, err := strconv.ParseUint(c.Param("session_id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("session_id", "must be a valid number"))
+		return
+	}
+
+	if err := ctrl.authService.RevokeSession(c.Request.Context(), uint(id), uint(sessionID)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// respondLoginError memetakan error login: terlalu banyak percobaan -> 429 dengan header
+// Retry-After, selain itu diteruskan ke exception.ErrorHandler (credential salah -> 401).
+func respondLoginError(c *gin.Context, err error) {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) && !requestAborted(c, err) {
+		c.Header("Retry-After", strconv.FormatInt(throttled.RetryAfterSeconds(), 10))
+		c.JSON(http.StatusTooManyRequests, dto.ErrorResponse{
+			Error:   "Too many login attempts",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Error(err)
+}
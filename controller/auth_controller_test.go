@@ -0,0 +1,109 @@
+package controller_test
+
+import (
+	"api-user-crud-go/config"
+	"api-user-crud-go/controller"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/notification"
+	"api-user-crud-go/repository"
+	"api-user-crud-go/service"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB membuka database sqlite in-memory dengan skema lengkap.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&entity.User{}, &entity.LoginAttempt{}, &entity.IdempotencyKey{}, &entity.Session{}, &entity.RevokedToken{}, &entity.UserTokenRevocation{},
+		&entity.RefreshToken{}, &entity.PasswordResetToken{}, &entity.RecoveryCode{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+// newAuthRouter merakit route /auth/login seperti main.go, di atas database sqlite in-memory.
+func newAuthRouter(t *testing.T, cfg *config.Config) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t)
+
+	authService := service.NewAuthService(
+		repository.NewUserRepository(db),
+		repository.NewRefreshTokenRepository(db),
+		repository.NewSessionRepository(db),
+		repository.NewTokenRevocationRepository(db),
+		repository.NewPasswordResetRepository(db),
+		repository.NewRecoveryCodeRepository(db),
+		repository.NewLoginAttemptRepository(db),
+		repository.NewUnitOfWork(db),
+		notification.NewLogNotifier(),
+		jwtkeys.NewHMACKeySet(cfg.JWTSecret),
+		cfg,
+	)
+	authController := controller.NewAuthController(authService)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	router.Use(exception.ErrorHandler())
+	router.POST("/auth/login", authController.Login)
+	return router
+}
+
+func TestLogin_IPLockoutIgnoresSpoofedForwardedFor(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret:             "test-secret",
+		JWTExpiryMinutes:      15,
+		LoginMaxAttempts:      5,
+		LoginMaxAttemptsPerIP: 3,
+		LoginLockoutMinutes:   15,
+	}
+	router := newAuthRouter(t, cfg)
+
+	login := func(i int) *httptest.ResponseRecorder {
+		// Email berbeda tiap percobaan agar yang terkunci hanya IP, bukan akun
+		body := fmt.Sprintf(`{"email":"victim%d@example.com","password":"wrong-password"}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		req.RemoteAddr = "203.0.113.7:40000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < cfg.LoginMaxAttemptsPerIP; i++ {
+		if w := login(i); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	w := login(99)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected IP lockout to hold despite spoofed X-Forwarded-For, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on locked IP")
+	}
+}
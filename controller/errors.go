@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"api-user-crud-go/apperror"
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error handler memakai pola yang sama: error dicatat dengan c.Error dan response
+// dikirim oleh exception.ErrorHandler berdasarkan kategori apperror. Error binding
+// ditandai gin.ErrorTypeBind agar dijawab 400 dengan detail per field.
+
+// invalidParam membuat error validasi untuk parameter path, query, atau form yang tidak valid.
+func invalidParam(field, description string) error {
+	return apperror.Validation(field+" "+description, apperror.FieldViolation{Field: field, Description: description})
+}
+
+// requestAborted mengecek apakah err terjadi karena client membatalkan request
+// atau request melewati batas waktu. Context request ikut diperiksa karena service
+// bisa membungkus error query yang terhenti menjadi error lain.
+func requestAborted(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || c.Request.Context().Err() != nil
+}
@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"api-user-crud-go/dto"
+	"api-user-crud-go/service"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthController menangani endpoint provider OpenID Connect.
+type OAuthController struct {
+	oauthService service.OAuthService
+}
+
+// NewOAuthController membuat instance baru OAuthController.
+func NewOAuthController(oauthService service.OAuthService) *OAuthController {
+	return &OAuthController{oauthService: oauthService}
+}
+
+// loginPage adalah halaman login minimal untuk /oauth/authorize.
+// Parameter authorization request dibawa sebagai hidden field.
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Login</title></head>
+<body>
+<h1>Login</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}{{if .MFAToken}}<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label>Kode 2FA atau recovery code <input name="code" autocomplete="one-time-code" required autofocus></label>
+{{else}}<label>Email <input type="email" name="email" value="{{.Email}}" required autofocus></label>
+<label>Password <input type="password" name="password" required></label>
+{{end}}<button type="submit">Login</button>
+</form>
+</body>
+</html>
+`))
+
+// errorPage ditampilkan jika error tidak boleh di-redirect ke client.
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Authorization error</title></head>
+<body><h1>Authorization error</h1><p>{{.}}</p></body>
+</html>
+`))
+
+// Discovery handler untuk GET /.well-known/openid-configuration.
+func (ctrl *OAuthController) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ctrl.oauthService.Discovery())
+}
+
+// AuthorizeForm handler untuk GET /oauth/authorize - Menampilkan form login.
+func (ctrl *OAuthController) AuthorizeForm(c *gin.Context) {
+	var req dto.AuthorizeRequest
+	_ = c.ShouldBindQuery(&req)
+
+	if err := ctrl.oauthService.ValidateAuthorizeRequest(c.Request.Context(), req); err != nil {
+		respondAuthorizeError(c, req, err)
+		return
+	}
+
+	renderLoginPage(c, http.StatusOK, req, "", "")
+}
+
+// Authorize handler untuk POST /oauth/authorize - Memproses form login lalu
+// redirect ke client dengan authorization code.
+func (ctrl *OAuthController) Authorize(c *gin.Context) {
+	var req dto.AuthorizeRequest
+	_ = c.ShouldBind(&req)
+
+	resp, err := ctrl.oauthService.Authorize(c.Request.Context(), c.ClientIP(), req)
+	if requestAborted(c, err) {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			respondAuthorizeError(c, req, err)
+			return
+		}
+
+		// Login gagal: tampilkan form lagi (langkah 2FA tetap di langkah 2FA)
+		status := http.StatusUnauthorized
+		var throttled *service.LoginThrottledError
+		if errors.As(err, &throttled) {
+			status = http.StatusTooManyRequests
+			c.Header("Retry-After", strconv.FormatInt(throttled.RetryAfterSeconds(), 10))
+		}
+		renderLoginPage(c, status, req, req.MFAToken, err.Error())
+		return
+	}
+
+	if resp.MFARequired {
+		renderLoginPage(c, http.StatusOK, req, resp.MFAToken, "")
+		return
+	}
+
+	c.Redirect(http.StatusFound, resp.RedirectURL)
+}
+
+// Token handler untuk POST /oauth/token - Menukar authorization code dengan token.
+// Client confidential mengirim kredensial lewat HTTP Basic atau form (client_secret).
+func (ctrl *OAuthController) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req dto.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	// client_secret_basic: id dan secret di-encode form-urlencoded (RFC 6749 bagian 2.3.1)
+	basicID, basicSecret, usedBasic := c.Request.BasicAuth()
+	if usedBasic {
+		if req.ClientSecret != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "multiple client authentication methods"})
+			return
+		}
+		req.ClientID, _ = url.QueryUnescape(basicID)
+		req.ClientSecret, _ = url.QueryUnescape(basicSecret)
+	}
+
+	resp, err := ctrl.oauthService.Token(c.Request.Context(), req)
+	if requestAborted(c, err) {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if !errors.As(err, &oauthErr) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+
+		status := http.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+			if usedBasic {
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+		}
+		c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UserInfo handler untuk GET/POST /userinfo - Standard claims pemilik access token.
+// "oauth_scope" hanya di-set oleh middleware.UserInfoAuth untuk access token OAuth.
+func (ctrl *OAuthController) UserInfo(c *gin.Context) {
+	info, err := ctrl.oauthService.UserInfo(c.Request.Context(), c.GetUint("user_id"), c.GetString("oauth_scope"))
+	if requestAborted(c, err) {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// CreateClient handler untuk POST /oauth/clients - Mendaftarkan client (admin).
+func (ctrl *OAuthController) CreateClient(c *gin.Context) {
+	var req dto.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	client, err := ctrl.oauthService.CreateClient(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, client)
+}
+
+// ListClients handler untuk GET /oauth/clients - Daftar client (admin).
+func (ctrl *OAuthController) ListClients(c *gin.Context) {
+	clients, err := ctrl.oauthService.ListClients(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": clients})
+}
+
+// DeleteClient handler untuk DELETE /oauth/clients/:client_id - Menghapus client (admin).
+func (ctrl *OAuthController) DeleteClient(c *gin.Context) {
+	if err := ctrl.oauthService.DeleteClient(c.Request.Context(), c.Param("client_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Client deleted successfully"})
+}
+
+// respondAuthorizeError me-redirect error ke client jika aman, selain itu
+// menampilkannya ke user (client_id atau redirect_uri tidak valid).
+func respondAuthorizeError(c *gin.Context, req dto.AuthorizeRequest, err error) {
+	var oauthErr *service.OAuthError
+	if errors.As(err, &oauthErr) && oauthErr.Redirect {
+		c.Redirect(http.StatusFound, oauthErr.RedirectURL(req))
+		return
+	}
+
+	setPageHeaders(c)
+	c.Status(http.StatusBadRequest)
+	_ = errorPage.Execute(c.Writer, err.Error())
+}
+
+// renderLoginPage menampilkan form login (atau form kode 2FA jika mfaToken diisi).
+func renderLoginPage(c *gin.Context, status int, req dto.AuthorizeRequest, mfaToken, errMsg string) {
+	setPageHeaders(c)
+	c.Status(status)
+	_ = loginPage.Execute(c.Writer, map[string]interface{}{
+		"Params": map[string]string{
+			"response_type":         req.ResponseType,
+			"client_id":             req.ClientID,
+			"redirect_uri":          req.RedirectURI,
+			"scope":                 req.Scope,
+			"state":                 req.State,
+			"nonce":                 req.Nonce,
+			"code_challenge":        req.CodeChallenge,
+			"code_challenge_method": req.CodeChallengeMethod,
+		},
+		"Email":    req.Email,
+		"MFAToken": mfaToken,
+		"Error":    errMsg,
+	})
+}
+
+// setPageHeaders memasang header untuk halaman HTML: tidak di-cache dan tidak boleh di-frame.
+func setPageHeaders(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+}
@@ -1,14 +1,27 @@
 package controller
 
 import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/authz"
 	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/middleware"
 	"api-user-crud-go/service"
+	"cmp"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// mimeMergePatch adalah media type JSON Merge Patch (RFC 7396).
+const mimeMergePatch = "application/merge-patch+json"
+
 // UserController menangani HTTP requests untuk endpoint User.
 // Controller menerima request, memanggil service, dan mengembalikan response.
 type UserController struct {
@@ -26,117 +39,432 @@ func (ctrl *UserController) CreateUser(c *gin.Context) {
 
 	// Bind dan validasi input JSON
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "Invalid input",
-			Message: err.Error(),
-		})
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Hanya role tertentu yang boleh menentukan role user lain
+	if !canSetRole(c, req.Role) {
 		return
 	}
 
 	// Panggil service untuk membuat user
-	user, err := ctrl.userService.CreateUser(req)
+	user, err := ctrl.userService.CreateUser(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error:   "Failed to create user",
-			Message: err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", service.FormatETag(user.Version))
 	c.JSON(http.StatusCreated, user)
 }
 
-// GetUsers handler untuk GET /users - Mengambil semua user.
+// GetUsers handler untuk GET /users - Mengambil daftar user.
+// Mendukung paginasi (page/page_size atau limit/offset), filter, dan sort.
 func (ctrl *UserController) GetUsers(c *gin.Context) {
-	users, err := ctrl.userService.GetAllUsers()
+	var req dto.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Freshness dihitung sebelum listing: jika ada write di antaranya, ETag lebih
+	// tua dari body sehingga request berikutnya tetap mendapat data terbaru
+	etag, lastModified, err := ctrl.userService.ListFreshness(c.Request.Context())
+	if err == nil && notModified(c, etag, lastModified) {
+		return
+	}
+
+	users, err := ctrl.userService.GetAllUsers(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error:   "Failed to retrieve users",
-			Message: err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, users)
 }
 
+// exportContentTypes memetakan format export ke Content-Type response.
+var exportContentTypes = map[string]string{
+	service.FormatCSV:    "text/csv; charset=utf-8",
+	service.FormatNDJSON: "application/x-ndjson",
+	service.FormatJSON:   "application/json; charset=utf-8",
+}
+
+// ExportUsers handler untuk GET /users/export - Mengunduh user sebagai CSV, NDJSON, atau JSON.
+// Response di-stream langsung dari database tanpa menampung seluruh data di memori.
+func (ctrl *UserController) ExportUsers(c *gin.Context) {
+	var req dto.ExportUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	format := cmp.Or(req.Format, service.FormatCSV)
+	c.Header("Content-Type", exportContentTypes[format])
+	c.Header("Content-Disposition", `attachment; filename="users.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	err := ctrl.userService.ExportUsers(c.Request.Context(), req, c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// Status dan sebagian body sudah terkirim; client akan menerima file terpotong
+		log.Printf("Export users terputus: %v", err)
+		return
+	}
+
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	c.Error(err)
+}
+
 // GetUser handler untuk GET /users/:id - Mengambil user berdasarkan ID.
 func (ctrl *UserController) GetUser(c *gin.Context) {
 	// Parse ID dari parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "Invalid ID",
-			Message: "ID must be a valid number",
-		})
+		c.Error(invalidParam("id", "must be a valid number"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByID(uint(id))
+	user, err := ctrl.userService.GetUserByID(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Error:   "User not found",
-			Message: err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	if notModified(c, service.FormatETag(user.Version), user.UpdatedAt) {
+		return
+	}
 	c.JSON(http.StatusOK, user)
 }
 
-// UpdateUser handler untuk PUT /users/:id - Mengupdate user.
+// notModified mengisi header ETag dan Last-Modified, lalu mengecek conditional GET.
+// If-None-Match didahulukan; If-Modified-Since hanya dipakai jika If-None-Match
+// tidak dikirim (RFC 9110). Jika representasi client masih segar, 304 dikirim
+// dan fungsi mengembalikan true.
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	fresh := false
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		fresh = etagMatches(inm, etag)
+	} else if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		fresh = err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	if fresh {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+	}
+	return fresh
+}
+
+// etagMatches membandingkan daftar entity tag If-None-Match dengan etag
+// memakai perbandingan weak (prefix W/ diabaikan).
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateUser handler untuk PUT /users/:id - Mengganti seluruh data user.
 func (ctrl *UserController) UpdateUser(c *gin.Context) {
 	// Parse ID dari parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "Invalid ID",
-			Message: "ID must be a valid number",
-		})
+		c.Error(invalidParam("id", "must be a valid number"))
 		return
 	}
 
 	var req dto.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "Invalid input",
-			Message: err.Error(),
-		})
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if !canSetRole(c, req.Role) {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	user, err := ctrl.userService.UpdateUser(c.Request.Context(), uint(id), version, req)
+	respondUserUpdate(c, user, err)
+}
+
+// PatchUser handler untuk PATCH /users/:id - Mengupdate sebagian data user
+// dengan JSON Merge Patch (RFC 7396). Field null di-reset ke default.
+func (ctrl *UserController) PatchUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+
+	if ct := c.ContentType(); ct != mimeMergePatch && ct != gin.MIMEJSON {
+		c.Error(apperror.UnsupportedMediaType("Content-Type must be " + mimeMergePatch))
+		return
+	}
+
+	var req dto.PatchUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	user, err := ctrl.userService.UpdateUser(uint(id), req)
+	// Reset role ke default (null) juga termasuk mengubah role
+	if req.Role.Set && !canSetRole(c, cmp.Or(req.Role.Value, entity.RoleUser)) {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	user, err := ctrl.userService.PatchUser(c.Request.Context(), uint(id), version, req)
+	respondUserUpdate(c, user, err)
+}
+
+// respondUserUpdate mengirim response untuk PUT dan PATCH /users/:id.
+func respondUserUpdate(c *gin.Context, user *dto.UserResponse, err error) {
+	if errors.Is(err, service.ErrVersionConflict) {
+		respondPreconditionFailed(c, err)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Error:   "Failed to update user",
-			Message: err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", service.FormatETag(user.Version))
 	c.JSON(http.StatusOK, user)
 }
 
+// ifMatchVersion membaca versi yang diharapkan client dari header If-Match
+// (0 jika tidak dikirim). Jika tag tidak mungkin cocok, response 412 langsung
+// dikirim dan fungsi mengembalikan false.
+func ifMatchVersion(c *gin.Context) (uint, bool) {
+	version, err := service.ParseETag(c.GetHeader("If-Match"))
+	if err != nil {
+		respondPreconditionFailed(c, err)
+		return 0, false
+	}
+	return version, true
+}
+
+// respondPreconditionFailed mencatat error 412 untuk exception.ErrorHandler ketika
+// If-Match tidak cocok dengan versi user.
+func respondPreconditionFailed(c *gin.Context, err error) {
+	c.Error(apperror.PreconditionFailed(err.Error(), err))
+}
+
 // DeleteUser handler untuk DELETE /users/:id - Menghapus user.
+// Secara default user hanya di-soft-delete; ?hard=true menghapus permanen (admin).
 func (ctrl *UserController) DeleteUser(c *gin.Context) {
 	// Parse ID dari parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "Invalid ID",
-			Message: "ID must be a valid number",
-		})
+		c.Error(invalidParam("id", "must be a valid number"))
 		return
 	}
 
-	err = ctrl.userService.DeleteUser(uint(id))
+	hard := false
+	if raw := c.Query("hard"); raw != "" {
+		hard, err = strconv.ParseBool(raw)
+		if err != nil {
+			c.Error(invalidParam("hard", "must be a boolean"))
+			return
+		}
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if hard {
+		if !hasPermission(c, authz.PermUserPurge) {
+			return
+		}
+		err = ctrl.userService.PurgeUser(c.Request.Context(), uint(id), version)
+	} else {
+		err = ctrl.userService.DeleteUser(c.Request.Context(), uint(id), version)
+	}
+	if errors.Is(err, service.ErrVersionConflict) {
+		respondPreconditionFailed(c, err)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Error:   "Failed to delete user",
-			Message: err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
+	if hard {
+		c.JSON(http.StatusOK, gin.H{"message": "User permanently deleted"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
+
+// RestoreUser handler untuk POST /users/:id/restore - Mengembalikan user yang sudah dihapus.
+func (ctrl *UserController) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(invalidParam("id", "must be a valid number"))
+		return
+	}
+
+	user, err := ctrl.userService.RestoreUser(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", service.FormatETag(user.Version))
+	c.JSON(http.StatusOK, user)
+}
+
+// BatchCreateUsers handler untuk POST /users:batchCreate - Membuat banyak user sekaligus.
+func (ctrl *UserController) BatchCreateUsers(c *gin.Context) {
+	var req dto.BatchCreateUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	for _, item := range req.Users {
+		if item.Role != "" {
+			if !canSetRole(c, item.Role) {
+				return
+			}
+			break
+		}
+	}
+
+	results, err := ctrl.userService.BatchCreateUsers(c.Request.Context(), req.Users, req.Mode != dto.BatchModeBestEffort)
+	respondBatch(c, req.Mode, nil, results, err, http.StatusCreated)
+}
+
+// BatchUpdateUsers handler untuk PATCH /users:batchUpdate - Merge patch banyak user sekaligus.
+func (ctrl *UserController) BatchUpdateUsers(c *gin.Context) {
+	var req dto.BatchUpdateUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	ids := make([]uint, len(req.Users))
+	roleChecked := false
+	for i, item := range req.Users {
+		ids[i] = item.ID
+		if item.Role.Set && !roleChecked {
+			if !canSetRole(c, cmp.Or(item.Role.Value, entity.RoleUser)) {
+				return
+			}
+			roleChecked = true
+		}
+	}
+
+	results, err := ctrl.userService.BatchUpdateUsers(c.Request.Context(), req.Users, req.Mode != dto.BatchModeBestEffort)
+	respondBatch(c, req.Mode, ids, results, err, http.StatusOK)
+}
+
+// BatchDeleteUsers handler untuk POST /users:batchDelete - Menghapus banyak user sekaligus (soft delete).
+func (ctrl *UserController) BatchDeleteUsers(c *gin.Context) {
+	var req dto.BatchDeleteUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	ids := make([]uint, len(req.Users))
+	for i, item := range req.Users {
+		ids[i] = item.ID
+	}
+
+	results, err := ctrl.userService.BatchDeleteUsers(c.Request.Context(), req.Users, req.Mode != dto.BatchModeBestEffort)
+	respondBatch(c, req.Mode, ids, results, err, http.StatusOK)
+}
+
+// respondBatch mengirim response endpoint batch dengan status per item.
+// ids berisi ID user per item (nil untuk create, ID diambil dari user baru).
+// Mode best_effort selalu 200; mode atomic memakai status item pertama yang gagal.
+func respondBatch(c *gin.Context, mode string, ids []uint, results []service.BatchResult, err error, successStatus int) {
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resp := dto.BatchUsersResponse{
+		Mode:    cmp.Or(mode, dto.BatchModeAtomic),
+		Results: make([]dto.BatchItemResult, len(results)),
+	}
+	httpStatus := http.StatusOK
+	for i, result := range results {
+		item := dto.BatchItemResult{Index: i, Status: successStatus, User: result.User}
+		if ids != nil {
+			item.ID = ids[i]
+		} else if result.User != nil {
+			item.ID = result.User.ID
+		}
+
+		if result.Err != nil {
+			item.Status = batchItemStatus(result.Err)
+			item.Error = result.Err.Error()
+			resp.Failed++
+			if resp.Mode == dto.BatchModeAtomic && httpStatus == http.StatusOK && !errors.Is(result.Err, service.ErrBatchAborted) {
+				httpStatus = item.Status
+			}
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results[i] = item
+	}
+
+	c.JSON(httpStatus, resp)
+}
+
+// batchItemStatus memetakan error satu item batch ke kode HTTP endpoint tunggalnya.
+func batchItemStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, service.ErrBatchAborted):
+		return http.StatusFailedDependency
+	}
+	return exception.HTTPStatus(err)
+}
+
+// canSetRole mengecek permission untuk mengisi field role.
+// Jika tidak diizinkan, error 403 dicatat untuk exception.ErrorHandler dan fungsi mengembalikan false.
+func canSetRole(c *gin.Context, role string) bool {
+	if role == "" {
+		return true
+	}
+	return hasPermission(c, authz.PermUserSetRole)
+}
+
+// hasPermission mengecek permission tambahan di luar permission route.
+// Jika tidak diizinkan, error 403 dicatat untuk exception.ErrorHandler dan fungsi mengembalikan false.
+func hasPermission(c *gin.Context, perm authz.Permission) bool {
+	if err := authz.Authorize(middleware.CurrentSubject(c), perm, 0); err != nil {
+		c.Error(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package controller_test
+
+import (
+	"api-user-crud-go/config"
+	"api-user-crud-go/controller"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/repository"
+	"api-user-crud-go/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userRouter adalah route /users seperti main.go beserta token admin untuk memanggilnya.
+type userRouter struct {
+	*gin.Engine
+	token string
+}
+
+// newUserRouter merakit route /users dan /users:batchX dengan middleware yang sama seperti main.go.
+func newUserRouter(t *testing.T) *userRouter {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWTSecret: "test-secret", JWTExpiryMinutes: 15, IdempotencyKeyTTLHours: 24}
+	db := newTestDB(t)
+
+	userRepo := repository.NewUserRepository(db)
+	revocationRepo := repository.NewTokenRevocationRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	keys := jwtkeys.NewHMACKeySet(cfg.JWTSecret)
+	userController := controller.NewUserController(service.NewUserService(userRepo, cfg))
+
+	admin := &entity.User{Name: "Admin", Email: "admin@example.com", Age: 30, Role: entity.RoleAdmin}
+	if err := userRepo.Create(context.Background(), admin); err != nil {
+		t.Fatalf("failed to create admin: %v", err)
+	}
+	token, err := middleware.GenerateToken(admin, 0, keys, cfg)
+	if err != nil {
+		t.Fatalf("GenerateToken returned unexpected error: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(exception.ErrorHandler())
+	userMiddleware := []gin.HandlerFunc{
+		middleware.JWTAuth(keys, revocationRepo, nil),
+		middleware.RequireVerifiedEmail(cfg),
+		middleware.RequireAdminMFA(cfg),
+		middleware.Authorize(),
+	}
+	userRoutes := router.Group("/users", userMiddleware...)
+	userRoutes.POST("", middleware.Idempotency(idempotencyRepo, cfg), userController.CreateUser)
+	userRoutes.GET("/:id", userController.GetUser)
+	userRoutes.PATCH("/:id", middleware.RequireIfMatch(cfg), userController.PatchUser)
+
+	userBatchRoutes := router.Group("", userMiddleware...)
+	userBatchRoutes.POST("/users\\:batchCreate", middleware.Idempotency(idempotencyRepo, cfg), userController.BatchCreateUsers)
+	userBatchRoutes.PATCH("/users\\:batchUpdate", userController.BatchUpdateUsers)
+	userBatchRoutes.POST("/users\\:batchDelete", userController.BatchDeleteUsers)
+
+	// main.go menjalankan router lewat Run, satu-satunya jalur yang mengubah "\:" di
+	// route tree menjadi ":" literal. Alamat tidak valid membuat Run langsung kembali
+	// setelah route tree diperbarui.
+	if err := router.Run("127.0.0.1:-1"); err == nil {
+		t.Fatal("expected Run with an invalid address to fail")
+	}
+
+	return &userRouter{Engine: router, token: token}
+}
+
+// do mengirim request sebagai admin; headers berisi pasangan nama dan nilai header.
+func (r *userRouter) do(method, path, contentType, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) dto.ErrorResponse {
+	t.Helper()
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected JSON error body, got %q: %v", w.Body.String(), err)
+	}
+	return resp
+}
+
+func TestPatchUser_PreconditionErrorsUseSharedErrorBody(t *testing.T) {
+	router := newUserRouter(t)
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		status      int
+		title       string
+	}{
+		{"unsupported media type", "text/plain", "", http.StatusUnsupportedMediaType, "Unsupported Media Type"},
+		{"stale If-Match", "application/merge-patch+json", `"999"`, http.StatusPreconditionFailed, "Precondition Failed"},
+		{"malformed If-Match", "application/merge-patch+json", `W/"1"`, http.StatusPreconditionFailed, "Precondition Failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := router.do(http.MethodPatch, "/users/1", tt.contentType, `{"age":31}`, "If-Match", tt.ifMatch)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if resp := decodeError(t, w); resp.Error != tt.title || resp.Message == "" {
+				t.Errorf("unexpected error body %+v", resp)
+			}
+		})
+	}
+}
+
+func TestCreateUser_IdempotentReplayKeepsETag(t *testing.T) {
+	router := newUserRouter(t)
+	body := `{"name":"Bob","email":"bob@example.com","age":30}`
+
+	first := router.do(http.MethodPost, "/users", gin.MIMEJSON, body, "Idempotency-Key", "create-bob")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag on the first response")
+	}
+
+	replay := router.do(http.MethodPost, "/users", gin.MIMEJSON, body, "Idempotency-Key", "create-bob")
+	if replay.Code != http.StatusCreated || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replayed 201, got %d (replayed=%q)", replay.Code, replay.Header().Get("Idempotent-Replayed"))
+	}
+	if got := replay.Header().Get("ETag"); got != etag {
+		t.Errorf("expected replayed ETag %s, got %q", etag, got)
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("expected replayed body %s, got %s", first.Body.String(), replay.Body.String())
+	}
+}
+
+func TestBatchRoutes_ReachHandlersThroughRouter(t *testing.T) {
+	router := newUserRouter(t)
+
+	decodeBatch := func(t *testing.T, w *httptest.ResponseRecorder) dto.BatchUsersResponse {
+		t.Helper()
+		var resp dto.BatchUsersResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("expected batch response, got %q: %v", w.Body.String(), err)
+		}
+		return resp
+	}
+
+	// Route /users\:batchX harus cocok dengan path /users:batchX, lolos Authorize
+	// (authz.RoutePermissions), dan tidak tertangkap route /users/:id
+	w := router.do(http.MethodPost, "/users:batchCreate", gin.MIMEJSON,
+		`{"users":[{"name":"Bob","email":"bob@example.com","age":30},{"name":"Carol","email":"carol@example.com","age":40}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("batchCreate: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	created := decodeBatch(t, w)
+	if created.Succeeded != 2 || len(created.Results) != 2 {
+		t.Fatalf("batchCreate: unexpected response %+v", created)
+	}
+	bob, carol := created.Results[0].ID, created.Results[1].ID
+
+	w = router.do(http.MethodPatch, "/users:batchUpdate", gin.MIMEJSON,
+		fmt.Sprintf(`{"users":[{"id":%d,"age":31},{"id":%d,"age":41}]}`, bob, carol))
+	if w.Code != http.StatusOK {
+		t.Fatalf("batchUpdate: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if updated := decodeBatch(t, w); updated.Succeeded != 2 || updated.Results[0].User.Age != 31 {
+		t.Errorf("batchUpdate: unexpected response %+v", updated)
+	}
+
+	w = router.do(http.MethodPost, "/users:batchDelete", gin.MIMEJSON,
+		fmt.Sprintf(`{"users":[{"id":%d},{"id":%d}]}`, bob, carol))
+	if w.Code != http.StatusOK {
+		t.Fatalf("batchDelete: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if deleted := decodeBatch(t, w); deleted.Succeeded != 2 {
+		t.Errorf("batchDelete: unexpected response %+v", deleted)
+	}
+	if w := router.do(http.MethodGet, fmt.Sprintf("/users/%d", bob), "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected deleted user to be gone, got %d", w.Code)
+	}
+}
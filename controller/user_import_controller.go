@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"api-user-crud-go/authz"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserImportController menangani endpoint import user dari file.
+type UserImportController struct {
+	importService  service.UserImportService
+	maxUploadBytes int64
+}
+
+// NewUserImportController membuat instance baru UserImportController.
+// Upload yang lebih besar dari maxUploadBytes ditolak dengan 413.
+func NewUserImportController(importService service.UserImportService, maxUploadBytes int64) *UserImportController {
+	return &UserImportController{importService: importService, maxUploadBytes: maxUploadBytes}
+}
+
+// ImportUsers handler untuk POST /users/import - Membuat user dari file CSV/NDJSON (multipart).
+// Import kecil dijawab 200 dengan laporan; import besar atau async=true dijawab
+// 202 dengan job yang bisa dipantau di header Location. Import yang berhenti di
+// tengah dijawab dengan status error-nya, tetapi body tetap berisi laporan sebagian
+// (field error dan not_processed) karena user dari batch sebelumnya sudah tersimpan.
+func (ctrl *UserImportController) ImportUsers(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ctrl.maxUploadBytes)
+
+	var req dto.ImportUsersRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondUploadError(c, err)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	opts := service.ImportOptions{
+		Format:   req.Format,
+		Filename: fileHeader.Filename,
+		DryRun:   req.DryRun,
+		Async:    req.Async,
+		// Baris dengan kolom role hanya diterima jika pemanggil boleh mengatur role
+		AllowRole: authz.Authorize(middleware.CurrentSubject(c), authz.PermUserSetRole, 0) == nil,
+	}
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &opts.Mapping); err != nil {
+			c.Error(invalidParam("mapping", "must be a JSON object of column name to field name"))
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer file.Close()
+
+	report, job, err := ctrl.importService.ImportUsers(c.Request.Context(), c.GetUint("user_id"), file, opts)
+	if err != nil && (report == nil || requestAborted(c, err)) {
+		c.Error(err)
+		return
+	}
+	if err != nil {
+		c.JSON(exception.HTTPStatus(err), report)
+		return
+	}
+
+	if job != nil {
+		c.Header("Location", "/users/import/"+job.ID)
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetImportJob handler untuk GET /users/import/:job_id - Status dan laporan job import.
+func (ctrl *UserImportController) GetImportJob(c *gin.Context) {
+	job, err := ctrl.importService.GetImportJob(c.Request.Context(), c.GetUint("user_id"), c.Param("job_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// respondUploadError mengirim 413 jika upload melebihi batas, selain itu error binding (400).
+func respondUploadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, dto.ErrorResponse{
+			Error:   "File too large",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.Error(err).SetType(gin.ErrorTypeBind)
+}
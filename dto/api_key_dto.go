@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// CreateAPIKeyRequest adalah DTO untuk membuat API key.
+// Scopes berisi permission (misalnya "users:read"); kosong berarti key mewarisi
+// semua permission role user. ExpiresInDays 0 berarti key tidak pernah expired.
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// APIKeyResponse adalah DTO untuk response API key.
+// Key hanya dikembalikan sekali, saat key dibuat.
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
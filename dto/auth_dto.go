@@ -1,15 +1,23 @@
 package dto
 
+import "time"
+
 // LoginRequest adalah DTO untuk login
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 }
 
-// LoginResponse adalah DTO untuk response login
+// LoginResponse adalah DTO untuk response login.
+// Jika user mengaktifkan 2FA, login dengan password hanya mengembalikan
+// MFARequired dan MFAToken; token baru diterbitkan setelah POST /auth/mfa/verify.
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string        `json:"token,omitempty"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresIn    int64         `json:"expires_in,omitempty"` // umur access token dalam detik
+	User         *UserResponse `json:"user,omitempty"`
+	MFARequired  bool          `json:"mfa_required,omitempty"`
+	MFAToken     string        `json:"mfa_token,omitempty"`
 }
 
 // RegisterRequest adalah DTO untuk registrasi user baru
@@ -19,3 +27,73 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 	Age      int    `json:"age" binding:"required,min=1"`
 }
+
+// RefreshTokenRequest adalah DTO untuk menukar refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest adalah DTO untuk logout.
+// RefreshToken opsional; jika diisi, refresh token tersebut ikut dicabut.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutAllRequest adalah DTO untuk logout dari semua sesi.
+// Before opsional; default-nya waktu saat request diproses.
+type LogoutAllRequest struct {
+	Before *time.Time `json:"before"`
+}
+
+// ChangePasswordRequest adalah DTO untuk mengganti password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// ForgotPasswordRequest adalah DTO untuk meminta token reset password
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest adalah DTO untuk reset password dengan token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// MFACodeRequest adalah DTO berisi kode TOTP (atau recovery code)
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAVerifyRequest adalah DTO untuk menyelesaikan login 2FA
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFAEnrollResponse adalah DTO response saat memulai enrollment 2FA
+type MFAEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://, untuk QR code
+}
+
+// MFAConfirmResponse adalah DTO response setelah 2FA aktif.
+// Recovery code hanya ditampilkan sekali; sesi lain dicabut dan token baru diterbitkan.
+type MFAConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	LoginResponse
+}
+
+// SessionResponse adalah DTO untuk satu session login (perangkat) user.
+// Current bernilai true untuk session yang dipakai oleh request saat ini.
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// ImportUsersRequest adalah field form POST /users/import (multipart/form-data).
+// File dikirim di field "file".
+type ImportUsersRequest struct {
+	Format  string `form:"format" binding:"omitempty,oneof=csv ndjson"` // default dari ekstensi file
+	Mapping string `form:"mapping"`                                     // JSON {"kolom di file": "name|email|age|role"}
+	DryRun  bool   `form:"dry_run"`                                     // hanya validasi, tidak ada user yang dibuat
+	Async   bool   `form:"async"`                                       // paksa jalan di background
+}
+
+// ImportError adalah error satu baris file import.
+type ImportError struct {
+	Line  int    `json:"line"` // nomor baris di file (header CSV = baris 1)
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportReport adalah hasil import (atau validasi dry run) satu file.
+type ImportReport struct {
+	DryRun          bool          `json:"dry_run"`
+	Total           int           `json:"total"`   // jumlah baris data
+	Valid           int           `json:"valid"`   // baris yang lolos validasi
+	Created         int           `json:"created"` // user yang dibuat (selalu 0 saat dry run)
+	Failed          int           `json:"failed"`
+	Errors          []ImportError `json:"errors"`
+	ErrorsTruncated bool          `json:"errors_truncated,omitempty"` // daftar errors dipotong
+	NotProcessed    int           `json:"not_processed,omitempty"`    // baris yang tidak diproses karena import berhenti
+	Error           string        `json:"error,omitempty"`            // alasan import berhenti sebelum semua baris diproses
+}
+
+// ImportJobResponse adalah status import yang berjalan di background.
+type ImportJobResponse struct {
+	ID          string        `json:"id"`
+	Status      string        `json:"status"` // pending, running, completed, failed
+	DryRun      bool          `json:"dry_run"`
+	Total       int           `json:"total"`
+	Processed   int           `json:"processed"`
+	Report      *ImportReport `json:"report,omitempty"` // terisi setelah job selesai; laporan sebagian jika failed
+	Error       string        `json:"error,omitempty"`  // alasan job gagal
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+}
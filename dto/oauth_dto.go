@@ -0,0 +1,95 @@
+package dto
+
+import "time"
+
+// CreateOAuthClientRequest adalah DTO untuk mendaftarkan client OpenID Connect.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	Public       bool     `json:"public"` // true untuk SPA/mobile: tanpa client secret, wajib PKCE
+}
+
+// OAuthClientResponse adalah DTO untuk response client OpenID Connect.
+// ClientSecret hanya dikembalikan sekali, saat client dibuat.
+type OAuthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuthorizeRequest adalah parameter authorization request OIDC (query GET atau
+// form POST /oauth/authorize) beserta isian form login.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	Prompt              string `form:"prompt"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+
+	// Isian form login (hanya pada POST)
+	Email    string `form:"email"`
+	Password string `form:"password"`
+	MFAToken string `form:"mfa_token"`
+	Code     string `form:"code"`
+}
+
+// AuthorizeResponse adalah hasil login di /oauth/authorize: redirect ke client
+// dengan authorization code, atau permintaan kode 2FA.
+type AuthorizeResponse struct {
+	RedirectURL string
+	MFARequired bool
+	MFAToken    string
+}
+
+// TokenRequest adalah DTO untuk POST /oauth/token (form-urlencoded).
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// TokenResponse adalah DTO untuk response token endpoint (RFC 6749 bagian 5.1).
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// UserInfoResponse adalah DTO untuk response /userinfo (standard claims OIDC).
+// Name hanya diisi untuk scope profile; Email dan EmailVerified untuk scope email.
+type UserInfoResponse struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// OpenIDConfiguration adalah dokumen discovery /.well-known/openid-configuration.
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ResponseModesSupported            []string `json:"response_modes_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "encoding/json"
+
+// Optional membedakan field JSON yang tidak dikirim dengan field yang dikirim.
+// Dipakai untuk JSON Merge Patch (RFC 7396): field yang tidak ada di body tidak
+// disentuh, sedangkan null berarti reset ke nilai default (zero value).
+type Optional[T any] struct {
+	Set   bool // field ada di body
+	Value T    // zero value jika dikirim null
+}
+
+// OptionalOf membuat Optional yang terisi dengan v.
+func OptionalOf[T any](v T) Optional[T] {
+	return Optional[T]{Set: true, Value: v}
+}
+
+// UnmarshalJSON menandai field sebagai dikirim; null menghasilkan zero value.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		var zero T
+		o.Value = zero
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
@@ -1,33 +1,165 @@
 package dto
 
+import (
+	"api-user-crud-go/apperror"
+	"time"
+)
+
 // CreateUserRequest adalah DTO untuk membuat user baru.
 // Digunakan untuk menerima input dari POST /users.
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required"`
 	Email string `json:"email" binding:"required,email"`
 	Age   int    `json:"age" binding:"required,min=1"`
+	Role  string `json:"role" binding:"omitempty,oneof=admin user"`
 }
 
-// UpdateUserRequest adalah DTO untuk mengupdate user.
-// Digunakan untuk menerima input dari PUT /users/:id.
+// UpdateUserRequest adalah DTO untuk mengganti seluruh data user.
+// Digunakan untuk menerima input dari PUT /users/:id; field opsional yang
+// tidak dikirim di-reset ke default (age 0, role user).
 type UpdateUserRequest struct {
-	Name  string `json:"name" binding:"omitempty"`
-	Email string `json:"email" binding:"omitempty,email"`
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
 	Age   int    `json:"age" binding:"omitempty,min=1"`
+	Role  string `json:"role" binding:"omitempty,oneof=admin user"`
+}
+
+// PatchUserRequest adalah DTO untuk PATCH /users/:id (JSON Merge Patch, RFC 7396).
+// Hanya field yang ada di body yang diterapkan; null me-reset field ke default.
+type PatchUserRequest struct {
+	Name  Optional[string] `json:"name"`
+	Email Optional[string] `json:"email"`
+	Age   Optional[int]    `json:"age"`
+	Role  Optional[string] `json:"role"`
 }
 
 // UserResponse adalah DTO untuk response user.
 // Digunakan untuk mengembalikan data user ke client.
 type UserResponse struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Age   int    `json:"age"`
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Age           int    `json:"age"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+	Version       uint   `json:"version"` // sama dengan ETag; kirim di If-Match saat update
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"` // juga dikirim sebagai header Last-Modified
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // hanya terisi untuk user yang sudah di-soft-delete
 }
 
 // ErrorResponse adalah DTO untuk response error.
 // Digunakan untuk error handling yang konsisten.
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Error   string                    `json:"error"`
+	Message string                    `json:"message,omitempty"`
+	Details []apperror.FieldViolation `json:"details,omitempty"` // field yang tidak valid (error validasi)
+}
+
+// ListUsersRequest adalah DTO untuk query GET /users.
+// Halaman bisa ditentukan dengan page/page_size, limit/offset, atau cursor.
+// Sort berformat "field:asc|desc", beberapa field dipisah koma.
+type ListUsersRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+	Name     string `form:"name"`
+	Email    string `form:"email"`
+	MinAge   *int   `form:"min_age" binding:"omitempty,min=0"`
+	MaxAge   *int   `form:"max_age" binding:"omitempty,min=0"`
+	Sort     string `form:"sort"`
+	Cursor   string `form:"cursor"` // next_cursor/prev_cursor dari response sebelumnya
+
+	IncludeDeleted bool `form:"include_deleted"` // ikut sertakan user yang sudah dihapus
+	OnlyDeleted    bool `form:"only_deleted"`    // hanya user yang sudah dihapus
+}
+
+// UserListResponse adalah DTO untuk response listing user.
+// Total tidak diisi pada mode cursor.
+type UserListResponse struct {
+	Data       []UserResponse `json:"data"`
+	Total      *int64         `json:"total,omitempty"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	PrevCursor string         `json:"prev_cursor,omitempty"`
+}
+
+// Mode operasi batch user.
+const (
+	// BatchModeAtomic menerapkan semua item dalam satu transaksi: satu item gagal, semuanya dibatalkan.
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort menerapkan setiap item yang valid dan melaporkan status per item.
+	BatchModeBestEffort = "best_effort"
+)
+
+// BatchCreateUsersRequest adalah DTO untuk POST /users:batchCreate.
+// Item divalidasi satu per satu dengan aturan yang sama seperti CreateUserRequest,
+// sehingga mode best_effort bisa melaporkan item yang tidak valid tanpa menolak seluruh batch.
+type BatchCreateUsersRequest struct {
+	Mode  string              `json:"mode" binding:"omitempty,oneof=atomic best_effort"` // default atomic
+	Users []CreateUserRequest `json:"users" binding:"required,min=1,max=500"`
+}
+
+// BatchUpdateUserItem adalah satu item PATCH /users:batchUpdate: ID user, ETag
+// opsional (seperti If-Match), dan field merge patch seperti PATCH /users/:id.
+type BatchUpdateUserItem struct {
+	ID   uint   `json:"id"`
+	ETag string `json:"etag,omitempty"`
+	PatchUserRequest
+}
+
+// BatchUpdateUsersRequest adalah DTO untuk PATCH /users:batchUpdate.
+type BatchUpdateUsersRequest struct {
+	Mode  string                `json:"mode" binding:"omitempty,oneof=atomic best_effort"` // default atomic
+	Users []BatchUpdateUserItem `json:"users" binding:"required,min=1,max=500"`
+}
+
+// BatchDeleteUserItem adalah satu item POST /users:batchDelete (soft delete).
+type BatchDeleteUserItem struct {
+	ID   uint   `json:"id"`
+	ETag string `json:"etag,omitempty"`
+}
+
+// BatchDeleteUsersRequest adalah DTO untuk POST /users:batchDelete.
+type BatchDeleteUsersRequest struct {
+	Mode  string                `json:"mode" binding:"omitempty,oneof=atomic best_effort"` // default atomic
+	Users []BatchDeleteUserItem `json:"users" binding:"required,min=1,max=500"`
+}
+
+// BatchItemResult adalah status satu item batch. Status memakai kode HTTP yang
+// sama dengan endpoint tunggalnya; 424 berarti item valid tetapi dibatalkan
+// karena item lain gagal pada mode atomic.
+type BatchItemResult struct {
+	Index  int           `json:"index"`
+	Status int           `json:"status"`
+	ID     uint          `json:"id,omitempty"`
+	User   *UserResponse `json:"user,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// BatchUsersResponse adalah DTO untuk response endpoint batch user.
+// Results berurutan sesuai item di request.
+type BatchUsersResponse struct {
+	Mode      string            `json:"mode"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// ExportUsersRequest adalah DTO untuk query GET /users/export.
+// Filter sama dengan ListUsersRequest; seluruh user yang cocok diekspor tanpa paginasi.
+type ExportUsersRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson json"` // default csv
+	Name   string `form:"name"`
+	Email  string `form:"email"`
+	MinAge *int   `form:"min_age" binding:"omitempty,min=0"`
+	MaxAge *int   `form:"max_age" binding:"omitempty,min=0"`
+
+	IncludeDeleted bool `form:"include_deleted"`
+	OnlyDeleted    bool `form:"only_deleted"`
 }
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey adalah kunci statis milik user untuk akses machine-to-machine
+// (cron job, script). Key asli hanya ditampilkan sekali saat dibuat; yang
+// disimpan adalah Prefix (untuk lookup) dan hash SHA-256 dari key lengkap.
+type APIKey struct {
+	ID         uint       `gorm:"primarykey"`
+	UserID     uint       `gorm:"index;not null"`
+	Name       string     `gorm:"not null"`
+	Prefix     string     `gorm:"uniqueIndex;not null"`
+	KeyHash    string     `gorm:"not null"`
+	Scopes     string     // permission dipisah spasi; kosong = semua permission role user
+	ExpiresAt  *time.Time // nil = tidak pernah expired
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// ScopeList mengembalikan daftar scope key, atau nil jika key tidak dibatasi.
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Fields(k.Scopes)
+}
+
+// IsExpired mengecek apakah key sudah melewati masa berlakunya.
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !k.ExpiresAt.After(now)
+}
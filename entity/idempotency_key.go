@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// IdempotencyKey menyimpan hasil request yang dikirim dengan Idempotency-Key,
+// sehingga retry dengan key yang sama mendapat response yang sama tanpa menulis ulang.
+type IdempotencyKey struct {
+	Key          string     `gorm:"primaryKey;column:idempotency_key"` // "<operasi>:<user_id>:<key dari client>"
+	RequestHash  string     `gorm:"not null"`                          // SHA-256 dari isi request
+	StatusCode   int        // status HTTP response (REST); 0 untuk gRPC
+	ResponseBody []byte     // body response (JSON untuk REST, protobuf untuk gRPC)
+	Headers      []byte     // header response yang ikut di-replay, mis. ETag (JSON); kosong untuk gRPC
+	CompletedAt  *time.Time // nil = request pertama masih diproses
+	ExpiresAt    time.Time  `gorm:"index;not null"`
+	CreatedAt    time.Time
+}
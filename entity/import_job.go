@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Status ImportJob.
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportJob menyimpan status import user yang dijalankan di background,
+// sehingga client bisa polling progres dan laporan error-nya.
+type ImportJob struct {
+	ID          string `gorm:"primaryKey"`     // ID acak untuk polling GET /users/import/:job_id
+	UserID      uint   `gorm:"index;not null"` // user yang memulai import; hanya dia yang bisa melihat job
+	Status      string `gorm:"not null"`
+	DryRun      bool
+	Total       int       // jumlah baris data di file
+	Processed   int       // jumlah baris yang sudah diproses
+	Report      []byte    // dto.ImportReport (JSON), terisi setelah job selesai
+	Error       string    // alasan job gagal (bukan error per baris)
+	ExpiresAt   time.Time `gorm:"index;not null"`
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// LoginAttempt mencatat login gagal berturut-turut untuk satu kunci
+// (akun atau IP client) beserta status lockout-nya.
+type LoginAttempt struct {
+	Key           string     `gorm:"primaryKey;column:attempt_key"` // "account:<email>" atau "ip:<alamat>"
+	Failures      int        `gorm:"not null;default:0"`
+	LastFailureAt time.Time  `gorm:"not null"`
+	LockedUntil   *time.Time // nil = tidak sedang dikunci
+	ExpiresAt     time.Time  `gorm:"index;not null"` // setelah ini hitungan dianggap reset dan baris boleh dibersihkan
+	UpdatedAt     time.Time
+}
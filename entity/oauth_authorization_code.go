@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// OAuthAuthorizationCode adalah authorization code sekali pakai dari /oauth/authorize.
+// Seperti token lain, hanya hash SHA-256-nya yang disimpan.
+type OAuthAuthorizationCode struct {
+	CodeHash      string `gorm:"primaryKey"`
+	ClientID      string `gorm:"index;not null"`
+	UserID        uint   `gorm:"not null"`
+	RedirectURI   string `gorm:"not null"`
+	Scope         string `gorm:"not null"`
+	Nonce         string
+	CodeChallenge string    // PKCE (S256); kosong jika client tidak memakai PKCE
+	AuthTime      time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
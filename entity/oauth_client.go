@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// OAuthClient adalah aplikasi yang terdaftar untuk login lewat OpenID Connect.
+// Client tanpa secret adalah public client (SPA, mobile) dan wajib memakai PKCE.
+type OAuthClient struct {
+	ID           string `gorm:"primaryKey;column:client_id"`
+	Name         string `gorm:"not null"`
+	SecretHash   string // hash SHA-256 dari client secret; kosong = public client
+	RedirectURIs string `gorm:"not null"` // dipisah spasi, dicocokkan persis
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// IsPublic mengecek apakah client tidak memiliki secret.
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// RedirectURIList mengembalikan daftar redirect URI yang terdaftar.
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+// AllowsRedirectURI mengecek apakah redirect URI terdaftar (perbandingan string persis).
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIList() {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
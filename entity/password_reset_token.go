@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// PasswordResetToken adalah token sekali pakai untuk reset password.
+// Seperti refresh token, hanya hash SHA-256-nya yang disimpan.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
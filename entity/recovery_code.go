@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// RecoveryCode adalah kode cadangan sekali pakai untuk login 2FA saat
+// authenticator tidak tersedia. Hanya hash SHA-256-nya yang disimpan.
+type RecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	CodeHash  string `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// RefreshToken menyimpan refresh token opaque di sisi server.
+// Token asli tidak pernah disimpan, hanya hash SHA-256-nya.
+// Setiap rotasi menghasilkan token baru dengan FamilyID yang sama,
+// sehingga seluruh rantai token bisa dicabut sekaligus ketika terdeteksi reuse.
+type RefreshToken struct {
+	ID        uint       `gorm:"primarykey"`
+	UserID    uint       `gorm:"index;not null"`
+	TokenHash string     `gorm:"uniqueIndex;not null"`
+	FamilyID  string     `gorm:"index;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // diisi saat token dirotasi
+	RevokedAt *time.Time // diisi saat family dicabut
+	CreatedAt time.Time
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// RevokedToken adalah daftar access token (berdasarkan jti) yang sudah dicabut
+// sebelum waktu expired-nya. Baris dihapus oleh job background setelah
+// ExpiresAt lewat, karena token tersebut sudah tidak valid dengan sendirinya.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// UserTokenRevocation menandai bahwa semua token milik user yang diterbitkan
+// sebelum RevokedBefore tidak berlaku lagi ("logout dari semua perangkat").
+type UserTokenRevocation struct {
+	UserID        uint      `gorm:"primaryKey"`
+	RevokedBefore time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+	UpdatedAt     time.Time
+}
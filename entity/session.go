@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Session adalah satu login user di satu perangkat. Setiap session memiliki
+// satu family refresh token (FamilyID); access token membawa ID session di
+// claim "sid" sehingga mencabut session langsung membatalkan semua tokennya.
+type Session struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     uint   `gorm:"index;not null"`
+	FamilyID   string `gorm:"uniqueIndex;not null"`
+	UserAgent  string
+	IPAddress  string
+	LastSeenAt time.Time  `gorm:"not null"` // diperbarui setiap token diterbitkan (login/refresh)
+	ExpiresAt  time.Time  `gorm:"index;not null"`
+	RevokedAt  *time.Time // diisi saat session dicabut
+	CreatedAt  time.Time
+}
+
+// IsActive mengecek apakah session belum dicabut dan belum expired.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(now)
+}
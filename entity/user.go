@@ -1,13 +1,31 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Role yang dikenal oleh sistem.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
 
 // User merepresentasikan entitas User di database.
 // Struct ini digunakan oleh repository layer untuk operasi database.
+// Email hanya unik di antara user yang belum di-soft-delete (partial index),
+// sehingga email user yang sudah dihapus bisa didaftarkan ulang.
 type User struct {
-	gorm.Model          // Embed gorm.Model (ID, CreatedAt, UpdatedAt, DeletedAt)
-	Name       string   `json:"name" gorm:"not null"`
-	Email      string   `json:"email" gorm:"uniqueIndex;not null"`
-	Password   string   `json:"-" gorm:"not null"` // json:"-" agar tidak ter-serialize
-	Age        int      `json:"age"`
+	gorm.Model                 // Embed gorm.Model (ID, CreatedAt, UpdatedAt, DeletedAt)
+	Name            string     `json:"name" gorm:"not null"`
+	Email           string     `json:"email" gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL;not null"`
+	Password        string     `json:"-" gorm:"not null"` // json:"-" agar tidak ter-serialize
+	Age             int        `json:"age"`
+	Role            string     `json:"role" gorm:"not null;default:user"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                 // nil = email belum diverifikasi
+	MFASecret       string     `json:"-"`                                 // secret TOTP (base32); terisi sejak enrollment dimulai
+	MFAEnabledAt    *time.Time `json:"-"`                                 // nil = 2FA belum aktif
+	MFALastStep     int64      `json:"-"`                                 // time step TOTP terakhir yang dipakai, untuk mencegah replay
+	Version         uint       `json:"version" gorm:"not null;default:1"` // naik setiap update, untuk optimistic locking (ETag)
 }
@@ -8,22 +8,25 @@ import (
 )
 
 // ErrorHandler adalah middleware untuk menangani error secara global.
-// Middleware ini akan menangkap panic dan error yang tidak tertangani.
+// Handler cukup mencatat error dengan c.Error lalu return; middleware ini mengirim
+// response yang konsisten dengan status dari kategori apperror (lihat HTTPStatus).
+// Error binding (gin.ErrorTypeBind) dijawab 400 dengan detail per field.
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Lanjutkan ke handler berikutnya
 		c.Next()
 
-		// Cek apakah ada error yang terjadi
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
+		// Cek apakah ada error yang belum dijawab handler
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
 
-			// Response dengan error yang konsisten
-			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-				Error:   "Internal Server Error",
-				Message: err.Error(),
-			})
+		err := c.Errors.Last()
+		if err.IsType(gin.ErrorTypeBind) {
+			RespondError(c, BindError(err.Err))
+			return
 		}
+		RespondError(c, err.Err)
 	}
 }
 
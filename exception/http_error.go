@@ -0,0 +1,130 @@
+package exception
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/dto"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// StatusClientClosedRequest adalah status non-standar (dari nginx) untuk request
+// yang dibatalkan client sebelum response selesai dibuat.
+const StatusClientClosedRequest = 499
+
+// HTTPStatus memetakan error ke status HTTP berdasarkan kategori apperror.
+// Error yang tidak dikenal dianggap error internal (500).
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(err, apperror.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, apperror.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, apperror.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, apperror.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperror.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, apperror.ErrPreconditionRequired):
+		return http.StatusPreconditionRequired
+	case errors.Is(err, apperror.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, apperror.ErrAlreadyExists), errors.Is(err, apperror.ErrConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// RespondError mengirim response error yang konsisten untuk err. Context request
+// yang sudah dibatalkan atau lewat deadline dilaporkan sebagai 499/504 walaupun
+// service membungkus error query yang terhenti menjadi error lain.
+func RespondError(c *gin.Context, err error) {
+	if ctxErr := c.Request.Context().Err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	status := HTTPStatus(err)
+	c.JSON(status, dto.ErrorResponse{
+		Error:   errorTitle(status),
+		Message: err.Error(),
+		Details: apperror.FieldsOf(err),
+	})
+}
+
+// BindError mengubah error binding request (JSON rusak, tag binding gagal) menjadi
+// error validasi dengan detail per field.
+func BindError(err error) error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return apperror.Validation(err.Error())
+	}
+
+	fields := make([]apperror.FieldViolation, len(invalid))
+	messages := make([]string, len(invalid))
+	for i, fe := range invalid {
+		name := snakeCase(fe.Field())
+		fields[i] = apperror.FieldViolation{Field: name, Description: describeTag(fe)}
+		messages[i] = name + " " + fields[i].Description
+	}
+	return apperror.Validation(strings.Join(messages, "; "), fields...)
+}
+
+// errorTitle mengembalikan ringkasan error untuk field "error" pada response.
+func errorTitle(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "Invalid input"
+	case StatusClientClosedRequest:
+		return "Request canceled"
+	case http.StatusGatewayTimeout:
+		return "Request timed out"
+	case http.StatusInternalServerError:
+		return "Internal Server Error"
+	}
+	return http.StatusText(status)
+}
+
+// describeTag menjelaskan aturan binding yang dilanggar dalam bahasa yang mudah dibaca.
+func describeTag(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+	return fmt.Sprintf("failed on the %q rule", fe.Tag())
+}
+
+// snakeCase mengubah nama field struct (PageSize) menjadi nama field JSON/query (page_size).
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Awal kata baru: huruf besar setelah huruf kecil, atau akhir singkatan (MFACode -> mfa_code)
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package grpcserver
+
+import (
+	"api-user-crud-go/dto"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/proto"
+	"api-user-crud-go/service"
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// userAgent mengambil user-agent client gRPC dari metadata (dicatat di session).
+func userAgent(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("user-agent"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Register menangani RPC Register - registrasi user baru.
+// Semantik error sama dengan AuthController.Register (400 -> InvalidArgument,
+// 409 -> AlreadyExists).
+func (s *UserGRPCServer) Register(ctx context.Context, req *proto.RegisterRequest) (*proto.AuthResponse, error) {
+	registerReq := dto.RegisterRequest{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+		Age:      int(req.Age),
+	}
+
+	// Validasi memakai tag binding yang sama dengan REST
+	if err := binding.Validator.ValidateStruct(&registerReq); err != nil {
+		return nil, statusError(exception.BindError(err))
+	}
+
+	resp, err := s.authService.Register(ctx, middleware.PeerIP(ctx), userAgent(ctx), registerReq)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toProtoAuthResponse(resp), nil
+}
+
+// Login menangani RPC Login - autentikasi user.
+// Semantik error sama dengan AuthController.Login (401 -> Unauthenticated,
+// 429 -> ResourceExhausted).
+func (s *UserGRPCServer) Login(ctx context.Context, req *proto.LoginRequest) (*proto.AuthResponse, error) {
+	loginReq := dto.LoginRequest{
+		Email:    req.Email,
+		Password: req.Password,
+	}
+
+	if err := binding.Validator.ValidateStruct(&loginReq); err != nil {
+		return nil, statusError(exception.BindError(err))
+	}
+
+	resp, err := s.authService.Login(ctx, middleware.PeerIP(ctx), userAgent(ctx), loginReq)
+	if err != nil {
+		return nil, loginError(err)
+	}
+
+	return toProtoAuthResponse(resp), nil
+}
+
+// RefreshToken menangani RPC RefreshToken - menukar refresh token (rotasi).
+// Semantik error sama dengan AuthController.Refresh (401 -> Unauthenticated).
+func (s *UserGRPCServer) RefreshToken(ctx context.Context, req *proto.RefreshTokenRequest) (*proto.AuthResponse, error) {
+	refreshReq := dto.RefreshTokenRequest{RefreshToken: req.RefreshToken}
+
+	if err := binding.Validator.ValidateStruct(&refreshReq); err != nil {
+		return nil, statusError(exception.BindError(err))
+	}
+
+	resp, err := s.authService.Refresh(ctx, refreshReq)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return toProtoAuthResponse(resp), nil
+}
+
+// VerifyMFA menangani RPC VerifyMFA - menyelesaikan login 2FA.
+// Semantik error sama dengan AuthController.VerifyMFA (401 -> Unauthenticated).
+func (s *UserGRPCServer) VerifyMFA(ctx context.Context, req *proto.VerifyMFARequest) (*proto.AuthResponse, error) {
+	verifyReq := dto.MFAVerifyRequest{
+		MFAToken: req.MfaToken,
+		Code:     req.Code,
+	}
+
+	if err := binding.Validator.ValidateStruct(&verifyReq); err != nil {
+		return nil, statusError(exception.BindError(err))
+	}
+
+	resp, err := s.authService.VerifyMFA(ctx, middleware.PeerIP(ctx), userAgent(ctx), verifyReq)
+	if err != nil {
+		return nil, loginError(err)
+	}
+
+	return toProtoAuthResponse(resp), nil
+}
+
+// UnlockUser menangani RPC UnlockUser - membuka lockout login user (admin).
+func (s *UserGRPCServer) UnlockUser(ctx context.Context, req *proto.UnlockUserRequest) (*proto.UnlockUserResponse, error) {
+	if req.Id == 0 {
+		return nil, invalidField("id", "must be greater than 0")
+	}
+
+	adminID := middleware.SubjectFromContext(ctx).UserID
+	if err := s.authService.UnlockAccount(ctx, adminID, uint(req.Id)); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &proto.UnlockUserResponse{Message: "User unlocked successfully"}, nil
+}
+
+// loginError memetakan error login ke status gRPC, sama seperti respondLoginError di REST:
+// login yang di-throttle menjadi ResourceExhausted, sisanya lewat statusError.
+func loginError(err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return statusError(err)
+}
+
+// toProtoAuthResponse adalah helper untuk konversi dari dto.LoginResponse ke proto.AuthResponse.
+func toProtoAuthResponse(resp *dto.LoginResponse) *proto.AuthResponse {
+	authResp := &proto.AuthResponse{
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		MfaRequired:  resp.MFARequired,
+		MfaToken:     resp.MFAToken,
+	}
+	if resp.User != nil {
+		authResp.User = toProtoUser(resp.User)
+	}
+	return authResp
+}
@@ -0,0 +1,321 @@
+package grpcserver_test
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/notification"
+	"api-user-crud-go/proto"
+	"api-user-crud-go/repository"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ==========================================
+// MOCK REFRESH TOKEN REPOSITORY (in-memory)
+// ==========================================
+
+type mockRefreshTokenRepo struct {
+	tokens map[uint]*entity.RefreshToken
+	nextID uint
+}
+
+func newMockRefreshTokenRepo() *mockRefreshTokenRepo {
+	return &mockRefreshTokenRepo{tokens: make(map[uint]*entity.RefreshToken), nextID: 1}
+}
+
+func (m *mockRefreshTokenRepo) Create(ctx context.Context, token *entity.RefreshToken) error {
+	token.ID = m.nextID
+	token.CreatedAt = time.Now()
+	m.nextID++
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) FindByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, apperror.NotFound("refresh token not found")
+}
+
+func (m *mockRefreshTokenRepo) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	t, ok := m.tokens[id]
+	if !ok || t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error {
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ==========================================
+// TESTS: Register / Login / RefreshToken
+// ==========================================
+
+// ==========================================
+// MOCK SESSION REPOSITORY (in-memory)
+// ==========================================
+
+// mockSessionRepo adalah implementasi mock dari repository.SessionRepository.
+type mockSessionRepo struct {
+	sessions map[uint]*entity.Session
+	nextID   uint
+}
+
+func newMockSessionRepo() *mockSessionRepo {
+	return &mockSessionRepo{sessions: make(map[uint]*entity.Session), nextID: 1}
+}
+
+func (m *mockSessionRepo) Create(ctx context.Context, session *entity.Session) error {
+	session.ID = m.nextID
+	session.CreatedAt = time.Now()
+	m.nextID++
+	copied := *session
+	m.sessions[session.ID] = &copied
+	return nil
+}
+
+func (m *mockSessionRepo) FindByID(ctx context.Context, id uint) (*entity.Session, error) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, apperror.NotFound("session not found")
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (m *mockSessionRepo) FindByFamily(ctx context.Context, familyID string) (*entity.Session, error) {
+	for id, s := range m.sessions {
+		if s.FamilyID == familyID {
+			return m.FindByID(ctx, id)
+		}
+	}
+	return nil, apperror.NotFound("session not found")
+}
+
+func (m *mockSessionRepo) FindActiveByUser(ctx context.Context, userID uint, now time.Time) ([]entity.Session, error) {
+	return nil, nil
+}
+
+func (m *mockSessionRepo) Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *mockSessionRepo) Revoke(ctx context.Context, id uint) error { return nil }
+
+func (m *mockSessionRepo) RevokeByFamily(ctx context.Context, familyID string) error { return nil }
+
+func (m *mockSessionRepo) RevokeAllForUser(ctx context.Context, userID uint, before time.Time, exceptID uint) error {
+	return nil
+}
+
+func (m *mockSessionRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ==========================================
+// MOCK UNIT OF WORK
+// ==========================================
+
+// mockUnitOfWork menjalankan fn dengan repository mock yang sama.
+type mockUnitOfWork struct {
+	repos repository.Repositories
+}
+
+func (m *mockUnitOfWork) WithTx(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return fn(m.repos)
+}
+
+// ==========================================
+// MOCK LOGIN ATTEMPT REPOSITORY (in-memory)
+// ==========================================
+
+// mockLoginAttemptRepo adalah implementasi mock dari repository.LoginAttemptRepository.
+type mockLoginAttemptRepo struct {
+	attempts map[string]*entity.LoginAttempt
+}
+
+func newMockLoginAttemptRepo() *mockLoginAttemptRepo {
+	return &mockLoginAttemptRepo{attempts: make(map[string]*entity.LoginAttempt)}
+}
+
+func (m *mockLoginAttemptRepo) Get(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	a, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *mockLoginAttemptRepo) RecordFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (*entity.LoginAttempt, error) {
+	a, ok := m.attempts[key]
+	if !ok || a.ExpiresAt.Before(now) {
+		a = &entity.LoginAttempt{Key: key}
+		m.attempts[key] = a
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	a.ExpiresAt = expiresAt
+	return m.Get(ctx, key)
+}
+
+func (m *mockLoginAttemptRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	if a, ok := m.attempts[key]; ok {
+		a.LockedUntil = &until
+		a.ExpiresAt = until
+	}
+	return nil
+}
+
+func (m *mockLoginAttemptRepo) Reset(ctx context.Context, key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *mockLoginAttemptRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for key, a := range m.attempts {
+		if a.ExpiresAt.Before(now) {
+			delete(m.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// discardNotifier adalah Notifier yang membuang semua pesan.
+type discardNotifier struct{}
+
+func (discardNotifier) Send(msg notification.Message) error { return nil }
+
+func TestGRPC_VerifyMFA_InvalidToken(t *testing.T) {
+	srv := newServer()
+
+	_, err := srv.VerifyMFA(ctx, &proto.VerifyMFARequest{MfaToken: "garbage", Code: "123456"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestGRPC_Register_Success(t *testing.T) {
+	srv := newServer()
+
+	resp, err := srv.Register(ctx, &proto.RegisterRequest{
+		Name:     "Alice",
+		Email:    "alice@example.com",
+		Password: "password123",
+		Age:      25,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Error("expected access and refresh token")
+	}
+	if resp.User.GetEmail() != "alice@example.com" {
+		t.Errorf("expected email 'alice@example.com', got '%s'", resp.User.GetEmail())
+	}
+}
+
+func TestGRPC_Register_Validation(t *testing.T) {
+	srv := newServer()
+
+	_, err := srv.Register(ctx, &proto.RegisterRequest{
+		Name:     "Alice",
+		Email:    "not-an-email",
+		Password: "123",
+		Age:      25,
+	})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+
+	// Field yang salah dikirim sebagai errdetails.BadRequest
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	if !slices.Equal(fields, []string{"email", "password"}) {
+		t.Errorf("expected violations for email and password, got %v", fields)
+	}
+}
+
+func TestGRPC_Register_DuplicateEmail(t *testing.T) {
+	srv := newServer()
+	req := &proto.RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "password123", Age: 25}
+
+	srv.Register(ctx, req)
+	_, err := srv.Register(ctx, req)
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected AlreadyExists, got %v", err)
+	}
+}
+
+func TestGRPC_Login(t *testing.T) {
+	srv := newServer()
+	srv.Register(ctx, &proto.RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "password123", Age: 25})
+
+	resp, err := srv.Login(ctx, &proto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Token == "" {
+		t.Error("expected non-empty token")
+	}
+
+	_, err = srv.Login(ctx, &proto.LoginRequest{Email: "alice@example.com", Password: "wrong-password"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestGRPC_RefreshToken(t *testing.T) {
+	srv := newServer()
+	registered, _ := srv.Register(ctx, &proto.RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "password123", Age: 25})
+
+	resp, err := srv.RefreshToken(ctx, &proto.RefreshTokenRequest{RefreshToken: registered.RefreshToken})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.RefreshToken == registered.RefreshToken {
+		t.Error("expected rotated refresh token")
+	}
+
+	_, err = srv.RefreshToken(ctx, &proto.RefreshTokenRequest{RefreshToken: registered.RefreshToken})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated on reuse, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package grpcserver
+
+import (
+	"api-user-crud-go/apperror"
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcCode memetakan error ke kode gRPC berdasarkan kategori apperror.
+// Error yang tidak dikenal dianggap error internal.
+func grpcCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, apperror.ErrValidation):
+		return codes.InvalidArgument
+	case errors.Is(err, apperror.ErrUnauthorized):
+		return codes.Unauthenticated
+	case errors.Is(err, apperror.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, apperror.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, apperror.ErrAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, apperror.ErrPreconditionFailed), errors.Is(err, apperror.ErrPreconditionRequired):
+		return codes.FailedPrecondition
+	case errors.Is(err, apperror.ErrUnsupportedMediaType):
+		return codes.InvalidArgument
+	case errors.Is(err, apperror.ErrConflict):
+		return codes.Aborted
+	}
+	return codes.Internal
+}
+
+// statusError mengubah error service menjadi status gRPC. Error validasi dengan
+// detail field membawa errdetails.BadRequest agar client bisa menandai field yang salah.
+// Error yang sudah berupa status gRPC dikembalikan apa adanya.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	st := status.New(grpcCode(err), err.Error())
+	fields := apperror.FieldsOf(err)
+	if len(fields) == 0 {
+		return st.Err()
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+	for i, f := range fields {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Description}
+	}
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// invalidField membuat status InvalidArgument untuk satu field request yang tidak valid.
+func invalidField(field, description string) error {
+	return statusError(apperror.Validation(field+" "+description, apperror.FieldViolation{Field: field, Description: description}))
+}
@@ -1,13 +1,22 @@
 package grpcserver
 
 import (
+	"api-user-crud-go/authz"
 	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/middleware"
 	"api-user-crud-go/proto"
 	"api-user-crud-go/service"
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc"
 )
 
 // UserGRPCServer mengimplementasikan UserServiceServer yang dihasilkan dari proto.
@@ -15,60 +24,160 @@ import (
 type UserGRPCServer struct {
 	proto.UnimplementedUserServiceServer
 	userService service.UserService
+	authService service.AuthService
 }
 
 // NewUserGRPCServer membuat instance baru UserGRPCServer.
-func NewUserGRPCServer(userService service.UserService) *UserGRPCServer {
-	return &UserGRPCServer{userService: userService}
+func NewUserGRPCServer(userService service.UserService, authService service.AuthService) *UserGRPCServer {
+	return &UserGRPCServer{userService: userService, authService: authService}
 }
 
 // CreateUser menangani RPC CreateUser - membuat user baru.
 func (s *UserGRPCServer) CreateUser(ctx context.Context, req *proto.CreateUserRequest) (*proto.UserMessage, error) {
-	// Validasi input
-	if req.Name == "" || req.Email == "" {
-		return nil, status.Error(codes.InvalidArgument, "name and email are required")
+	createReq := dto.CreateUserRequest{
+		Name:  req.Name,
+		Email: req.Email,
+		Age:   int(req.Age),
+		Role:  req.Role,
+	}
+
+	// Validasi memakai tag binding yang sama dengan REST (format email, age >= 1, role)
+	if err := binding.Validator.ValidateStruct(&createReq); err != nil {
+		return nil, statusError(exception.BindError(err))
 	}
-	if req.Age <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "age must be greater than 0")
+	if err := checkRole(ctx, req.Role); err != nil {
+		return nil, err
 	}
 
 	// Panggil service yang sudah ada
-	resp, err := s.userService.CreateUser(dto.CreateUserRequest{
-		Name:  req.Name,
-		Email: req.Email,
-		Age:   int(req.Age),
-	})
+	resp, err := s.userService.CreateUser(ctx, createReq)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
+		return nil, statusError(err)
 	}
 
 	return toProtoUser(resp), nil
 }
 
-// GetAllUsers menangani RPC GetAllUsers - mengambil semua user.
+// BatchCreateUsers menangani RPC client-streaming BatchCreateUsers - membuat banyak user sekaligus.
+// Semua pesan dikumpulkan dulu, lalu dibuat dalam satu transaksi setelah client menutup stream.
+func (s *UserGRPCServer) BatchCreateUsers(stream grpc.ClientStreamingServer[proto.BatchCreateUsersRequest, proto.BatchUsersResponse]) error {
+	ctx := stream.Context()
+
+	var reqs []dto.CreateUserRequest
+	bestEffort, roleChecked := false, false
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(reqs) == 0 {
+			bestEffort = msg.BestEffort
+		}
+		if len(reqs) == service.MaxBatchSize {
+			return statusError(fmt.Errorf("%w: batch must contain at most %d users", service.ErrInvalidBatch, service.MaxBatchSize))
+		}
+
+		user := msg.GetUser()
+		if user.GetRole() != "" && !roleChecked {
+			if err := checkPermission(ctx, authz.PermUserSetRole); err != nil {
+				return err
+			}
+			roleChecked = true
+		}
+		reqs = append(reqs, dto.CreateUserRequest{
+			Name:  user.GetName(),
+			Email: user.GetEmail(),
+			Age:   int(user.GetAge()),
+			Role:  user.GetRole(),
+		})
+	}
+
+	results, err := s.userService.BatchCreateUsers(ctx, reqs, !bestEffort)
+	if err != nil {
+		return statusError(err)
+	}
+
+	resp := &proto.BatchUsersResponse{Results: make([]*proto.BatchItemResult, len(results))}
+	var firstErr error
+	for i, result := range results {
+		item := &proto.BatchItemResult{Index: uint32(i)}
+		if result.Err != nil {
+			item.Code = int32(grpcCode(result.Err))
+			item.Error = result.Err.Error()
+			resp.Failed++
+			if firstErr == nil && !errors.Is(result.Err, service.ErrBatchAborted) {
+				firstErr = statusError(fmt.Errorf("batch item %d: %w", i, result.Err))
+			}
+		} else {
+			item.User = toProtoUser(result.User)
+			resp.Succeeded++
+		}
+		resp.Results[i] = item
+	}
+
+	// Mode all-or-nothing yang gagal tidak mengubah apa pun, jadi RPC ikut gagal
+	if !bestEffort && firstErr != nil {
+		return firstErr
+	}
+	return stream.SendAndClose(resp)
+}
+
+// GetAllUsers menangani RPC GetAllUsers - mengambil daftar user dengan paginasi, filter, dan sort.
 func (s *UserGRPCServer) GetAllUsers(ctx context.Context, req *proto.GetAllUsersRequest) (*proto.GetAllUsersResponse, error) {
-	users, err := s.userService.GetAllUsers()
+	listReq := dto.ListUsersRequest{
+		Page:     int(req.Page),
+		PageSize: int(req.PageSize),
+		Limit:    int(req.Limit),
+		Offset:   int(req.Offset),
+		Name:     req.Name,
+		Email:    req.Email,
+		Sort:     req.Sort,
+		Cursor:   req.Cursor,
+
+		IncludeDeleted: req.IncludeDeleted,
+		OnlyDeleted:    req.OnlyDeleted,
+	}
+	if req.MinAge != nil {
+		minAge := int(req.GetMinAge())
+		listReq.MinAge = &minAge
+	}
+	if req.MaxAge != nil {
+		maxAge := int(req.GetMaxAge())
+		listReq.MaxAge = &maxAge
+	}
+
+	result, err := s.userService.GetAllUsers(ctx, listReq)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to retrieve users: %v", err)
+		return nil, statusError(err)
 	}
 
 	var protoUsers []*proto.UserMessage
-	for i := range users {
-		protoUsers = append(protoUsers, toProtoUser(&users[i]))
+	for i := range result.Data {
+		protoUsers = append(protoUsers, toProtoUser(&result.Data[i]))
 	}
 
-	return &proto.GetAllUsersResponse{Users: protoUsers}, nil
+	return &proto.GetAllUsersResponse{
+		Users:      protoUsers,
+		Total:      result.Total,
+		Limit:      uint32(result.Limit),
+		Offset:     uint32(result.Offset),
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+	}, nil
 }
 
 // GetUser menangani RPC GetUser - mengambil user berdasarkan ID.
 func (s *UserGRPCServer) GetUser(ctx context.Context, req *proto.GetUserRequest) (*proto.UserMessage, error) {
 	if req.Id == 0 {
-		return nil, status.Error(codes.InvalidArgument, "id must be greater than 0")
+		return nil, invalidField("id", "must be greater than 0")
 	}
 
-	user, err := s.userService.GetUserByID(uint(req.Id))
+	user, err := s.userService.GetUserByID(ctx, uint(req.Id))
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "user not found: %v", err)
+		return nil, statusError(err)
 	}
 
 	return toProtoUser(user), nil
@@ -77,41 +186,153 @@ func (s *UserGRPCServer) GetUser(ctx context.Context, req *proto.GetUserRequest)
 // UpdateUser menangani RPC UpdateUser - mengupdate data user.
 func (s *UserGRPCServer) UpdateUser(ctx context.Context, req *proto.UpdateUserRequest) (*proto.UserMessage, error) {
 	if req.Id == 0 {
-		return nil, status.Error(codes.InvalidArgument, "id must be greater than 0")
+		return nil, invalidField("id", "must be greater than 0")
 	}
 
-	user, err := s.userService.UpdateUser(uint(req.Id), dto.UpdateUserRequest{
-		Name:  req.Name,
-		Email: req.Email,
-		Age:   int(req.Age),
-	})
+	version, err := service.ParseETag(req.Etag)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "failed to update user: %v", err)
+		return nil, statusError(err)
+	}
+	patch, err := userPatchFromProto(req)
+	if err != nil {
+		return nil, err
+	}
+	// Reset role ke default juga termasuk mengubah role
+	if patch.Role.Set {
+		if err := checkRole(ctx, cmp.Or(req.Role, entity.RoleUser)); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.userService.PatchUser(ctx, uint(req.Id), version, patch)
+	if err != nil {
+		return nil, statusError(err)
 	}
 
 	return toProtoUser(user), nil
 }
 
+// userPatchFromProto mengubah UpdateUserRequest menjadi patch.
+// Tanpa update_mask hanya field non-kosong yang diterapkan; dengan update_mask
+// hanya path yang disebut, sehingga nilai kosong bisa dipakai untuk me-reset field.
+func userPatchFromProto(req *proto.UpdateUserRequest) (dto.PatchUserRequest, error) {
+	var patch dto.PatchUserRequest
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		if req.Name != "" {
+			patch.Name = dto.OptionalOf(req.Name)
+		}
+		if req.Email != "" {
+			patch.Email = dto.OptionalOf(req.Email)
+		}
+		if req.Age > 0 {
+			patch.Age = dto.OptionalOf(int(req.Age))
+		}
+		if req.Role != "" {
+			patch.Role = dto.OptionalOf(req.Role)
+		}
+		return patch, nil
+	}
+
+	for _, path := range paths {
+		switch path {
+		case "*":
+			patch.Name = dto.OptionalOf(req.Name)
+			patch.Email = dto.OptionalOf(req.Email)
+			patch.Age = dto.OptionalOf(int(req.Age))
+			patch.Role = dto.OptionalOf(req.Role)
+		case "name":
+			patch.Name = dto.OptionalOf(req.Name)
+		case "email":
+			patch.Email = dto.OptionalOf(req.Email)
+		case "age":
+			patch.Age = dto.OptionalOf(int(req.Age))
+		case "role":
+			patch.Role = dto.OptionalOf(req.Role)
+		default:
+			return patch, invalidField("update_mask", fmt.Sprintf("has unknown path %q", path))
+		}
+	}
+	return patch, nil
+}
+
 // DeleteUser menangani RPC DeleteUser - menghapus user berdasarkan ID.
+// hard=true menghapus permanen dan membutuhkan permission tambahan.
 func (s *UserGRPCServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*proto.DeleteUserResponse, error) {
 	if req.Id == 0 {
-		return nil, status.Error(codes.InvalidArgument, "id must be greater than 0")
+		return nil, invalidField("id", "must be greater than 0")
+	}
+
+	version, err := service.ParseETag(req.Etag)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	message := "User deleted successfully"
+	if req.Hard {
+		if err := checkPermission(ctx, authz.PermUserPurge); err != nil {
+			return nil, err
+		}
+		err = s.userService.PurgeUser(ctx, uint(req.Id), version)
+		message = "User permanently deleted"
+	} else {
+		err = s.userService.DeleteUser(ctx, uint(req.Id), version)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &proto.DeleteUserResponse{Message: message}, nil
+}
+
+// RestoreUser menangani RPC RestoreUser - mengembalikan user yang sudah di-soft-delete.
+func (s *UserGRPCServer) RestoreUser(ctx context.Context, req *proto.RestoreUserRequest) (*proto.UserMessage, error) {
+	if req.Id == 0 {
+		return nil, invalidField("id", "must be greater than 0")
 	}
 
-	err := s.userService.DeleteUser(uint(req.Id))
+	user, err := s.userService.RestoreUser(ctx, uint(req.Id))
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "failed to delete user: %v", err)
+		return nil, statusError(err)
 	}
 
-	return &proto.DeleteUserResponse{Message: "User deleted successfully"}, nil
+	return toProtoUser(user), nil
 }
 
 // toProtoUser adalah helper untuk konversi dari dto.UserResponse ke proto.UserMessage.
 func toProtoUser(u *dto.UserResponse) *proto.UserMessage {
-	return &proto.UserMessage{
-		Id:    uint32(u.ID),
-		Name:  u.Name,
-		Email: u.Email,
-		Age:   int32(u.Age),
+	msg := &proto.UserMessage{
+		Id:            uint32(u.ID),
+		Name:          u.Name,
+		Email:         u.Email,
+		Age:           int32(u.Age),
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified,
+		MfaEnabled:    u.MFAEnabled,
+		Etag:          service.FormatETag(u.Version),
+	}
+	if u.DeletedAt != nil {
+		msg.DeletedAt = u.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return msg
+}
+
+// checkRole memvalidasi field role dan memastikan pemanggil boleh mengisinya.
+func checkRole(ctx context.Context, role string) error {
+	if role == "" {
+		return nil
+	}
+	if role != entity.RoleAdmin && role != entity.RoleUser {
+		return invalidField("role", "must be one of: admin, user")
+	}
+	return checkPermission(ctx, authz.PermUserSetRole)
+}
+
+// checkPermission mengecek permission tambahan di luar permission RPC.
+func checkPermission(ctx context.Context, perm authz.Permission) error {
+	if err := authz.Authorize(middleware.SubjectFromContext(ctx), perm, 0); err != nil {
+		return statusError(err)
 	}
+	return nil
 }
@@ -1,14 +1,27 @@
 package grpcserver_test
 
 import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
 	"api-user-crud-go/dto"
 	"api-user-crud-go/entity"
 	grpcserver "api-user-crud-go/grpcserver"
+	"api-user-crud-go/jwtkeys"
 	"api-user-crud-go/proto"
+	"api-user-crud-go/repository"
 	"api-user-crud-go/service"
 	"context"
-	"errors"
+	"io"
+	"strings"
 	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gorm.io/gorm"
 )
 
 // ==========================================
@@ -16,66 +29,208 @@ import (
 // ==========================================
 
 type mockRepo struct {
-	users  map[uint]*entity.User
-	nextID uint
+	users   map[uint]*entity.User
+	deleted map[uint]*entity.User // user yang sudah di-soft-delete
+	nextID  uint
 }
 
 func newMockRepo() *mockRepo {
-	return &mockRepo{users: make(map[uint]*entity.User), nextID: 1}
+	return &mockRepo{users: make(map[uint]*entity.User), deleted: make(map[uint]*entity.User), nextID: 1}
 }
 
-func (m *mockRepo) Create(user *entity.User) error {
+func (m *mockRepo) Create(ctx context.Context, user *entity.User) error {
 	user.ID = m.nextID
+	user.Version = 1
 	m.nextID++
 	m.users[user.ID] = user
 	return nil
 }
 
-func (m *mockRepo) FindAll() ([]entity.User, error) {
+func (m *mockRepo) List(ctx context.Context, query repository.UserQuery) ([]entity.User, int64, error) {
 	var result []entity.User
-	for _, u := range m.users {
+	for id := uint(1); id < m.nextID; id++ {
+		u, ok := m.users[id]
+		switch query.Deleted {
+		case repository.IncludeDeleted:
+			if !ok {
+				u, ok = m.deleted[id]
+			}
+		case repository.OnlyDeleted:
+			u, ok = m.deleted[id]
+		}
+		if !ok || (query.Name != "" && !strings.Contains(u.Name, query.Name)) {
+			continue
+		}
 		result = append(result, *u)
 	}
-	return result, nil
+
+	total := int64(len(result))
+	if query.Offset >= len(result) {
+		return nil, total, nil
+	}
+	result = result[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(result) {
+		result = result[:query.Limit]
+	}
+	return result, total, nil
+}
+
+func (m *mockRepo) Stream(ctx context.Context, query repository.UserQuery, fn func(user *entity.User) error) error {
+	users, _, err := m.List(ctx, repository.UserQuery{Name: query.Name, Deleted: query.Deleted})
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if err := fn(&users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (m *mockRepo) FindByID(id uint) (*entity.User, error) {
+func (m *mockRepo) FindByID(ctx context.Context, id uint) (*entity.User, error) {
 	u, ok := m.users[id]
 	if !ok {
-		return nil, errors.New("user not found")
+		return nil, apperror.NotFound("user not found")
 	}
 	return u, nil
 }
 
-func (m *mockRepo) FindByEmail(email string) (*entity.User, error) {
+func (m *mockRepo) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	for _, u := range m.users {
 		if u.Email == email {
 			return u, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, apperror.NotFound("user not found")
+}
+
+func (m *mockRepo) Stamp(ctx context.Context) (repository.UserStamp, error) {
+	stamp := repository.UserStamp{Count: int64(len(m.users) + len(m.deleted))}
+	for _, users := range []map[uint]*entity.User{m.users, m.deleted} {
+		for _, u := range users {
+			if u.UpdatedAt.After(stamp.LastModified) {
+				stamp.LastModified = u.UpdatedAt
+			}
+			if u.DeletedAt.Valid && u.DeletedAt.Time.After(stamp.LastModified) {
+				stamp.LastModified = u.DeletedAt.Time
+			}
+		}
+	}
+	return stamp, nil
 }
 
-func (m *mockRepo) Update(user *entity.User) error {
+func (m *mockRepo) Update(ctx context.Context, user *entity.User) error {
 	if _, ok := m.users[user.ID]; !ok {
-		return errors.New("user not found")
+		return apperror.NotFound("user not found")
 	}
+	user.Version++
 	m.users[user.ID] = user
 	return nil
 }
 
-func (m *mockRepo) Delete(id uint) error {
-	if _, ok := m.users[id]; !ok {
-		return errors.New("user not found")
+func (m *mockRepo) AdvanceMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	u, ok := m.users[id]
+	if !ok || u.MFALastStep >= step {
+		return false, nil
+	}
+	u.MFALastStep = step
+	return true, nil
+}
+
+func (m *mockRepo) Delete(ctx context.Context, id uint, version uint) error {
+	u, ok := m.users[id]
+	if !ok {
+		return apperror.NotFound("user not found")
+	}
+	if version > 0 && u.Version != version {
+		return repository.ErrVersionConflict
 	}
+	u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	m.deleted[id] = u
 	delete(m.users, id)
 	return nil
 }
 
+func (m *mockRepo) FindDeletedByID(ctx context.Context, id uint) (*entity.User, error) {
+	u, ok := m.deleted[id]
+	if !ok {
+		return nil, apperror.NotFound("deleted user not found")
+	}
+	return u, nil
+}
+
+func (m *mockRepo) Restore(ctx context.Context, id uint) error {
+	u, ok := m.deleted[id]
+	if !ok {
+		return apperror.NotFound("deleted user not found")
+	}
+	u.DeletedAt = gorm.DeletedAt{}
+	m.users[id] = u
+	delete(m.deleted, id)
+	return nil
+}
+
+func (m *mockRepo) Purge(ctx context.Context, id uint, version uint) error {
+	u, ok := m.users[id]
+	if !ok {
+		u, ok = m.deleted[id]
+	}
+	if !ok {
+		return apperror.NotFound("user not found")
+	}
+	if version > 0 && u.Version != version {
+		return repository.ErrVersionConflict
+	}
+	delete(m.users, id)
+	delete(m.deleted, id)
+	return nil
+}
+
+func (m *mockRepo) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	for id, u := range m.deleted {
+		if u.DeletedAt.Time.Before(cutoff) {
+			delete(m.deleted, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Transaction pada mock menyimpan salinan data dan mengembalikannya jika fn gagal.
+func (m *mockRepo) Transaction(ctx context.Context, fn func(repository.UserRepository) error) error {
+	users, deleted, nextID := cloneUsers(m.users), cloneUsers(m.deleted), m.nextID
+	if err := fn(m); err != nil {
+		m.users, m.deleted, m.nextID = users, deleted, nextID
+		return err
+	}
+	return nil
+}
+
+func cloneUsers(users map[uint]*entity.User) map[uint]*entity.User {
+	clone := make(map[uint]*entity.User, len(users))
+	for id, u := range users {
+		copied := *u
+		clone[id] = &copied
+	}
+	return clone
+}
+
+var testConfig = &config.Config{
+	JWTSecret:               "test-secret",
+	JWTExpiryMinutes:        15,
+	RefreshTokenExpiryHours: 24,
+}
+
 // newServer membuat gRPC server baru dengan mock repo untuk setiap test.
 func newServer() *grpcserver.UserGRPCServer {
-	svc := service.NewUserService(newMockRepo())
-	return grpcserver.NewUserGRPCServer(svc)
+	repo := newMockRepo()
+	userService := service.NewUserService(repo, testConfig)
+	refreshTokens, sessions := newMockRefreshTokenRepo(), newMockSessionRepo()
+	uow := &mockUnitOfWork{repos: repository.Repositories{Users: repo, RefreshTokens: refreshTokens, Sessions: sessions}}
+	authService := service.NewAuthService(repo, refreshTokens, sessions, nil, nil, nil, newMockLoginAttemptRepo(), uow, discardNotifier{}, jwtkeys.NewHMACKeySet(testConfig.JWTSecret), testConfig)
+	return grpcserver.NewUserGRPCServer(userService, authService)
 }
 
 var ctx = context.Background()
@@ -147,6 +302,34 @@ func TestGRPC_CreateUser_InvalidAge(t *testing.T) {
 	}
 }
 
+func TestGRPC_CreateAndUpdateUser_InvalidEmail(t *testing.T) {
+	srv := newServer()
+
+	// Format email divalidasi sama seperti REST, dengan detail field di errdetails.BadRequest
+	_, err := srv.CreateUser(ctx, &proto.CreateUserRequest{Name: "Alice", Email: "x", Age: 25})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for invalid email on create, got %v", err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	if len(fields) != 1 || fields[0] != "email" {
+		t.Errorf("expected violation for email, got %v", fields)
+	}
+
+	created, _ := srv.CreateUser(ctx, &proto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	_, err = srv.UpdateUser(ctx, &proto.UpdateUserRequest{Id: created.Id, Email: "x"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for invalid email on update, got %v", err)
+	}
+}
+
 // ==========================================
 // TESTS: GetAllUsers
 // ==========================================
@@ -176,6 +359,18 @@ func TestGRPC_GetAllUsers_WithData(t *testing.T) {
 	if len(resp.Users) != 2 {
 		t.Errorf("expected 2 users, got %d", len(resp.Users))
 	}
+	if resp.GetTotal() != 2 {
+		t.Errorf("expected total 2, got %d", resp.GetTotal())
+	}
+}
+
+func TestGRPC_GetAllUsers_InvalidSort(t *testing.T) {
+	srv := newServer()
+
+	_, err := srv.GetAllUsers(ctx, &proto.GetAllUsersRequest{Sort: "password:desc"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
 }
 
 // ==========================================
@@ -243,6 +438,78 @@ func TestGRPC_UpdateUser_Success(t *testing.T) {
 	}
 }
 
+func TestGRPC_UpdateUser_FieldMask(t *testing.T) {
+	srv := newServer()
+
+	created, _ := srv.CreateUser(ctx, &proto.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 25,
+	})
+
+	// Hanya age yang ada di mask: name diabaikan, age 0 me-reset field
+	resp, err := srv.UpdateUser(ctx, &proto.UpdateUserRequest{
+		Id:         created.Id,
+		Name:       "Ignored",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"age"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Name != "Alice" || resp.Age != 0 {
+		t.Errorf("expected name Alice and age 0, got %s/%d", resp.Name, resp.Age)
+	}
+
+	_, err = srv.UpdateUser(ctx, &proto.UpdateUserRequest{
+		Id:         created.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"password"}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unknown path, got %v", err)
+	}
+
+	_, err = srv.UpdateUser(ctx, &proto.UpdateUserRequest{
+		Id:         created.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument when clearing name, got %v", err)
+	}
+
+	// Me-reset role tetap butuh permission set_role
+	userCtx := context.WithValue(context.WithValue(ctx, "user_id", uint(created.Id)), "role", "user")
+	_, err = srv.UpdateUser(userCtx, &proto.UpdateUserRequest{
+		Id:         created.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"role"}},
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied when resetting role, got %v", err)
+	}
+}
+
+func TestGRPC_UpdateUser_StaleEtag(t *testing.T) {
+	srv := newServer()
+
+	created, _ := srv.CreateUser(ctx, &proto.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 25,
+	})
+
+	updated, err := srv.UpdateUser(ctx, &proto.UpdateUserRequest{Id: created.Id, Age: 26, Etag: created.Etag})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Etag == created.Etag {
+		t.Error("expected etag to change after update")
+	}
+
+	_, err = srv.UpdateUser(ctx, &proto.UpdateUserRequest{Id: created.Id, Age: 27, Etag: created.Etag})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("expected Aborted for stale etag, got %v", err)
+	}
+	_, err = srv.DeleteUser(ctx, &proto.DeleteUserRequest{Id: created.Id, Etag: created.Etag})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("expected Aborted for stale etag on delete, got %v", err)
+	}
+}
+
 func TestGRPC_UpdateUser_NotFound(t *testing.T) {
 	srv := newServer()
 
@@ -296,6 +563,161 @@ func TestGRPC_DeleteUser_InvalidID(t *testing.T) {
 	}
 }
 
+func TestGRPC_DeleteUser_HardRequiresAdmin(t *testing.T) {
+	srv := newServer()
+
+	created, _ := srv.CreateUser(ctx, &proto.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 25,
+	})
+
+	userCtx := context.WithValue(context.WithValue(ctx, "user_id", uint(created.Id)), "role", "user")
+	_, err := srv.DeleteUser(userCtx, &proto.DeleteUserRequest{Id: created.Id, Hard: true})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+
+	adminCtx := context.WithValue(ctx, "role", "admin")
+	if _, err := srv.DeleteUser(adminCtx, &proto.DeleteUserRequest{Id: created.Id, Hard: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := srv.RestoreUser(adminCtx, &proto.RestoreUserRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for purged user, got %v", err)
+	}
+}
+
+// ==========================================
+// TESTS: RestoreUser
+// ==========================================
+
+func TestGRPC_RestoreUser(t *testing.T) {
+	srv := newServer()
+
+	created, _ := srv.CreateUser(ctx, &proto.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 25,
+	})
+	srv.DeleteUser(ctx, &proto.DeleteUserRequest{Id: created.Id})
+
+	list, err := srv.GetAllUsers(ctx, &proto.GetAllUsersRequest{OnlyDeleted: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Users) != 1 || list.Users[0].DeletedAt == "" {
+		t.Fatalf("expected one deleted user with deleted_at, got %v", list.Users)
+	}
+
+	restored, err := srv.RestoreUser(ctx, &proto.RestoreUserRequest{Id: created.Id})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.DeletedAt != "" {
+		t.Errorf("expected empty deleted_at, got %q", restored.DeletedAt)
+	}
+}
+
+func TestGRPC_RestoreUser_EmailTaken(t *testing.T) {
+	srv := newServer()
+
+	old, _ := srv.CreateUser(ctx, &proto.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 25,
+	})
+	srv.DeleteUser(ctx, &proto.DeleteUserRequest{Id: old.Id})
+	srv.CreateUser(ctx, &proto.CreateUserRequest{
+		Name: "New Alice", Email: "alice@example.com", Age: 30,
+	})
+
+	_, err := srv.RestoreUser(ctx, &proto.RestoreUserRequest{Id: old.Id})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+}
+
+// ==========================================
+// TESTS: BatchCreateUsers (client streaming)
+// ==========================================
+
+// batchStream adalah fake stream BatchCreateUsers yang mengirim reqs lalu EOF.
+type batchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	reqs []*proto.BatchCreateUsersRequest
+	resp *proto.BatchUsersResponse
+}
+
+func (s *batchStream) Context() context.Context { return s.ctx }
+
+func (s *batchStream) Recv() (*proto.BatchCreateUsersRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *batchStream) SendAndClose(resp *proto.BatchUsersResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func batchRequests(bestEffort bool, users ...*proto.CreateUserRequest) []*proto.BatchCreateUsersRequest {
+	reqs := make([]*proto.BatchCreateUsersRequest, len(users))
+	for i, u := range users {
+		reqs[i] = &proto.BatchCreateUsersRequest{User: u, BestEffort: bestEffort}
+	}
+	return reqs
+}
+
+func TestGRPC_BatchCreateUsers(t *testing.T) {
+	srv := newServer()
+	users := []*proto.CreateUserRequest{
+		{Name: "Alice", Email: "alice@example.com", Age: 25},
+		{Name: "Alice Again", Email: "alice@example.com", Age: 26},
+		{Name: "Bob", Email: "bob@example.com", Age: 30},
+	}
+
+	// All-or-nothing: RPC gagal dengan status item yang gagal, tidak ada user dibuat
+	stream := &batchStream{ctx: ctx, reqs: batchRequests(false, users...)}
+	if err := srv.BatchCreateUsers(stream); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+	if list, _ := srv.GetAllUsers(ctx, &proto.GetAllUsersRequest{}); len(list.Users) != 0 {
+		t.Fatalf("expected no users after failed atomic batch, got %d", len(list.Users))
+	}
+
+	// Best effort: item yang valid tetap dibuat
+	stream = &batchStream{ctx: ctx, reqs: batchRequests(true, users...)}
+	if err := srv.BatchCreateUsers(stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stream.resp.Succeeded != 2 || stream.resp.Failed != 1 {
+		t.Fatalf("expected 2 succeeded and 1 failed, got %d/%d", stream.resp.Succeeded, stream.resp.Failed)
+	}
+	if got := stream.resp.Results[1]; codes.Code(got.Code) != codes.AlreadyExists || got.User != nil {
+		t.Errorf("expected duplicate to fail with AlreadyExists, got %+v", got)
+	}
+	if got := stream.resp.Results[2].User; got == nil || got.Email != "bob@example.com" {
+		t.Errorf("expected Bob to be created, got %+v", got)
+	}
+}
+
+func TestGRPC_BatchCreateUsers_RoleRequiresPermission(t *testing.T) {
+	srv := newServer()
+	userCtx := context.WithValue(context.WithValue(ctx, "user_id", uint(1)), "role", "user")
+
+	stream := &batchStream{ctx: userCtx, reqs: batchRequests(false,
+		&proto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25},
+		&proto.CreateUserRequest{Name: "Root", Email: "root@example.com", Age: 30, Role: "admin"},
+	)}
+	if err := srv.BatchCreateUsers(stream); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+
+	stream = &batchStream{ctx: ctx}
+	if err := srv.BatchCreateUsers(stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for empty stream, got %v", err)
+	}
+}
+
 // ==========================================
 // HELPER: ensure UserResponse implements dto
 // ==========================================
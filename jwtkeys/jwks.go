@@ -0,0 +1,99 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// JWK adalah representasi JSON Web Key (RFC 7517) untuk kunci publik.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS adalah JSON Web Key Set yang dipublikasikan di /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan kunci publik dari semua kunci asimetris di KeySet,
+// diurutkan berdasarkan kid. Kunci HMAC tidak pernah dipublikasikan.
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if key.ID == "" {
+			continue
+		}
+		jwk, err := publicJWK(key.verifyKey)
+		if err != nil {
+			continue
+		}
+		jwk.Kid = key.ID
+		jwk.Use = "sig"
+		jwk.Alg = key.Algorithm
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// publicJWK mengubah kunci publik menjadi JWK (tanpa kid/use/alg).
+func publicJWK(public crypto.PublicKey) (JWK, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   b64(pub.N.Bytes()),
+			E:   b64(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Crv: pub.Curve.Params().Name,
+			X:   b64(pub.X.FillBytes(make([]byte, size))),
+			Y:   b64(pub.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: b64(pub)}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+// thumbprint menghitung JWK thumbprint (RFC 7638): SHA-256 dari member wajib
+// JWK, diurutkan secara leksikografis, tanpa whitespace.
+func thumbprint(jwk JWK) string {
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Crv, jwk.X, jwk.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return b64(sum[:])
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
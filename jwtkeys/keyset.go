@@ -0,0 +1,163 @@
+// Package jwtkeys mengelola kunci untuk sign dan verifikasi JWT access token.
+//
+// Mode default memakai HS256 dengan JWT_SECRET. Jika JWT_SIGNING_KEY_FILE diset,
+// token di-sign dengan kunci asimetris dari file PEM (RSA -> RS256,
+// ECDSA -> ES256/ES384/ES512, Ed25519 -> EdDSA) dan header "kid" berisi
+// thumbprint RFC 7638 dari kunci publiknya. Kunci tambahan di
+// JWT_VERIFICATION_KEY_FILES tetap diterima untuk verifikasi sehingga signing
+// key bisa dirotasi tanpa membatalkan token yang masih berlaku.
+package jwtkeys
+
+import (
+	"api-user-crud-go/config"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key adalah satu kunci JWT beserta algoritmanya.
+type Key struct {
+	ID        string      // kid; kosong untuk kunci HMAC
+	Algorithm string      // "HS256", "RS256", "ES256", "EdDSA", dst.
+	signKey   interface{} // nil jika kunci hanya untuk verifikasi
+	verifyKey interface{}
+}
+
+// KeySet berisi satu signing key dan semua kunci yang diterima untuk verifikasi.
+// Aman dipakai bersamaan dari banyak goroutine, termasuk saat Reload.
+type KeySet struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key // berdasarkan kid
+	load    func() (*Key, []*Key, error)
+}
+
+// New membuat KeySet sesuai konfigurasi: asimetris jika JWT_SIGNING_KEY_FILE diset,
+// selain itu HS256 dengan JWT_SECRET.
+func New(cfg *config.Config) (*KeySet, error) {
+	if cfg.JWTSigningKeyFile == "" {
+		return NewHMACKeySet(cfg.JWTSecret), nil
+	}
+	return LoadKeySet(cfg.JWTSigningKeyFile, splitFiles(cfg.JWTVerificationKeyFiles))
+}
+
+// NewHMACKeySet membuat KeySet HS256 dengan shared secret.
+// Token HS256 tidak memakai kid dan kuncinya tidak dipublikasikan di JWKS.
+func NewHMACKeySet(secret string) *KeySet {
+	key := &Key{Algorithm: jwt.SigningMethodHS256.Alg(), signKey: []byte(secret), verifyKey: []byte(secret)}
+	return &KeySet{
+		signing: key,
+		keys:    map[string]*Key{"": key},
+		load:    func() (*Key, []*Key, error) { return key, nil, nil },
+	}
+}
+
+// LoadKeySet membaca signing key (private key PEM) dan kunci verifikasi tambahan
+// (public atau private key PEM) dari file.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	s := &KeySet{
+		load: func() (*Key, []*Key, error) {
+			signing, err := loadKeyFile(signingKeyFile)
+			if err != nil {
+				return nil, nil, err
+			}
+			if signing.signKey == nil {
+				return nil, nil, fmt.Errorf("%s: signing key must be a private key", signingKeyFile)
+			}
+
+			var verification []*Key
+			for _, file := range verificationKeyFiles {
+				key, err := loadKeyFile(file)
+				if err != nil {
+					return nil, nil, err
+				}
+				key.signKey = nil // kunci lama hanya untuk verifikasi
+				verification = append(verification, key)
+			}
+			return signing, verification, nil
+		},
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload membaca ulang file kunci. Jika gagal, kunci lama tetap dipakai.
+func (s *KeySet) Reload() error {
+	signing, verification, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*Key{signing.ID: signing}
+	for _, key := range verification {
+		if _, exists := keys[key.ID]; !exists {
+			keys[key.ID] = key
+		}
+	}
+
+	s.mu.Lock()
+	s.signing = signing
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// Asymmetric mengecek apakah signing key aktif adalah kunci asimetris
+// (token bisa diverifikasi pihak lain lewat JWKS).
+func (s *KeySet) Asymmetric() bool {
+	return s.SigningAlgorithm() != jwt.SigningMethodHS256.Alg()
+}
+
+// SigningAlgorithm mengembalikan algoritma signing key aktif.
+func (s *KeySet) SigningAlgorithm() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signing.Algorithm
+}
+
+// Sign membuat JWT dari claims dengan signing key aktif.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	key := s.signing
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc memilih kunci verifikasi berdasarkan header kid, untuk jwt.Parse.
+// Algoritma token harus sama dengan algoritma kunci (mencegah algorithm confusion).
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// splitFiles memecah daftar file yang dipisah koma.
+func splitFiles(s string) []string {
+	var files []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
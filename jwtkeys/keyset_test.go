@@ -0,0 +1,171 @@
+package jwtkeys_test
+
+import (
+	"api-user-crud-go/jwtkeys"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePrivateKey menulis private key sebagai PEM PKCS#8 ke direktori sementara.
+func writePrivateKey(t *testing.T, name string, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return path
+}
+
+func parse(keys *jwtkeys.KeySet, token string) error {
+	_, err := jwt.Parse(token, keys.Keyfunc)
+	return err
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{"rsa", rsaKey, "RS256"},
+		{"ecdsa", ecKey, "ES256"},
+		{"ed25519", edKey, "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := jwtkeys.LoadKeySet(writePrivateKey(t, tt.name+".pem", tt.key), nil)
+			if err != nil {
+				t.Fatalf("LoadKeySet returned unexpected error: %v", err)
+			}
+
+			token, err := keys.Sign(jwt.MapClaims{"sub": "1"})
+			if err != nil {
+				t.Fatalf("Sign returned unexpected error: %v", err)
+			}
+			parsed, _, _ := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if parsed.Method.Alg() != tt.alg || parsed.Header["kid"] == "" {
+				t.Errorf("expected alg %s with kid, got header %v", tt.alg, parsed.Header)
+			}
+			if err := parse(keys, token); err != nil {
+				t.Errorf("expected token to verify, got %v", err)
+			}
+
+			jwks := keys.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != parsed.Header["kid"] || jwks.Keys[0].Alg != tt.alg {
+				t.Errorf("unexpected JWKS: %+v", jwks)
+			}
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	oldFile := writePrivateKey(t, "old.pem", oldKey)
+	newFile := writePrivateKey(t, "new.pem", newKey)
+
+	before, _ := jwtkeys.LoadKeySet(oldFile, nil)
+	oldToken, _ := before.Sign(jwt.MapClaims{"sub": "1"})
+
+	// Signing key baru, kunci lama tetap diterima untuk verifikasi
+	after, err := jwtkeys.LoadKeySet(newFile, []string{oldFile})
+	if err != nil {
+		t.Fatalf("LoadKeySet returned unexpected error: %v", err)
+	}
+	if err := parse(after, oldToken); err != nil {
+		t.Errorf("expected token signed with previous key to verify, got %v", err)
+	}
+	if len(after.JWKS().Keys) != 2 {
+		t.Errorf("expected both keys in JWKS, got %+v", after.JWKS())
+	}
+
+	// Setelah kunci lama dihapus dari daftar verifikasi, tokennya ditolak
+	final, _ := jwtkeys.LoadKeySet(newFile, nil)
+	if err := parse(final, oldToken); err == nil {
+		t.Error("expected token with retired kid to be rejected")
+	}
+}
+
+func TestKeySet_HMAC(t *testing.T) {
+	keys := jwtkeys.NewHMACKeySet("secret")
+
+	token, err := keys.Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatalf("Sign returned unexpected error: %v", err)
+	}
+	if err := parse(keys, token); err != nil {
+		t.Errorf("expected token to verify, got %v", err)
+	}
+	if err := parse(jwtkeys.NewHMACKeySet("other"), token); err == nil {
+		t.Error("expected token signed with another secret to be rejected")
+	}
+	if len(keys.JWKS().Keys) != 0 {
+		t.Error("expected HMAC key not to be published")
+	}
+}
+
+func TestKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys, _ := jwtkeys.LoadKeySet(writePrivateKey(t, "rsa.pem", rsaKey), nil)
+	kid := keys.JWKS().Keys[0].Kid
+
+	// Token HS256 yang di-sign dengan public key RSA sebagai secret
+	publicDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"})
+	forged.Header["kid"] = kid
+	token, _ := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+
+	if err := parse(keys, token); err == nil {
+		t.Error("expected HS256 token with RSA kid to be rejected")
+	}
+}
+
+func TestParsePEM_ThumbprintKid(t *testing.T) {
+	// Contoh kunci dari RFC 7638 bagian 3.1
+	n, _ := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	der, _ := x509.MarshalPKIXPublicKey(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537})
+
+	key, err := jwtkeys.ParsePEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParsePEM returned unexpected error: %v", err)
+	}
+	if key.ID != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("unexpected kid %q", key.ID)
+	}
+	if key.Algorithm != "RS256" {
+		t.Errorf("expected RS256, got %s", key.Algorithm)
+	}
+}
+
+func TestLoadKeySet_RequiresPrivateSigningKey(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	path := filepath.Join(t.TempDir(), "public.pem")
+	os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+
+	if _, err := jwtkeys.LoadKeySet(path, nil); err == nil {
+		t.Error("expected public key to be rejected as signing key")
+	}
+}
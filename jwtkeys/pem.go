@@ -0,0 +1,97 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// loadKeyFile membaca satu kunci dari file PEM.
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key, err := ParsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParsePEM membaca private key (PKCS#8, PKCS#1, SEC 1) atau public key (PKIX)
+// dari blok PEM. Private key bisa dipakai untuk sign, public key hanya untuk verifikasi.
+func ParsePEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{}
+	var public crypto.PublicKey
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.signKey = signer
+		public = signer.Public()
+	} else {
+		public = parsed
+	}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key must be at least 2048 bits")
+		}
+		key.Algorithm = "RS256"
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			key.Algorithm = "ES256"
+		case elliptic.P384():
+			key.Algorithm = "ES384"
+		case elliptic.P521():
+			key.Algorithm = "ES512"
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA curve")
+		}
+	case ed25519.PublicKey:
+		key.Algorithm = "EdDSA"
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+	key.verifyKey = public
+
+	jwk, err := publicJWK(public)
+	if err != nil {
+		return nil, err
+	}
+	key.ID = thumbprint(jwk)
+	return key, nil
+}
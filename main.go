@@ -5,13 +5,21 @@ import (
 	"api-user-crud-go/controller"
 	"api-user-crud-go/exception"
 	grpcserver "api-user-crud-go/grpcserver"
+	"api-user-crud-go/jwtkeys"
 	"api-user-crud-go/middleware"
+	"api-user-crud-go/notification"
 	"api-user-crud-go/proto"
+	"api-user-crud-go/ratelimit"
 	"api-user-crud-go/repository"
 	"api-user-crud-go/service"
+	"context"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
@@ -40,14 +48,88 @@ func main() {
 	// ==========================================
 	// Repository layer - mengakses database
 	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	revocationRepo := repository.NewTokenRevocationRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	oauthClientRepo := repository.NewOAuthClientRepository(db)
+	oauthCodeRepo := repository.NewOAuthCodeRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	importJobRepo := repository.NewImportJobRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db) // transaksi lintas repository
+
+	// Job import yang sedang berjalan ikut berhenti saat proses mati
+	if n, err := importJobRepo.FailUnfinished(context.Background(), "interrupted by server restart"); err != nil {
+		log.Printf("Gagal menandai import job yang terputus: %v", err)
+	} else if n > 0 {
+		log.Printf("✓ %d import job yang terputus ditandai gagal", n)
+	}
+
+	// Notifier untuk mengirim token (reset password, dll) ke user
+	notifier := notification.NewNotifier(cfg.NotifierDriver, cfg.NotifierFilePath)
+
+	// Rate limiter token bucket (in-memory) yang dipakai bersama oleh REST & gRPC
+	rateLimitStore := ratelimit.NewMemoryStore()
+	authRateLimit, err := ratelimit.ParseLimit(cfg.RateLimitAuth)
+	if err != nil {
+		log.Fatalf("RATE_LIMIT_AUTH tidak valid: %v", err)
+	}
+	apiRateLimit, err := ratelimit.ParseLimit(cfg.RateLimitAPI)
+	if err != nil {
+		log.Fatalf("RATE_LIMIT_API tidak valid: %v", err)
+	}
+
+	// Kunci JWT (HS256 atau RS256/ES256/EdDSA dari file PEM); SIGHUP membaca ulang file kunci
+	jwtKeys, err := jwtkeys.New(cfg)
+	if err != nil {
+		log.Fatalf("Gagal memuat kunci JWT: %v", err)
+	}
+	reloadKeys := make(chan os.Signal, 1)
+	signal.Notify(reloadKeys, syscall.SIGHUP)
+	go func() {
+		for range reloadKeys {
+			if err := jwtKeys.Reload(); err != nil {
+				log.Printf("Gagal memuat ulang kunci JWT, kunci lama tetap dipakai: %v", err)
+				continue
+			}
+			log.Println("✓ Kunci JWT dimuat ulang")
+		}
+	}()
 
 	// Service layer - business logic, menggunakan repository
-	userService := service.NewUserService(userRepo)
-	authService := service.NewAuthService(userRepo, cfg)
+	userService := service.NewUserService(userRepo, cfg)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, sessionRepo, revocationRepo, passwordResetRepo, recoveryCodeRepo, loginAttemptRepo, unitOfWork, notifier, jwtKeys, cfg)
+	oauthService := service.NewOAuthService(authService, userRepo, oauthClientRepo, oauthCodeRepo, jwtKeys, cfg)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	userImportService := service.NewUserImportService(userService, importJobRepo, cfg)
+
+	// Background job untuk membersihkan token yang sudah expired dan
+	// menghapus permanen user yang sudah melewati masa retensi soft delete
+	cleanupStores := map[string]service.ExpiredTokenStore{
+		"revoked token":        revocationRepo,
+		"refresh token":        refreshTokenRepo,
+		"session":              sessionRepo,
+		"password reset token": passwordResetRepo,
+		"login attempt":        loginAttemptRepo,
+		"idempotency key":      idempotencyRepo,
+		"oauth code":           oauthCodeRepo,
+		"import job":           importJobRepo,
+	}
+	if cfg.DeletedUserRetentionDays > 0 {
+		cleanupStores["soft-deleted user"] = service.NewDeletedUserPurger(userRepo, time.Duration(cfg.DeletedUserRetentionDays)*24*time.Hour)
+	}
+	stopTokenCleanup := service.StartTokenCleanup(time.Duration(cfg.TokenCleanupMinutes)*time.Minute, cleanupStores)
+	defer stopTokenCleanup()
 
 	// Controller layer - HTTP handlers, menggunakan service
 	userController := controller.NewUserController(userService)
 	authController := controller.NewAuthController(authService)
+	oauthController := controller.NewOAuthController(oauthService)
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+	userImportController := controller.NewUserImportController(userImportService, int64(cfg.ImportMaxUploadMB)<<20)
 
 	// ==========================================
 	// 4. START gRPC SERVER (with auth interceptor)
@@ -58,13 +140,26 @@ func main() {
 			log.Fatalf("Gagal mengaktifkan gRPC listener: %v", err)
 		}
 
-		// Create gRPC server with auth interceptor
+		// Create gRPC server with auth + RBAC interceptor
+		accessInterceptors := []grpc.UnaryServerInterceptor{
+			middleware.GRPCContextErrorInterceptor(), // request dibatalkan/deadline lewat -> Canceled/DeadlineExceeded
+			middleware.GRPCAuthInterceptor(jwtKeys, revocationRepo, apiKeyService),
+			middleware.GRPCRateLimitInterceptor(rateLimitStore, authRateLimit, apiRateLimit),
+			middleware.GRPCVerifiedEmailInterceptor(cfg),
+			middleware.GRPCAdminMFAInterceptor(cfg),
+			middleware.GRPCAuthorizeInterceptor(),
+		}
 		grpcServer := grpc.NewServer(
-			grpc.UnaryInterceptor(middleware.GRPCAuthInterceptor(cfg)),
+			grpc.ChainUnaryInterceptor(append(accessInterceptors,
+				middleware.GRPCRequireETagInterceptor(cfg),
+				middleware.GRPCIdempotencyInterceptor(idempotencyRepo, cfg),
+			)...),
+			// RPC streaming (BatchCreateUsers) melewati auth, rate limit, dan RBAC yang sama
+			grpc.ChainStreamInterceptor(middleware.GRPCStreamInterceptor(accessInterceptors...)),
 		)
 
 		// Register UserService gRPC handler (berbagi userService yang sama)
-		proto.RegisterUserServiceServer(grpcServer, grpcserver.NewUserGRPCServer(userService))
+		proto.RegisterUserServiceServer(grpcServer, grpcserver.NewUserGRPCServer(userService, authService))
 
 		// Register reflection service (untuk grpcurl & tooling lainnya)
 		reflection.Register(grpcServer)
@@ -72,10 +167,17 @@ func main() {
 		log.Printf("✓ gRPC Server berjalan di grpc://localhost:%s\n", cfg.GRPCPort)
 		log.Println("✓ gRPC Methods:")
 		log.Println("  - UserService/CreateUser")
+		log.Println("  - UserService/BatchCreateUsers (client streaming)")
 		log.Println("  - UserService/GetAllUsers")
 		log.Println("  - UserService/GetUser")
 		log.Println("  - UserService/UpdateUser")
 		log.Println("  - UserService/DeleteUser")
+		log.Println("  - UserService/RestoreUser (admin)")
+		log.Println("  - UserService/UnlockUser (admin)")
+		log.Println("  - UserService/Register (public)")
+		log.Println("  - UserService/Login (public)")
+		log.Println("  - UserService/RefreshToken (public)")
+		log.Println("  - UserService/VerifyMFA (public)")
 
 		if err := grpcServer.Serve(lis); err != nil {
 			log.Fatalf("Gagal menjalankan gRPC server: %v", err)
@@ -87,36 +189,138 @@ func main() {
 	// ==========================================
 	router := gin.New()
 
+	// Hanya proxy di TRUSTED_PROXIES yang boleh menentukan IP client lewat X-Forwarded-For.
+	// Tanpa ini header bisa dipalsukan untuk lolos dari rate limit dan lockout per IP.
+	if err := router.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		log.Fatalf("TRUSTED_PROXIES tidak valid: %v", err)
+	}
+
 	// Middleware global
 	router.Use(exception.LoggerMiddleware()) // Logging setiap request
 	router.Use(exception.Recovery())         // Recovery dari panic
-	router.Use(exception.ErrorHandler())     // Handle error secara konsisten
+
+	// Deadline request: query database dihentikan dan dijawab 504 setelah lewat batas waktu.
+	// Dipasang sebelum ErrorHandler agar error dijawab selagi context request masih berlaku.
+	router.Use(middleware.RequestTimeout(time.Duration(cfg.RequestTimeoutSeconds) * time.Second))
+	router.Use(exception.ErrorHandler()) // Handle error secara konsisten
 
 	// ==========================================
 	// 6. REGISTER ROUTES (API Endpoints)
 	// ==========================================
-	
+
 	// Health check endpoint (public)
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Kunci publik untuk verifikasi access token oleh service lain (public)
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwtKeys.JWKS())
+	})
+
 	// Auth routes (public)
 	authRoutes := router.Group("/auth")
+	authRoutes.Use(middleware.RateLimit(rateLimitStore, middleware.RateLimitGroupAuth, authRateLimit)) // Batasi request per IP
+	{
+		authRoutes.POST("/register", authController.Register)              // POST /auth/register
+		authRoutes.POST("/login", authController.Login)                    // POST /auth/login
+		authRoutes.POST("/refresh", authController.Refresh)                // POST /auth/refresh
+		authRoutes.POST("/password/forgot", authController.ForgotPassword) // POST /auth/password/forgot
+		authRoutes.POST("/password/reset", authController.ResetPassword)   // POST /auth/password/reset
+		authRoutes.POST("/mfa/verify", authController.VerifyMFA)           // POST /auth/mfa/verify
+		authRoutes.GET("/verify", authController.VerifyEmail)              // GET /auth/verify?token=
+	}
+
+	// Auth routes (protected with JWT); API key sengaja tidak diterima di sini
+	authProtectedRoutes := router.Group("/auth")
+	authProtectedRoutes.Use(middleware.JWTAuth(jwtKeys, revocationRepo, nil))
+	authProtectedRoutes.Use(middleware.RateLimit(rateLimitStore, middleware.RateLimitGroupAPI, apiRateLimit)) // Batasi request per user
 	{
-		authRoutes.POST("/register", authController.Register) // POST /auth/register
-		authRoutes.POST("/login", authController.Login)       // POST /auth/login
+		authProtectedRoutes.POST("/logout", authController.Logout)                    // POST /auth/logout
+		authProtectedRoutes.POST("/logout-all", authController.LogoutAll)             // POST /auth/logout-all
+		authProtectedRoutes.POST("/password/change", authController.ChangePassword)   // POST /auth/password/change
+		authProtectedRoutes.POST("/verify/resend", authController.ResendVerification) // POST /auth/verify/resend
+		authProtectedRoutes.POST("/mfa/enroll", authController.EnrollMFA)             // POST /auth/mfa/enroll
+		authProtectedRoutes.POST("/mfa/confirm", authController.ConfirmMFA)           // POST /auth/mfa/confirm
+		authProtectedRoutes.POST("/mfa/disable", authController.DisableMFA)           // POST /auth/mfa/disable
+		authProtectedRoutes.GET("/sessions", authController.ListSessions)             // GET /auth/sessions
+		authProtectedRoutes.DELETE("/sessions/:id", authController.RevokeSession)     // DELETE /auth/sessions/:id
+		authProtectedRoutes.POST("/api-keys", apiKeyController.CreateAPIKey)          // POST /auth/api-keys
+		authProtectedRoutes.GET("/api-keys", apiKeyController.ListAPIKeys)            // GET /auth/api-keys
+		authProtectedRoutes.DELETE("/api-keys/:id", apiKeyController.RevokeAPIKey)    // DELETE /auth/api-keys/:id
 	}
 
 	// User routes (protected with JWT)
-	userRoutes := router.Group("/users")
-	userRoutes.Use(middleware.JWTAuth(cfg)) // Apply JWT middleware
+	userCache := middleware.CacheControl(cfg.UserCacheControl) // Cache-Control untuk GET yang mendukung conditional request
+	userMiddleware := []gin.HandlerFunc{
+		middleware.JWTAuth(jwtKeys, revocationRepo, apiKeyService),                       // Apply JWT middleware (atau API key)
+		middleware.RateLimit(rateLimitStore, middleware.RateLimitGroupAPI, apiRateLimit), // Batasi request per user
+		middleware.RequireVerifiedEmail(cfg),                                             // Tolak user yang belum verifikasi email (jika diaktifkan)
+		middleware.RequireAdminMFA(cfg),                                                  // Admin wajib login dengan 2FA (jika diaktifkan)
+		middleware.Authorize(),                                                           // Apply RBAC (lihat authz.RoutePermissions)
+	}
+	userRoutes := router.Group("/users", userMiddleware...)
+	{
+		userRoutes.POST("", middleware.Idempotency(idempotencyRepo, cfg), userController.CreateUser) // POST /users (mendukung Idempotency-Key)
+		userRoutes.GET("", userCache, userController.GetUsers)                                       // GET /users (ETag/304)
+		userRoutes.GET("/export", userController.ExportUsers)                                        // GET /users/export?format=csv|ndjson|json
+		userRoutes.POST("/import", userImportController.ImportUsers)                                 // POST /users/import (multipart)
+		userRoutes.GET("/import/:job_id", userImportController.GetImportJob)                         // GET /users/import/:job_id
+		userRoutes.GET("/:id", userCache, userController.GetUser)                                    // GET /users/:id (ETag/304)
+		userRoutes.PUT("/:id", middleware.RequireIfMatch(cfg), userController.UpdateUser)            // PUT /users/:id (If-Match)
+		userRoutes.PATCH("/:id", middleware.RequireIfMatch(cfg), userController.PatchUser)           // PATCH /users/:id (merge patch, If-Match)
+		userRoutes.DELETE("/:id", middleware.RequireIfMatch(cfg), userController.DeleteUser)         // DELETE /users/:id (If-Match)
+		userRoutes.POST("/:id/restore", userController.RestoreUser)                                  // POST /users/:id/restore
+		userRoutes.POST("/:id/unlock", authController.UnlockUser)                                    // POST /users/:id/unlock
+		userRoutes.GET("/:id/sessions", authController.ListUserSessions)                             // GET /users/:id/sessions
+		userRoutes.DELETE("/:id/sessions/:session_id", authController.RevokeUserSession)             // DELETE /users/:id/sessions/:session_id
+	}
+
+	// Operasi batch (/users:batchX) didaftarkan dari root karena group /users selalu
+	// menyisipkan "/" sebelum path; ":" di-escape agar tidak dibaca sebagai parameter
+	userBatchRoutes := router.Group("", userMiddleware...)
 	{
-		userRoutes.POST("", userController.CreateUser)       // POST /users
-		userRoutes.GET("", userController.GetUsers)          // GET /users
-		userRoutes.GET("/:id", userController.GetUser)       // GET /users/:id
-		userRoutes.PUT("/:id", userController.UpdateUser)    // PUT /users/:id
-		userRoutes.DELETE("/:id", userController.DeleteUser) // DELETE /users/:id
+		userBatchRoutes.POST("/users\\:batchCreate", middleware.Idempotency(idempotencyRepo, cfg), userController.BatchCreateUsers) // POST /users:batchCreate (mendukung Idempotency-Key)
+		userBatchRoutes.PATCH("/users\\:batchUpdate", userController.BatchUpdateUsers)                                              // PATCH /users:batchUpdate
+		userBatchRoutes.POST("/users\\:batchDelete", userController.BatchDeleteUsers)                                               // POST /users:batchDelete
+	}
+
+	// OpenID Connect provider; ID token harus bisa diverifikasi client lewat JWKS,
+	// jadi hanya aktif dengan signing key asimetris (JWT_SIGNING_KEY_FILE)
+	oidcEnabled := jwtKeys.Asymmetric()
+	if oidcEnabled {
+		router.GET("/.well-known/openid-configuration", oauthController.Discovery)
+
+		oauthRoutes := router.Group("/oauth")
+		oauthRoutes.Use(middleware.RateLimit(rateLimitStore, middleware.RateLimitGroupAuth, authRateLimit)) // Batasi request per IP
+		{
+			oauthRoutes.GET("/authorize", oauthController.AuthorizeForm) // GET /oauth/authorize (form login)
+			oauthRoutes.POST("/authorize", oauthController.Authorize)    // POST /oauth/authorize
+			oauthRoutes.POST("/token", oauthController.Token)            // POST /oauth/token
+		}
+
+		userInfoRoutes := router.Group("/userinfo")
+		userInfoRoutes.Use(middleware.UserInfoAuth(jwtKeys, revocationRepo, apiKeyService)) // Juga menerima access token OAuth
+		userInfoRoutes.Use(middleware.RateLimit(rateLimitStore, middleware.RateLimitGroupAPI, apiRateLimit))
+		{
+			userInfoRoutes.GET("", oauthController.UserInfo)  // GET /userinfo
+			userInfoRoutes.POST("", oauthController.UserInfo) // POST /userinfo
+		}
+
+		oauthClientRoutes := router.Group("/oauth/clients")
+		oauthClientRoutes.Use(middleware.JWTAuth(jwtKeys, revocationRepo, apiKeyService))
+		oauthClientRoutes.Use(middleware.RateLimit(rateLimitStore, middleware.RateLimitGroupAPI, apiRateLimit))
+		oauthClientRoutes.Use(middleware.RequireVerifiedEmail(cfg))
+		oauthClientRoutes.Use(middleware.RequireAdminMFA(cfg))
+		oauthClientRoutes.Use(middleware.Authorize()) // Hanya admin (lihat authz.PermOAuthClientManage)
+		{
+			oauthClientRoutes.POST("", oauthController.CreateClient)              // POST /oauth/clients
+			oauthClientRoutes.GET("", oauthController.ListClients)                // GET /oauth/clients
+			oauthClientRoutes.DELETE("/:client_id", oauthController.DeleteClient) // DELETE /oauth/clients/:client_id
+		}
+	} else {
+		log.Println("OpenID Connect nonaktif: set JWT_SIGNING_KEY_FILE untuk mengaktifkan /oauth/*")
 	}
 
 	// ==========================================
@@ -126,15 +330,58 @@ func main() {
 	log.Println("✓ REST API Endpoints:")
 	log.Println("  Public:")
 	log.Println("    - GET    /health")
+	log.Println("    - GET    /.well-known/jwks.json")
 	log.Println("    - POST   /auth/register")
 	log.Println("    - POST   /auth/login")
+	log.Println("    - POST   /auth/refresh")
+	log.Println("    - POST   /auth/password/forgot")
+	log.Println("    - POST   /auth/password/reset")
+	log.Println("    - POST   /auth/mfa/verify")
+	log.Println("    - GET    /auth/verify?token=")
 	log.Println("  Protected (requires JWT):")
+	log.Println("    - POST   /auth/logout")
+	log.Println("    - POST   /auth/logout-all")
+	log.Println("    - POST   /auth/password/change")
+	log.Println("    - POST   /auth/verify/resend")
+	log.Println("    - POST   /auth/mfa/enroll")
+	log.Println("    - POST   /auth/mfa/confirm")
+	log.Println("    - POST   /auth/mfa/disable")
+	log.Println("    - GET    /auth/sessions")
+	log.Println("    - DELETE /auth/sessions/:id")
+	log.Println("    - POST   /auth/api-keys")
+	log.Println("    - GET    /auth/api-keys")
+	log.Println("    - DELETE /auth/api-keys/:id")
 	log.Println("    - POST   /users")
 	log.Println("    - GET    /users")
+	log.Println("    - POST   /users:batchCreate")
+	log.Println("    - PATCH  /users:batchUpdate")
+	log.Println("    - POST   /users:batchDelete")
+	log.Println("    - GET    /users/export")
+	log.Println("    - POST   /users/import")
+	log.Println("    - GET    /users/import/:job_id")
 	log.Println("    - GET    /users/:id")
 	log.Println("    - PUT    /users/:id")
-	log.Println("    - DELETE /users/:id")
+	log.Println("    - PATCH  /users/:id")
+	log.Println("    - DELETE /users/:id (?hard=true untuk hapus permanen)")
+	log.Println("    - POST   /users/:id/restore")
+	log.Println("    - POST   /users/:id/unlock")
+	log.Println("    - GET    /users/:id/sessions")
+	log.Println("    - DELETE /users/:id/sessions/:session_id")
+	if oidcEnabled {
+		log.Println("  OpenID Connect:")
+		log.Println("    - GET    /.well-known/openid-configuration")
+		log.Println("    - GET    /oauth/authorize")
+		log.Println("    - POST   /oauth/authorize")
+		log.Println("    - POST   /oauth/token")
+		log.Println("    - GET    /userinfo (requires JWT)")
+		log.Println("    - POST   /oauth/clients (admin)")
+		log.Println("    - GET    /oauth/clients (admin)")
+		log.Println("    - DELETE /oauth/clients/:client_id (admin)")
+	}
 
+	// Route /users\:batchX bergantung pada Run: hanya Run yang mengubah "\:" di route
+	// tree menjadi ":" literal. Jika diganti http.Server{Handler: router}, route batch
+	// menjadi 404. Perilaku ini diuji di controller/user_controller_test.go.
 	if err := router.Run(":" + cfg.HTTPPort); err != nil {
 		log.Fatal("Gagal menjalankan HTTP server:", err)
 	}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"api-user-crud-go/authz"
+	"api-user-crud-go/entity"
+	"context"
+	"strings"
+)
+
+// Scheme kredensial yang diterima JWTAuth dan GRPCAuthInterceptor.
+const (
+	schemeBearer = "Bearer"
+	schemeAPIKey = "ApiKey"
+)
+
+// APIKeyAuthenticator memvalidasi API key dan mengembalikan pemiliknya beserta
+// scope key tersebut (nil = semua permission role user).
+// Diimplementasikan oleh service.APIKeyService.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*entity.User, []authz.Permission, error)
+}
+
+// parseCredentials membaca kredensial dari header Authorization
+// ("Bearer <jwt>" atau "ApiKey <key>") atau, jika kosong, dari header X-API-Key.
+// scheme kosong berarti tidak ada kredensial; ok bernilai false jika formatnya salah.
+func parseCredentials(authorization, apiKey string) (scheme, credential string, ok bool) {
+	if authorization == "" {
+		if apiKey == "" {
+			return "", "", true
+		}
+		return schemeAPIKey, apiKey, true
+	}
+
+	parts := strings.Split(authorization, " ")
+	if len(parts) != 2 || (parts[0] != schemeBearer && parts[0] != schemeAPIKey) {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
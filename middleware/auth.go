@@ -1,73 +1,225 @@
 package middleware
 
 import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/authz"
 	"api-user-crud-go/config"
-	"net/http"
-	"strings"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Claims adalah struktur JWT claims
+// Claims adalah struktur JWT claims.
+// ID token (claim "jti") ada di RegisteredClaims.ID dan dipakai untuk revocation.
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID        uint   `json:"user_id"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	MFA           bool   `json:"mfa"`             // true jika user login dengan 2FA
+	SessionID     uint   `json:"sid,omitempty"`   // session login (lihat entity.Session); 0 untuk token OIDC
+	Scope         string `json:"scope,omitempty"` // scope OAuth yang diberikan; hanya di access token OAuth
 	jwt.RegisteredClaims
 }
 
-// JWTAuth adalah middleware untuk validasi JWT token
-func JWTAuth(cfg *config.Config) gin.HandlerFunc {
+// IsOAuth mengecek apakah token adalah access token OAuth yang diterbitkan
+// /oauth/token untuk sebuah client (claim aud berisi client_id).
+func (c *Claims) IsOAuth() bool {
+	return len(c.Audience) > 0
+}
+
+// JWTAuth adalah middleware untuk validasi JWT token.
+// Selain "Authorization: Bearer <jwt>", API key diterima lewat
+// "Authorization: ApiKey <key>" atau header X-API-Key jika apiKeys tidak nil.
+// Request dengan API key mendapat context user yang sama, ditambah "scopes".
+// Request yang ditolak dijawab 401 oleh exception.ErrorHandler.
+// Access token OAuth ditolak; token itu hanya diterima oleh UserInfoAuth.
+func JWTAuth(keys *jwtkeys.KeySet, revocations repository.TokenRevocationRepository, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	return jwtAuth(keys, revocations, apiKeys, false)
+}
+
+// UserInfoAuth sama dengan JWTAuth, tetapi juga menerima access token OAuth.
+// Hanya dipasang di /userinfo. Untuk token OAuth, "oauth_scope" berisi scope
+// yang diberikan dan "scopes" dikosongkan sehingga Authorize menolak semua permission.
+func UserInfoAuth(keys *jwtkeys.KeySet, revocations repository.TokenRevocationRepository, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	return jwtAuth(keys, revocations, apiKeys, true)
+}
+
+func jwtAuth(keys *jwtkeys.KeySet, revocations repository.TokenRevocationRepository, apiKeys APIKeyAuthenticator, acceptOAuth bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		scheme, credential, ok := parseCredentials(c.GetHeader("Authorization"), c.GetHeader("X-API-Key"))
+		if !ok {
+			c.Error(apperror.Unauthorized("Invalid authorization format"))
 			c.Abort()
 			return
 		}
 
-		// Format: Bearer <token>
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+		switch scheme {
+		case "":
+			c.Error(apperror.Unauthorized("Authorization header required"))
 			c.Abort()
 			return
-		}
+		case schemeAPIKey:
+			// Endpoint manajemen akun (logout, password, 2FA, API key) hanya
+			// menerima token sesi, agar key yang bocor tidak bisa membuat key baru
+			if apiKeys == nil {
+				c.Error(apperror.Unauthorized("API keys are not accepted for this endpoint"))
+				c.Abort()
+				return
+			}
 
-		tokenString := parts[1]
-		claims := &Claims{}
+			user, scopes, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), credential)
+			if err != nil {
+				c.Error(apperror.Unauthorized("Invalid or expired API key"))
+				c.Abort()
+				return
+			}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(cfg.JWTSecret), nil
-		})
+			c.Set("user_id", user.ID)
+			c.Set("email", user.Email)
+			c.Set("role", user.Role)
+			c.Set("email_verified", user.EmailVerifiedAt != nil)
+			c.Set("mfa", user.MFAEnabledAt != nil)
+			c.Set("scopes", scopes)
+			c.Next()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		claims, err := parseToken(c.Request.Context(), credential, keys, revocations, acceptOAuth)
+		if err != nil {
+			c.Error(apperror.Unauthorized("Invalid or expired token"))
 			c.Abort()
 			return
 		}
+		if claims.IsOAuth() {
+			c.Set("oauth_scope", claims.Scope)
+			c.Set("scopes", []authz.Permission{})
+		}
 
 		// Set user info ke context untuk digunakan di handler
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("email_verified", claims.EmailVerified)
+		c.Set("mfa", claims.MFA)
+		c.Set("jti", claims.ID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
 		c.Next()
 	}
 }
 
-// GenerateToken membuat JWT token baru
-func GenerateToken(userID uint, email string, cfg *config.Config) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(cfg.JWTExpiryHours) * time.Hour)
-	
+// ParseToken memvalidasi signature, expiry, dan status revocation sebuah token.
+// Kunci verifikasi dipilih berdasarkan header kid (lihat jwtkeys.KeySet).
+// Dipakai bersama oleh JWTAuth (REST) dan GRPCAuthInterceptor (gRPC); ctx adalah
+// context request sehingga cek revocation ikut berhenti saat request dibatalkan.
+// Access token OAuth ditolak karena hanya berlaku untuk /userinfo.
+func ParseToken(ctx context.Context, tokenString string, keys *jwtkeys.KeySet, revocations repository.TokenRevocationRepository) (*Claims, error) {
+	return parseToken(ctx, tokenString, keys, revocations, false)
+}
+
+func parseToken(ctx context.Context, tokenString string, keys *jwtkeys.KeySet, revocations repository.TokenRevocationRepository, acceptOAuth bool) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	// Token tanpa jti tidak bisa dicabut, jadi tidak diterima. Ini juga menolak
+	// ID token OIDC yang di-sign dengan kunci yang sama.
+	if claims.ID == "" || claims.UserID == 0 || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return nil, errors.New("invalid or expired token")
+	}
+	if claims.IsOAuth() && !acceptOAuth {
+		return nil, errors.New("oauth access token is only accepted by /userinfo")
+	}
+
+	revoked, err := revocations.IsRevoked(ctx, claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token has been revoked")
+	}
+
+	return claims, nil
+}
+
+// GenerateToken membuat JWT access token baru untuk session sessionID.
+// Umur token sengaja dibuat pendek (JWTExpiryMinutes); client memperpanjang
+// sesi melalui refresh token.
+// Claim mfa diisi dari status 2FA user: jika 2FA aktif, satu-satunya cara
+// mendapat token adalah lewat verifikasi kode (lihat AuthService.VerifyMFA).
+func GenerateToken(user *entity.User, sessionID uint, keys *jwtkeys.KeySet, cfg *config.Config) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL(cfg))
+
+	jti, err := generateJTI()
+	if err != nil {
+		return "", err
+	}
+
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:        user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		MFA:           user.MFAEnabledAt != nil,
+		SessionID:     sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.JWTSecret))
+	return keys.Sign(claims)
+}
+
+// GenerateOAuthAccessToken membuat access token OAuth untuk client clientID.
+// Token membawa aud (client_id) dan scope yang diberikan, tidak terikat ke
+// session login, dan hanya diterima oleh /userinfo (lihat UserInfoAuth).
+// Token tetap ikut dicabut oleh logout dari semua perangkat dan ganti password.
+func GenerateOAuthAccessToken(user *entity.User, clientID, scope string, keys *jwtkeys.KeySet, cfg *config.Config) (string, error) {
+	now := time.Now()
+
+	jti, err := generateJTI()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &Claims{
+		UserID: user.ID,
+		Scope:  scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL(cfg))),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return keys.Sign(claims)
+}
+
+// AccessTokenTTL mengembalikan umur access token sesuai konfigurasi.
+func AccessTokenTTL(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.JWTExpiryMinutes) * time.Minute
+}
+
+// generateJTI membuat ID unik untuk setiap token.
+func generateJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
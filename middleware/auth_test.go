@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"api-user-crud-go/config"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/exception"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/middleware"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// noRevocations adalah TokenRevocationRepository yang tidak pernah mencabut token.
+type noRevocations struct{}
+
+func (noRevocations) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	return nil
+}
+
+func (noRevocations) RevokeAllForUser(ctx context.Context, userID uint, before time.Time, expiresAt time.Time) error {
+	return nil
+}
+
+func (noRevocations) IsRevoked(ctx context.Context, jti string, sessionID uint, userID uint, issuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func (noRevocations) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestOAuthAccessToken_OnlyAcceptedByUserInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWTExpiryMinutes: 15}
+	keys := jwtkeys.NewHMACKeySet("test-secret")
+	user := &entity.User{Email: "alice@example.com", Role: "admin"}
+	user.ID = 1
+
+	token, err := middleware.GenerateOAuthAccessToken(user, "client-1", "openid email", keys, cfg)
+	if err != nil {
+		t.Fatalf("GenerateOAuthAccessToken returned unexpected error: %v", err)
+	}
+
+	if _, err := middleware.ParseToken(context.Background(), token, keys, noRevocations{}); err == nil {
+		t.Error("expected ParseToken (REST and gRPC API) to reject an OAuth access token")
+	}
+
+	var gotScope string
+	router := gin.New()
+	router.Use(exception.ErrorHandler())
+	router.GET("/users", middleware.JWTAuth(keys, noRevocations{}, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/userinfo", middleware.UserInfoAuth(keys, noRevocations{}, nil), func(c *gin.Context) {
+		gotScope = c.GetString("oauth_scope")
+		if scopes := middleware.CurrentSubject(c).Scopes; scopes == nil || len(scopes) != 0 {
+			t.Errorf("expected OAuth access token to carry no API permissions, got %v", scopes)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	get := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := get("/users"); code != http.StatusUnauthorized {
+		t.Errorf("expected JWTAuth to reject OAuth access token with 401, got %d", code)
+	}
+	if code := get("/userinfo"); code != http.StatusOK {
+		t.Fatalf("expected UserInfoAuth to accept OAuth access token, got %d", code)
+	}
+	if gotScope != "openid email" {
+		t.Errorf("expected oauth_scope %q, got %q", "openid email", gotScope)
+	}
+}
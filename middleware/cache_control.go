@@ -0,0 +1,14 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// CacheControl mengisi header Cache-Control pada response, misalnya untuk
+// endpoint GET yang mendukung conditional request. Nilai kosong berarti header tidak dikirim.
+func CacheControl(value string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value != "" {
+			c.Header("Cache-Control", value)
+		}
+		c.Next()
+	}
+}
@@ -1,19 +1,20 @@
 package middleware
 
 import (
-	"api-user-crud-go/config"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/proto"
+	"api-user-crud-go/repository"
 	"context"
-	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
-// GRPCAuthInterceptor adalah interceptor untuk validasi JWT di gRPC
-func GRPCAuthInterceptor(cfg *config.Config) grpc.UnaryServerInterceptor {
+// GRPCAuthInterceptor adalah interceptor untuk validasi JWT di gRPC.
+// API key diterima lewat metadata "authorization: ApiKey <key>" atau "x-api-key".
+func GRPCAuthInterceptor(keys *jwtkeys.KeySet, revocations repository.TokenRevocationRepository, apiKeys APIKeyAuthenticator) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -30,41 +31,63 @@ func GRPCAuthInterceptor(cfg *config.Config) grpc.UnaryServerInterceptor {
 			return nil, status.Error(codes.Unauthenticated, "metadata not provided")
 		}
 
-		authHeader := md.Get("authorization")
-		if len(authHeader) == 0 {
+		scheme, credential, ok := parseCredentials(firstValue(md, "authorization"), firstValue(md, "x-api-key"))
+		switch {
+		case !ok:
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+		case scheme == "":
 			return nil, status.Error(codes.Unauthenticated, "authorization token not provided")
-		}
+		case scheme == schemeAPIKey:
+			if apiKeys == nil {
+				return nil, status.Error(codes.Unauthenticated, "api keys are not accepted")
+			}
+			user, scopes, err := apiKeys.AuthenticateAPIKey(ctx, credential)
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, "invalid or expired api key")
+			}
 
-		// Format: Bearer <token>
-		parts := strings.Split(authHeader[0], " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+			ctx = context.WithValue(ctx, "user_id", user.ID)
+			ctx = context.WithValue(ctx, "email", user.Email)
+			ctx = context.WithValue(ctx, "role", user.Role)
+			ctx = context.WithValue(ctx, "email_verified", user.EmailVerifiedAt != nil)
+			ctx = context.WithValue(ctx, "mfa", user.MFAEnabledAt != nil)
+			ctx = context.WithValue(ctx, "scopes", scopes)
+			return handler(ctx, req)
 		}
 
-		tokenString := parts[1]
-		claims := &Claims{}
-
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(cfg.JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := ParseToken(ctx, credential, keys, revocations)
+		if err != nil {
 			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
 		}
 
 		// Add user info to context
 		ctx = context.WithValue(ctx, "user_id", claims.UserID)
 		ctx = context.WithValue(ctx, "email", claims.Email)
+		ctx = context.WithValue(ctx, "role", claims.Role)
+		ctx = context.WithValue(ctx, "email_verified", claims.EmailVerified)
+		ctx = context.WithValue(ctx, "mfa", claims.MFA)
+		ctx = context.WithValue(ctx, "jti", claims.ID)
+		ctx = context.WithValue(ctx, "session_id", claims.SessionID)
 
 		return handler(ctx, req)
 	}
 }
 
+// firstValue mengambil nilai pertama sebuah key metadata, atau string kosong.
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
 // isPublicMethod mengecek apakah method tidak memerlukan autentikasi
 func isPublicMethod(method string) bool {
 	publicMethods := []string{
-		"/user.UserService/Login",
-		"/user.UserService/Register",
+		proto.UserService_Login_FullMethodName,
+		proto.UserService_Register_FullMethodName,
+		proto.UserService_RefreshToken_FullMethodName,
+		proto.UserService_VerifyMFA_FullMethodName,
 	}
 
 	for _, pm := range publicMethods {
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCContextErrorInterceptor memetakan error RPC yang context-nya sudah selesai
+// menjadi codes.Canceled (client membatalkan) atau codes.DeadlineExceeded
+// (deadline client lewat). Tanpa interceptor ini, query yang dihentikan
+// dilaporkan handler sebagai Internal atau NotFound.
+func GRPCContextErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, status.FromContextError(ctxErr).Err()
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// GRPCStreamInterceptor menjalankan interceptor unary (auth, rate limit, RBAC, ...)
+// satu kali saat stream dibuka, lalu memanggil handler stream dengan context hasilnya.
+// Interceptor menerima request nil karena pesan stream belum dibaca, sehingga
+// pengecekan yang membaca isi request (misalnya target ID) tidak berlaku untuk stream.
+func GRPCStreamInterceptor(interceptors ...grpc.UnaryServerInterceptor) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		unaryInfo := &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod}
+
+		next := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+		}
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, unaryInfo, inner)
+			}
+		}
+
+		_, err := next(ss.Context(), nil)
+		return err
+	}
+}
+
+// contextServerStream mengganti context stream dengan context yang sudah diisi interceptor.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context mengembalikan context hasil interceptor.
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
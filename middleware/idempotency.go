@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"api-user-crud-go/config"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/proto"
+	"api-user-crud-go/repository"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// maxIdempotencyKeyLength adalah panjang maksimal Idempotency-Key dari client.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders adalah header response yang disimpan bersama body dan dikirim
+// ulang saat replay, karena bagian dari kontrak endpoint (ETag untuk If-Match).
+var replayedHeaders = []string{"ETag", "Location"}
+
+// idempotentMethods adalah RPC yang mendukung metadata idempotency-key,
+// beserta konstruktor tipe response-nya untuk replay.
+var idempotentMethods = map[string]func() protobuf.Message{
+	proto.UserService_CreateUser_FullMethodName: func() protobuf.Message { return &proto.UserMessage{} },
+}
+
+// Idempotency membuat endpoint aman di-retry dengan header Idempotency-Key.
+// Response sukses disimpan selama IDEMPOTENCY_KEY_TTL_HOURS; retry dengan key dan body
+// yang sama mendapat response yang sama, body berbeda ditolak dengan 422.
+// Harus dipasang setelah JWTAuth karena key dipisah per user.
+func Idempotency(repo repository.IdempotencyRepository, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientKey := c.GetHeader("Idempotency-Key")
+		if clientKey == "" {
+			c.Next()
+			return
+		}
+		if len(clientKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:   "Invalid Idempotency-Key",
+				Message: fmt.Sprintf("key must be at most %d characters", maxIdempotencyKeyLength),
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid input", Message: err.Error()})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotencyStoreKey(c.Request.Method+" "+c.FullPath(), c.GetUint("user_id"), clientKey)
+		record, reserved, err := reserveIdempotencyKey(c.Request.Context(), repo, cfg, key, hashJSONBody(body))
+		if err != nil {
+			log.Printf("Idempotency store error: %v", err)
+			c.Next()
+			return
+		}
+		if !reserved {
+			replayIdempotentResponse(c, record, hashJSONBody(body))
+			return
+		}
+
+		// Tangkap response agar bisa disimpan; key dilepas jika request gagal atau panic.
+		// Release/Complete tetap jalan walaupun request sudah dibatalkan atau timeout,
+		// agar key tidak tertahan sebagai "in progress" sampai expired.
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		storeCtx := context.WithoutCancel(c.Request.Context())
+		completed := false
+		defer func() {
+			if !completed {
+				if err := repo.Release(storeCtx, key); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if writer.Status() >= 200 && writer.Status() < 300 {
+			if err := repo.Complete(storeCtx, key, writer.Status(), encodeReplayedHeaders(writer.Header()), writer.body.Bytes()); err != nil {
+				log.Printf("Failed to store idempotent response: %v", err)
+				return
+			}
+			completed = true
+		}
+	}
+}
+
+// GRPCIdempotencyInterceptor adalah versi gRPC dari Idempotency untuk RPC di
+// idempotentMethods, dengan key dari metadata idempotency-key.
+// Harus dipasang setelah GRPCAuthInterceptor.
+func GRPCIdempotencyInterceptor(repo repository.IdempotencyRepository, cfg *config.Config) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		newResponse, ok := idempotentMethods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("idempotency-key")
+		if len(values) == 0 || values[0] == "" {
+			return handler(ctx, req)
+		}
+		clientKey := values[0]
+		if len(clientKey) > maxIdempotencyKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency-key must be at most %d characters", maxIdempotencyKeyLength)
+		}
+
+		reqBytes, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(req.(protobuf.Message))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode request: %v", err)
+		}
+		requestHash := hashBytes(reqBytes)
+
+		userID, _ := ctx.Value("user_id").(uint)
+		key := idempotencyStoreKey(info.FullMethod, userID, clientKey)
+		record, reserved, err := reserveIdempotencyKey(ctx, repo, cfg, key, requestHash)
+		if err != nil {
+			log.Printf("Idempotency store error: %v", err)
+			return handler(ctx, req)
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				return nil, status.Error(codes.FailedPrecondition, "idempotency-key was already used with a different request")
+			case record.CompletedAt == nil:
+				return nil, status.Error(codes.Aborted, "a request with this idempotency-key is still being processed")
+			}
+			resp := newResponse()
+			if err := protobuf.Unmarshal(record.ResponseBody, resp); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to decode stored response: %v", err)
+			}
+			_ = grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+			return resp, nil
+		}
+
+		storeCtx := context.WithoutCancel(ctx)
+		completed := false
+		defer func() {
+			if !completed {
+				if err := repo.Release(storeCtx, key); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		respBytes, err := protobuf.Marshal(resp.(protobuf.Message))
+		if err == nil {
+			err = repo.Complete(storeCtx, key, 0, nil, respBytes)
+		}
+		if err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+			return resp, nil
+		}
+		completed = true
+		return resp, nil
+	}
+}
+
+// replayIdempotentResponse menjawab request dengan key yang sudah pernah dipakai.
+func replayIdempotentResponse(c *gin.Context, record *entity.IdempotencyKey, requestHash string) {
+	switch {
+	case record.RequestHash != requestHash:
+		c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+			Error:   "Idempotency-Key reused",
+			Message: "this Idempotency-Key was already used with a different request body",
+		})
+	case record.CompletedAt == nil:
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "Request in progress",
+			Message: "a request with this Idempotency-Key is still being processed",
+		})
+	default:
+		var headers map[string]string
+		if len(record.Headers) > 0 {
+			if err := json.Unmarshal(record.Headers, &headers); err != nil {
+				log.Printf("Failed to decode stored idempotent headers: %v", err)
+			}
+		}
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(record.StatusCode, "application/json; charset=utf-8", record.ResponseBody)
+	}
+	c.Abort()
+}
+
+// encodeReplayedHeaders mengambil header di replayedHeaders dari response sebagai JSON.
+func encodeReplayedHeaders(header http.Header) []byte {
+	headers := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	encoded, _ := json.Marshal(headers)
+	return encoded
+}
+
+// reserveIdempotencyKey mencadangkan key untuk request ini.
+func reserveIdempotencyKey(ctx context.Context, repo repository.IdempotencyRepository, cfg *config.Config, key, requestHash string) (*entity.IdempotencyKey, bool, error) {
+	now := time.Now()
+	return repo.Reserve(ctx, &entity.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(time.Duration(cfg.IdempotencyKeyTTLHours) * time.Hour),
+	}, now)
+}
+
+// idempotencyStoreKey memisahkan key per operasi dan per user, sehingga key
+// yang sama dari client berbeda tidak saling bertabrakan.
+func idempotencyStoreKey(operation string, userID uint, clientKey string) string {
+	return fmt.Sprintf("%s:%d:%s", operation, userID, clientKey)
+}
+
+// hashJSONBody menghitung hash body JSON setelah dinormalisasi (urutan field dan
+// whitespace tidak berpengaruh). Body yang bukan JSON valid di-hash apa adanya.
+func hashJSONBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if normalized, err := json.Marshal(v); err == nil {
+			return hashBytes(normalized)
+		}
+	}
+	return hashBytes(body)
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder menyalin body response sambil tetap menulisnya ke client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequireIfMatch menolak request tulis tanpa header If-Match dengan 428, agar
+// client tidak menimpa perubahan orang lain tanpa sadar.
+// Hanya aktif jika REQUIRE_IF_MATCH=true; dipasang per route PUT/PATCH/DELETE.
+func RequireIfMatch(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.RequireIfMatch && c.GetHeader("If-Match") == "" {
+			c.Error(apperror.PreconditionRequired("If-Match header is required; use the ETag from GET"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// etagRequest adalah request gRPC yang membawa field etag (UpdateUserRequest, DeleteUserRequest).
+type etagRequest interface {
+	GetEtag() string
+}
+
+// GRPCRequireETagInterceptor adalah versi gRPC dari RequireIfMatch: request yang
+// memiliki field etag ditolak dengan FAILED_PRECONDITION jika etag kosong.
+func GRPCRequireETagInterceptor(cfg *config.Config) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if r, ok := req.(etagRequest); ok && cfg.RequireIfMatch && r.GetEtag() == "" {
+			return nil, status.Error(codes.FailedPrecondition, "etag is required; use the etag from GetUser")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
+	"api-user-crud-go/entity"
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequireAdminMFA menolak request admin yang login tanpa 2FA.
+// Hanya aktif jika REQUIRE_ADMIN_MFA=true; harus dipasang setelah JWTAuth.
+// Admin tetap bisa memakai /auth/* untuk mengaktifkan 2FA.
+func RequireAdminMFA(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.RequireAdminMFA && c.GetString("role") == entity.RoleAdmin && !c.GetBool("mfa") {
+			c.Error(apperror.Forbidden("Two-factor authentication required for admin accounts"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GRPCAdminMFAInterceptor adalah versi gRPC dari RequireAdminMFA.
+// Harus dipasang setelah GRPCAuthInterceptor.
+func GRPCAdminMFAInterceptor(cfg *config.Config) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !cfg.RequireAdminMFA || isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		role, _ := ctx.Value("role").(string)
+		mfa, _ := ctx.Value("mfa").(bool)
+		if role == entity.RoleAdmin && !mfa {
+			return nil, status.Error(codes.PermissionDenied, "two-factor authentication required for admin accounts")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"api-user-crud-go/dto"
+	"api-user-crud-go/ratelimit"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Nama group rate limit. REST dan gRPC memakai nama yang sama sehingga
+// satu client berbagi bucket yang sama di kedua transport.
+const (
+	RateLimitGroupAuth = "auth" // endpoint auth publik, dibatasi per IP
+	RateLimitGroupAPI  = "api"  // endpoint yang butuh JWT, dibatasi per user ID
+)
+
+// RateLimit membatasi request per client dengan token bucket untuk satu route group.
+// Client diidentifikasi dengan user ID jika dipasang setelah JWTAuth, selain itu dengan IP.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		res, ok := takeToken(store, group, rateLimitClientKey(c.GetUint("user_id"), c.ClientIP()), limit)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		for key, value := range rateLimitHeaders(limit, res) {
+			c.Header(key, value)
+		}
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, dto.ErrorResponse{
+				Error:   "Too many requests",
+				Message: fmt.Sprintf("retry after %d seconds", retryAfter),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GRPCRateLimitInterceptor adalah versi gRPC dari RateLimit. RPC publik masuk
+// group auth (per IP), sisanya masuk group api (per user ID).
+// Harus dipasang setelah GRPCAuthInterceptor.
+func GRPCRateLimitInterceptor(store ratelimit.Store, authLimit, apiLimit ratelimit.Limit) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		group, limit := RateLimitGroupAPI, apiLimit
+		if isPublicMethod(info.FullMethod) {
+			group, limit = RateLimitGroupAuth, authLimit
+		}
+		if !limit.Enabled() {
+			return handler(ctx, req)
+		}
+
+		userID, _ := ctx.Value("user_id").(uint)
+		res, ok := takeToken(store, group, rateLimitClientKey(userID, PeerIP(ctx)), limit)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		md := metadata.New(rateLimitHeaders(limit, res))
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			md.Set("retry-after", strconv.Itoa(retryAfter))
+			_ = grpc.SetHeader(ctx, md)
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry after %d seconds", retryAfter)
+		}
+		_ = grpc.SetHeader(ctx, md)
+		return handler(ctx, req)
+	}
+}
+
+// PeerIP mengambil alamat IP client dari koneksi gRPC.
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// takeToken mengambil token dari store. Jika store error, request tetap
+// dilanjutkan (fail open) agar gangguan store tidak menjatuhkan seluruh API.
+func takeToken(store ratelimit.Store, group, clientKey string, limit ratelimit.Limit) (ratelimit.Result, bool) {
+	res, err := store.Take(group+":"+clientKey, limit, time.Now())
+	if err != nil {
+		log.Printf("Rate limit store error: %v", err)
+		return ratelimit.Result{}, false
+	}
+	return res, true
+}
+
+// rateLimitClientKey mengidentifikasi client: user ID jika sudah terautentikasi, selain itu IP.
+func rateLimitClientKey(userID uint, ip string) string {
+	if userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + ip
+}
+
+// rateLimitHeaders membuat header RateLimit-* (draft IETF RateLimit header fields).
+func rateLimitHeaders(limit ratelimit.Limit, res ratelimit.Result) map[string]string {
+	return map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(res.Limit),
+		"RateLimit-Remaining": strconv.Itoa(res.Remaining),
+		"RateLimit-Reset":     strconv.Itoa(ceilSeconds(res.ResetAfter)),
+		"RateLimit-Policy":    fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Per)),
+	}
+}
+
+// ceilSeconds membulatkan durasi ke atas dalam detik.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"api-user-crud-go/config"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/ratelimit"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitRouter(t *testing.T, trustedProxies string, limit ratelimit.Limit) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	cfg := &config.Config{TrustedProxies: trustedProxies}
+	if err := router.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	router.Use(middleware.RateLimit(ratelimit.NewMemoryStore(), middleware.RateLimitGroupAuth, limit))
+	router.POST("/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func postLogin(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimit_SpoofedForwardedForDoesNotResetBucket(t *testing.T) {
+	router := newRateLimitRouter(t, "", ratelimit.Limit{Requests: 2, Per: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if code := postLogin(router, "203.0.113.7:40000", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, code)
+		}
+	}
+	if code := postLogin(router, "203.0.113.7:40000", "198.51.100.99"); code != http.StatusTooManyRequests {
+		t.Errorf("expected spoofed X-Forwarded-For to stay limited with 429, got %d", code)
+	}
+}
+
+func TestRateLimit_TrustedProxyForwardsClientIP(t *testing.T) {
+	router := newRateLimitRouter(t, "10.0.0.0/8", ratelimit.Limit{Requests: 1, Per: time.Minute})
+
+	if code := postLogin(router, "10.0.0.5:40000", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := postLogin(router, "10.0.0.5:40000", "198.51.100.2"); code != http.StatusOK {
+		t.Errorf("expected a different client behind the trusted proxy to get its own bucket, got %d", code)
+	}
+	if code := postLogin(router, "10.0.0.5:40000", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the first client to be limited with 429, got %d", code)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequireVerifiedEmail menolak request dari user yang emailnya belum diverifikasi.
+// Hanya aktif jika REQUIRE_EMAIL_VERIFICATION=true; harus dipasang setelah JWTAuth.
+func RequireVerifiedEmail(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.RequireEmailVerification && !c.GetBool("email_verified") {
+			c.Error(apperror.Forbidden("Email not verified"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GRPCVerifiedEmailInterceptor adalah versi gRPC dari RequireVerifiedEmail.
+// Harus dipasang setelah GRPCAuthInterceptor.
+func GRPCVerifiedEmailInterceptor(cfg *config.Config) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !cfg.RequireEmailVerification || isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if verified, _ := ctx.Value("email_verified").(bool); !verified {
+			return nil, status.Error(codes.PermissionDenied, "email not verified")
+		}
+		return handler(ctx, req)
+	}
+}
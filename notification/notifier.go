@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message adalah pesan yang dikirim ke user (email, SMS, dll).
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Notifier adalah interface untuk mengirim pesan ke user.
+// Implementasi produksi (SMTP, provider email, dll) cukup memenuhi interface ini.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// NewNotifier membuat Notifier sesuai driver yang dikonfigurasi.
+// Driver yang tersedia: "log" (default) dan "file".
+func NewNotifier(driver, filePath string) Notifier {
+	if driver == "file" {
+		return NewFileNotifier(filePath)
+	}
+	return NewLogNotifier()
+}
+
+// logNotifier menulis pesan ke log aplikasi. Cocok untuk development lokal.
+type logNotifier struct{}
+
+// NewLogNotifier membuat Notifier yang menulis pesan ke log.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+// Send menulis pesan ke log.
+func (n *logNotifier) Send(msg Message) error {
+	log.Printf("✉ [notification] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// fileNotifier menambahkan pesan ke file sebagai JSON per baris.
+// Cocok untuk test atau development ketika pesan perlu dibaca oleh tool lain.
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileNotifier membuat Notifier yang menulis pesan ke file.
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+// Send menambahkan pesan ke file.
+func (n *fileNotifier) Send(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if msg.SentAt.IsZero() {
+		msg.SentAt = time.Now()
+	}
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(msg)
+}
@@ -0,0 +1,74 @@
+// Package ratelimit menyediakan rate limiter token bucket.
+// Penyimpanan bucket ada di belakang interface Store sehingga implementasi
+// in-memory bawaan bisa diganti (misalnya Redis) saat aplikasi di-scale.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit adalah aturan token bucket: maksimal Requests request per Per,
+// dengan burst sebesar Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled mengecek apakah limit aktif. Limit kosong berarti tanpa batas.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// String mengembalikan limit dalam format yang sama dengan ParseLimit.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// ParseLimit membaca limit dengan format "<jumlah>/<durasi>", misalnya
+// "20/1m", "5/s", atau "1000/1h". String kosong atau "0" berarti tanpa batas.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	countStr, perStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<duration>", s)
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a non-negative integer", s)
+	}
+
+	// "s", "m", "h" tanpa angka berarti satu satuan
+	if perStr == "s" || perStr == "m" || perStr == "h" {
+		perStr = "1" + perStr
+	}
+	per, err := time.ParseDuration(perStr)
+	if err != nil || per <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: invalid duration", s)
+	}
+
+	return Limit{Requests: count, Per: per}, nil
+}
+
+// Result adalah hasil pengambilan token dari bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int           // kapasitas bucket
+	Remaining  int           // token tersisa setelah request ini
+	RetryAfter time.Duration // kapan token berikutnya tersedia (jika ditolak)
+	ResetAfter time.Duration // kapan bucket terisi penuh lagi
+}
+
+// Store adalah penyimpanan bucket. Implementasi harus aman dipakai bersamaan
+// dari banyak goroutine.
+type Store interface {
+	// Take mengambil satu token dari bucket milik key.
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval adalah seberapa sering bucket yang sudah penuh kembali dibuang.
+const sweepInterval = time.Minute
+
+// bucket menyimpan state token bucket untuk satu key.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// memoryStore adalah Store in-memory. State hilang saat restart dan tidak
+// dibagi antar instance.
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore membuat Store in-memory.
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take mengambil satu token dari bucket milik key.
+func (s *memoryStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Per.Seconds() // token per detik
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: capacity, last: now, limit: limit}
+		s.buckets[key] = b
+	}
+
+	// Isi ulang token sesuai waktu yang berlalu
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.last = now
+	}
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.ResetAfter = secondsToDuration((capacity - b.tokens) / rate)
+
+	return result, nil
+}
+
+// sweep membuang bucket yang sudah terisi penuh kembali; bucket seperti itu
+// sama saja dengan bucket baru sehingga tidak perlu disimpan.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.limit.Per {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
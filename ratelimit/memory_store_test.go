@@ -0,0 +1,82 @@
+package ratelimit_test
+
+import (
+	"api-user-crud-go/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ratelimit.Limit
+		wantErr bool
+	}{
+		{"20/1m", ratelimit.Limit{Requests: 20, Per: time.Minute}, false},
+		{"5/s", ratelimit.Limit{Requests: 5, Per: time.Second}, false},
+		{"1000/1h", ratelimit.Limit{Requests: 1000, Per: time.Hour}, false},
+		{"", ratelimit.Limit{}, false},
+		{"0", ratelimit.Limit{}, false},
+		{"20", ratelimit.Limit{}, true},
+		{"x/1m", ratelimit.Limit{}, true},
+		{"20/0s", ratelimit.Limit{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ratelimit.ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q): unexpected error state: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q): expected %+v, got %+v", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 3, Per: 3 * time.Second} // 1 token per detik
+	now := time.Unix(1000, 0)
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.Take("ip:1.2.3.4", limit, now)
+		if err != nil || !res.Allowed {
+			t.Fatalf("expected request to be allowed, got %+v (err %v)", res, err)
+		}
+		if res.Remaining != i {
+			t.Errorf("expected %d remaining, got %d", i, res.Remaining)
+		}
+	}
+
+	res, _ := store.Take("ip:1.2.3.4", limit, now)
+	if res.Allowed {
+		t.Fatal("expected request over burst to be rejected")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("expected retry after 1s, got %v", res.RetryAfter)
+	}
+	if res.ResetAfter != 3*time.Second {
+		t.Errorf("expected reset after 3s, got %v", res.ResetAfter)
+	}
+
+	// Key lain punya bucket sendiri
+	if res, _ := store.Take("ip:5.6.7.8", limit, now); !res.Allowed {
+		t.Error("expected other key to be allowed")
+	}
+
+	// Setelah 1 detik, satu token terisi kembali
+	if res, _ := store.Take("ip:1.2.3.4", limit, now.Add(time.Second)); !res.Allowed {
+		t.Error("expected request to be allowed after refill")
+	}
+}
+
+func TestMemoryStore_DisabledLimit(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+
+	for i := 0; i < 100; i++ {
+		if res, _ := store.Take("ip:1.2.3.4", ratelimit.Limit{}, time.Now()); !res.Allowed {
+			t.Fatal("expected disabled limit to allow every request")
+		}
+	}
+}
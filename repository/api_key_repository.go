@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository adalah interface untuk penyimpanan API key user.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	FindByUser(ctx context.Context, userID uint) ([]entity.APIKey, error)
+	Delete(ctx context.Context, userID, id uint) error
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+// apiKeyRepositoryImpl adalah implementasi dari APIKeyRepository.
+type apiKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository membuat instance baru APIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepositoryImpl{db: db}
+}
+
+// Create menyimpan API key baru.
+func (r *apiKeyRepositoryImpl) Create(ctx context.Context, key *entity.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// FindByPrefix mencari API key berdasarkan prefix-nya.
+func (r *apiKeyRepositoryImpl) FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// FindByUser mengambil semua API key milik user, terbaru lebih dulu.
+func (r *apiKeyRepositoryImpl) FindByUser(ctx context.Context, userID uint) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Delete mencabut API key milik user. Key milik user lain dianggap tidak ada.
+func (r *apiKeyRepositoryImpl) Delete(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("api key not found")
+	}
+	return nil
+}
+
+// TouchLastUsed memperbarui waktu terakhir key dipakai.
+func (r *apiKeyRepositoryImpl) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
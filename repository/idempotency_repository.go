@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"api-user-crud-go/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository adalah interface untuk penyimpanan idempotency key.
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *entity.IdempotencyKey, now time.Time) (*entity.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, headers, body []byte) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// idempotencyRepositoryImpl adalah implementasi dari IdempotencyRepository.
+type idempotencyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository membuat instance baru IdempotencyRepository.
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepositoryImpl{db: db}
+}
+
+// Reserve mencadangkan key secara atomik untuk request yang sedang diproses.
+// Jika key sudah dipakai (dan belum expired), catatan yang ada dikembalikan
+// dengan reserved = false. Catatan yang sudah expired ditimpa.
+func (r *idempotencyRepositoryImpl) Reserve(ctx context.Context, record *entity.IdempotencyKey, now time.Time) (*entity.IdempotencyKey, bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "idempotency_key"}},
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "idempotency_keys", Name: "expires_at"}, Value: now},
+		}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"request_hash":  record.RequestHash,
+			"status_code":   0,
+			"response_body": nil,
+			"completed_at":  nil,
+			"expires_at":    record.ExpiresAt,
+			"created_at":    now,
+		}),
+	}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing entity.IdempotencyKey
+	if err := r.db.WithContext(ctx).Where("idempotency_key = ?", record.Key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// Complete menyimpan response dari request yang berhasil diproses.
+// headers adalah header response yang perlu di-replay (JSON, boleh nil).
+func (r *idempotencyRepositoryImpl) Complete(ctx context.Context, key string, statusCode int, headers, body []byte) error {
+	return r.db.WithContext(ctx).Model(&entity.IdempotencyKey{}).
+		Where("idempotency_key = ?", key).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"headers":       headers,
+			"response_body": body,
+			"completed_at":  time.Now(),
+		}).Error
+}
+
+// Release menghapus key yang gagal diproses agar client bisa mencoba lagi.
+func (r *idempotencyRepositoryImpl) Release(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("idempotency_key = ?", key).Delete(&entity.IdempotencyKey{}).Error
+}
+
+// DeleteExpired menghapus idempotency key yang sudah expired.
+func (r *idempotencyRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,59 @@
+package repository_test
+
+import (
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdempotency_Reserve(t *testing.T) {
+	repo := repository.NewIdempotencyRepository(newTestDB(t))
+	now := time.Now()
+	key := "POST /users:1:abc"
+
+	_, reserved, err := repo.Reserve(context.Background(), &entity.IdempotencyKey{Key: key, RequestHash: "h1", ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil || !reserved {
+		t.Fatalf("expected first Reserve to succeed, got reserved=%v err=%v", reserved, err)
+	}
+
+	// Key yang sama selagi diproses: catatan lama dikembalikan
+	existing, reserved, err := repo.Reserve(context.Background(), &entity.IdempotencyKey{Key: key, RequestHash: "h2", ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil || reserved {
+		t.Fatalf("expected second Reserve to be rejected, got reserved=%v err=%v", reserved, err)
+	}
+	if existing.RequestHash != "h1" || existing.CompletedAt != nil {
+		t.Errorf("expected pending record with original hash, got %+v", existing)
+	}
+
+	if err := repo.Complete(context.Background(), key, 201, []byte(`{"ETag":"\"1\""}`), []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Complete returned unexpected error: %v", err)
+	}
+	existing, reserved, _ = repo.Reserve(context.Background(), &entity.IdempotencyKey{Key: key, RequestHash: "h1", ExpiresAt: now.Add(time.Hour)}, now)
+	if reserved || existing.CompletedAt == nil || existing.StatusCode != 201 || string(existing.ResponseBody) != `{"id":1}` ||
+		string(existing.Headers) != `{"ETag":"\"1\""}` {
+		t.Errorf("expected completed record to be replayed, got %+v", existing)
+	}
+
+	// Setelah expired, key boleh dipakai lagi
+	later := now.Add(2 * time.Hour)
+	record, reserved, err := repo.Reserve(context.Background(), &entity.IdempotencyKey{Key: key, RequestHash: "h3", ExpiresAt: later.Add(time.Hour)}, later)
+	if err != nil || !reserved || record.RequestHash != "h3" {
+		t.Errorf("expected expired key to be reserved again, got reserved=%v record=%+v err=%v", reserved, record, err)
+	}
+}
+
+func TestIdempotency_Release(t *testing.T) {
+	repo := repository.NewIdempotencyRepository(newTestDB(t))
+	now := time.Now()
+	key := "POST /users:1:abc"
+
+	repo.Reserve(context.Background(), &entity.IdempotencyKey{Key: key, RequestHash: "h1", ExpiresAt: now.Add(time.Hour)}, now)
+	if err := repo.Release(context.Background(), key); err != nil {
+		t.Fatalf("Release returned unexpected error: %v", err)
+	}
+	if _, reserved, _ := repo.Reserve(context.Background(), &entity.IdempotencyKey{Key: key, RequestHash: "h2", ExpiresAt: now.Add(time.Hour)}, now); !reserved {
+		t.Error("expected released key to be reserved again")
+	}
+}
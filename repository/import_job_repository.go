@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportJobRepository adalah interface untuk penyimpanan job import user.
+type ImportJobRepository interface {
+	Create(ctx context.Context, job *entity.ImportJob) error
+	Update(ctx context.Context, job *entity.ImportJob) error
+	FindByID(ctx context.Context, userID uint, id string) (*entity.ImportJob, error)
+	FailUnfinished(ctx context.Context, reason string) (int64, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// importJobRepositoryImpl adalah implementasi dari ImportJobRepository.
+type importJobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewImportJobRepository membuat instance baru ImportJobRepository.
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	return &importJobRepositoryImpl{db: db}
+}
+
+// Create menyimpan job import baru.
+func (r *importJobRepositoryImpl) Create(ctx context.Context, job *entity.ImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// Update menyimpan status dan progres job.
+func (r *importJobRepositoryImpl) Update(ctx context.Context, job *entity.ImportJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// FindByID mencari job milik user. Job milik user lain dianggap tidak ada.
+func (r *importJobRepositoryImpl) FindByID(ctx context.Context, userID uint, id string) (*entity.ImportJob, error) {
+	var job entity.ImportJob
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("import job not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FailUnfinished menandai job yang belum selesai sebagai gagal. Dipanggil saat
+// aplikasi start, karena job yang sedang berjalan ikut berhenti ketika proses mati.
+func (r *importJobRepositoryImpl) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&entity.ImportJob{}).
+		Where("status IN ?", []string{entity.ImportJobPending, entity.ImportJobRunning}).
+		Updates(map[string]any{"status": entity.ImportJobFailed, "error": reason, "completed_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteExpired menghapus job yang sudah melewati masa simpan.
+func (r *importJobRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.ImportJob{})
+	return result.RowsAffected, result.Error
+}
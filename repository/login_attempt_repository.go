@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"api-user-crud-go/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository adalah interface untuk pencatatan login gagal dan lockout.
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, key string) (*entity.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (*entity.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// loginAttemptRepositoryImpl adalah implementasi dari LoginAttemptRepository.
+type loginAttemptRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository membuat instance baru LoginAttemptRepository.
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepositoryImpl{db: db}
+}
+
+// Get mengambil catatan login gagal untuk sebuah kunci.
+// Mengembalikan nil tanpa error jika belum ada catatan.
+func (r *loginAttemptRepositoryImpl) Get(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	var attempt entity.LoginAttempt
+	err := r.db.WithContext(ctx).Where("attempt_key = ?", key).First(&attempt).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordFailure menambah hitungan login gagal secara atomik dan mengembalikan
+// catatan terbaru. Catatan yang sudah melewati expires_at dimulai lagi dari 1.
+func (r *loginAttemptRepositoryImpl) RecordFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (*entity.LoginAttempt, error) {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN login_attempts.expires_at < ? THEN 1 ELSE login_attempts.failures + 1 END", now),
+			"locked_until":    gorm.Expr("CASE WHEN login_attempts.expires_at < ? THEN NULL ELSE login_attempts.locked_until END", now),
+			"last_failure_at": now,
+			"expires_at":      expiresAt,
+			"updated_at":      now,
+		}),
+	}).Create(&entity.LoginAttempt{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+		ExpiresAt:     expiresAt,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Get(ctx, key)
+}
+
+// Lock mengunci sebuah kunci sampai waktu tertentu.
+func (r *loginAttemptRepositoryImpl) Lock(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.LoginAttempt{}).
+		Where("attempt_key = ?", key).
+		Updates(map[string]interface{}{"locked_until": until, "expires_at": until}).Error
+}
+
+// Reset menghapus catatan login gagal (setelah login berhasil atau unlock oleh admin).
+func (r *loginAttemptRepositoryImpl) Reset(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("attempt_key = ?", key).Delete(&entity.LoginAttempt{}).Error
+}
+
+// DeleteExpired menghapus catatan yang sudah kedaluwarsa.
+func (r *loginAttemptRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"api-user-crud-go/repository"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLoginAttempt_RecordFailure(t *testing.T) {
+	repo := repository.NewLoginAttemptRepository(newTestDB(t))
+	now := time.Now()
+	window := 15 * time.Minute
+
+	for i := 1; i <= 3; i++ {
+		attempt, err := repo.RecordFailure(context.Background(), "account:alice@example.com", now, now.Add(window))
+		if err != nil {
+			t.Fatalf("RecordFailure returned unexpected error: %v", err)
+		}
+		if attempt.Failures != i {
+			t.Fatalf("expected %d failures, got %d", i, attempt.Failures)
+		}
+	}
+
+	if err := repo.Lock(context.Background(), "account:alice@example.com", now.Add(window)); err != nil {
+		t.Fatalf("Lock returned unexpected error: %v", err)
+	}
+	attempt, err := repo.Get(context.Background(), "account:alice@example.com")
+	if err != nil || attempt.LockedUntil == nil {
+		t.Fatalf("expected locked attempt, got %+v (err %v)", attempt, err)
+	}
+
+	// Setelah expires_at lewat, hitungan dan lock dimulai dari awal
+	later := now.Add(window + time.Minute)
+	attempt, err = repo.RecordFailure(context.Background(), "account:alice@example.com", later, later.Add(window))
+	if err != nil {
+		t.Fatalf("RecordFailure returned unexpected error: %v", err)
+	}
+	if attempt.Failures != 1 || attempt.LockedUntil != nil {
+		t.Errorf("expected counter to restart after expiry, got failures=%d locked=%v", attempt.Failures, attempt.LockedUntil)
+	}
+}
+
+func TestLoginAttempt_ResetAndCleanup(t *testing.T) {
+	repo := repository.NewLoginAttemptRepository(newTestDB(t))
+	now := time.Now()
+
+	repo.RecordFailure(context.Background(), "ip:10.0.0.1", now, now.Add(time.Minute))
+	repo.RecordFailure(context.Background(), "ip:10.0.0.2", now, now.Add(-time.Minute))
+
+	if err := repo.Reset(context.Background(), "ip:10.0.0.1"); err != nil {
+		t.Fatalf("Reset returned unexpected error: %v", err)
+	}
+	if attempt, _ := repo.Get(context.Background(), "ip:10.0.0.1"); attempt != nil {
+		t.Errorf("expected attempt to be removed after reset, got %+v", attempt)
+	}
+
+	deleted, err := repo.DeleteExpired(context.Background(), now)
+	if err != nil {
+		t.Fatalf("DeleteExpired returned unexpected error: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 expired attempt deleted, got %d", deleted)
+	}
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClientRepository adalah interface untuk registry client OAuth/OIDC.
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *entity.OAuthClient) error
+	FindByID(ctx context.Context, clientID string) (*entity.OAuthClient, error)
+	FindAll(ctx context.Context) ([]entity.OAuthClient, error)
+	Delete(ctx context.Context, clientID string) error
+}
+
+// oauthClientRepositoryImpl adalah implementasi dari OAuthClientRepository.
+type oauthClientRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewOAuthClientRepository membuat instance baru OAuthClientRepository.
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepositoryImpl{db: db}
+}
+
+// Create mendaftarkan client baru.
+func (r *oauthClientRepositoryImpl) Create(ctx context.Context, client *entity.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// FindByID mencari client berdasarkan client_id.
+func (r *oauthClientRepositoryImpl) FindByID(ctx context.Context, clientID string) (*entity.OAuthClient, error) {
+	var client entity.OAuthClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("client not found")
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// FindAll mengambil semua client, diurutkan berdasarkan waktu pendaftaran.
+func (r *oauthClientRepositoryImpl) FindAll(ctx context.Context) ([]entity.OAuthClient, error) {
+	var clients []entity.OAuthClient
+	err := r.db.WithContext(ctx).Order("created_at").Find(&clients).Error
+	return clients, err
+}
+
+// Delete menghapus client beserta authorization code miliknya.
+func (r *oauthClientRepositoryImpl) Delete(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("client_id = ?", clientID).Delete(&entity.OAuthClient{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.NotFound("client not found")
+		}
+		return tx.Where("client_id = ?", clientID).Delete(&entity.OAuthAuthorizationCode{}).Error
+	})
+}
+
+// OAuthCodeRepository adalah interface untuk penyimpanan authorization code.
+type OAuthCodeRepository interface {
+	Create(ctx context.Context, code *entity.OAuthAuthorizationCode) error
+	FindValid(ctx context.Context, codeHash string, now time.Time) (*entity.OAuthAuthorizationCode, error)
+	Consume(ctx context.Context, codeHash string, now time.Time) (*entity.OAuthAuthorizationCode, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// oauthCodeRepositoryImpl adalah implementasi dari OAuthCodeRepository.
+type oauthCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewOAuthCodeRepository membuat instance baru OAuthCodeRepository.
+func NewOAuthCodeRepository(db *gorm.DB) OAuthCodeRepository {
+	return &oauthCodeRepositoryImpl{db: db}
+}
+
+// Create menyimpan authorization code baru.
+func (r *oauthCodeRepositoryImpl) Create(ctx context.Context, code *entity.OAuthAuthorizationCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// FindValid mencari code yang belum terpakai dan belum expired tanpa menandainya
+// terpakai, agar pemanggil bisa memeriksa client dan PKCE sebelum Consume.
+func (r *oauthCodeRepositoryImpl) FindValid(ctx context.Context, codeHash string, now time.Time) (*entity.OAuthAuthorizationCode, error) {
+	var code entity.OAuthAuthorizationCode
+	err := r.db.WithContext(ctx).
+		Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", codeHash, now).
+		First(&code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("authorization code not found")
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// Consume menandai code sebagai terpakai secara atomik dan mengembalikannya.
+// Code yang tidak ada, sudah terpakai, atau expired menghasilkan error.
+func (r *oauthCodeRepositoryImpl) Consume(ctx context.Context, codeHash string, now time.Time) (*entity.OAuthAuthorizationCode, error) {
+	result := r.db.WithContext(ctx).Model(&entity.OAuthAuthorizationCode{}).
+		Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", codeHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, apperror.NotFound("authorization code not found")
+	}
+
+	var code entity.OAuthAuthorizationCode
+	if err := r.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// DeleteExpired menghapus authorization code yang sudah expired.
+func (r *oauthCodeRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.OAuthAuthorizationCode{})
+	return result.RowsAffected, result.Error
+}
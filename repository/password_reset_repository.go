@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository adalah interface untuk penyimpanan token reset password.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *entity.PasswordResetToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*entity.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateForUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// passwordResetRepositoryImpl adalah implementasi dari PasswordResetRepository.
+type passwordResetRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository membuat instance baru PasswordResetRepository.
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepositoryImpl{db: db}
+}
+
+// Create menyimpan token reset baru.
+func (r *passwordResetRepositoryImpl) Create(ctx context.Context, token *entity.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindByHash mencari token reset berdasarkan hash-nya.
+func (r *passwordResetRepositoryImpl) FindByHash(ctx context.Context, tokenHash string) (*entity.PasswordResetToken, error) {
+	var token entity.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("reset token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed menandai token sebagai sudah dipakai.
+// Mengembalikan false jika token sudah dipakai sebelumnya (update bersyarat).
+func (r *passwordResetRepositoryImpl) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateForUser menandai semua token reset user yang belum dipakai sebagai terpakai,
+// sehingga hanya token terbaru yang berlaku.
+func (r *passwordResetRepositoryImpl) InvalidateForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&entity.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// DeleteExpired menghapus token reset yang sudah expired.
+func (r *passwordResetRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.PasswordResetToken{})
+	return result.RowsAffected, result.Error
+}
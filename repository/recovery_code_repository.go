@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"api-user-crud-go/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository adalah interface untuk penyimpanan recovery code 2FA.
+type RecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID uint, codeHashes []string) error
+	Use(ctx context.Context, userID uint, codeHash string) (bool, error)
+	DeleteForUser(ctx context.Context, userID uint) error
+}
+
+// recoveryCodeRepositoryImpl adalah implementasi dari RecoveryCodeRepository.
+type recoveryCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository membuat instance baru RecoveryCodeRepository.
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepositoryImpl{db: db}
+}
+
+// ReplaceForUser mengganti semua recovery code user dengan set yang baru.
+func (r *recoveryCodeRepositoryImpl) ReplaceForUser(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]entity.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = entity.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use menandai recovery code sebagai terpakai.
+// Mengembalikan false jika kode tidak ada atau sudah dipakai (update bersyarat).
+func (r *recoveryCodeRepositoryImpl) Use(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteForUser menghapus semua recovery code user (saat 2FA dinonaktifkan).
+func (r *recoveryCodeRepositoryImpl) DeleteForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository adalah interface untuk penyimpanan refresh token.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// refreshTokenRepositoryImpl adalah implementasi dari RefreshTokenRepository.
+type refreshTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository membuat instance baru RefreshTokenRepository.
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{db: db}
+}
+
+// Create menyimpan refresh token baru.
+func (r *refreshTokenRepositoryImpl) Create(ctx context.Context, token *entity.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindByHash mencari refresh token berdasarkan hash-nya.
+func (r *refreshTokenRepositoryImpl) FindByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("refresh token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed menandai token sebagai sudah dipakai.
+// Update bersyarat (used_at IS NULL) membuat dua request paralel dengan token
+// yang sama tidak bisa sama-sama berhasil; yang kalah mendapat false.
+func (r *refreshTokenRepositoryImpl) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily mencabut semua token dalam satu family.
+func (r *refreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser mencabut semua refresh token user yang dibuat sebelum waktu tertentu.
+func (r *refreshTokenRepositoryImpl) RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND created_at < ? AND revoked_at IS NULL", userID, before).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteExpired menghapus refresh token yang sudah expired.
+func (r *refreshTokenRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository adalah interface untuk penyimpanan session login user.
+type SessionRepository interface {
+	Create(ctx context.Context, session *entity.Session) error
+	FindByID(ctx context.Context, id uint) (*entity.Session, error)
+	FindByFamily(ctx context.Context, familyID string) (*entity.Session, error)
+	FindActiveByUser(ctx context.Context, userID uint, now time.Time) ([]entity.Session, error)
+	Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error
+	Revoke(ctx context.Context, id uint) error
+	RevokeByFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uint, before time.Time, exceptID uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// sessionRepositoryImpl adalah implementasi dari SessionRepository.
+type sessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository membuat instance baru SessionRepository.
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepositoryImpl{db: db}
+}
+
+// Create menyimpan session baru.
+func (r *sessionRepositoryImpl) Create(ctx context.Context, session *entity.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// FindByID mencari session berdasarkan ID.
+func (r *sessionRepositoryImpl) FindByID(ctx context.Context, id uint) (*entity.Session, error) {
+	return r.findOne(ctx, "id = ?", id)
+}
+
+// FindByFamily mencari session pemilik family refresh token.
+func (r *sessionRepositoryImpl) FindByFamily(ctx context.Context, familyID string) (*entity.Session, error) {
+	return r.findOne(ctx, "family_id = ?", familyID)
+}
+
+func (r *sessionRepositoryImpl) findOne(ctx context.Context, query string, arg interface{}) (*entity.Session, error) {
+	var session entity.Session
+	if err := r.db.WithContext(ctx).Where(query, arg).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("session not found")
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindActiveByUser mengambil session user yang belum dicabut dan belum expired,
+// yang terakhir aktif lebih dulu.
+func (r *sessionRepositoryImpl) FindActiveByUser(ctx context.Context, userID uint, now time.Time) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch memperbarui waktu terakhir session aktif dan masa berlakunya.
+func (r *sessionRepositoryImpl) Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": lastSeenAt, "expires_at": expiresAt}).Error
+}
+
+// Revoke mencabut satu session.
+func (r *sessionRepositoryImpl) Revoke(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByFamily mencabut session pemilik family refresh token.
+func (r *sessionRepositoryImpl) RevokeByFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser mencabut session user yang terakhir aktif sebelum waktu
+// tertentu, kecuali session exceptID (0 = tanpa pengecualian). Ini sejalan
+// dengan pencabutan refresh token yang dibuat sebelum waktu yang sama.
+func (r *sessionRepositoryImpl) RevokeAllForUser(ctx context.Context, userID uint, before time.Time, exceptID uint) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("user_id = ? AND last_seen_at < ? AND id <> ? AND revoked_at IS NULL", userID, before, exceptID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteExpired menghapus session yang sudah expired. Access token milik
+// session tersebut pasti sudah expired lebih dulu.
+func (r *sessionRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.Session{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+package repository_test
+
+import (
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSession_RevokeAllForUserKeepsCurrent(t *testing.T) {
+	repo := repository.NewSessionRepository(newTestDB(t))
+	now := time.Now()
+
+	for _, family := range []string{"f1", "f2", "f3"} {
+		repo.Create(context.Background(), &entity.Session{UserID: 1, FamilyID: family, LastSeenAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)})
+	}
+	repo.Create(context.Background(), &entity.Session{UserID: 2, FamilyID: "f4", LastSeenAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)})
+
+	if err := repo.RevokeAllForUser(context.Background(), 1, now, 2); err != nil {
+		t.Fatalf("RevokeAllForUser returned unexpected error: %v", err)
+	}
+
+	active, err := repo.FindActiveByUser(context.Background(), 1, now)
+	if err != nil {
+		t.Fatalf("FindActiveByUser returned unexpected error: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != 2 {
+		t.Errorf("expected only session 2 to stay active, got %+v", active)
+	}
+	if other, _ := repo.FindActiveByUser(context.Background(), 2, now); len(other) != 1 {
+		t.Errorf("expected other user's session to be untouched, got %d", len(other))
+	}
+}
+
+func TestTokenRevocation_IsRevokedChecksSession(t *testing.T) {
+	db := newTestDB(t)
+	sessions := repository.NewSessionRepository(db)
+	revocations := repository.NewTokenRevocationRepository(db)
+	now := time.Now()
+
+	session := &entity.Session{UserID: 1, FamilyID: "f1", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	sessions.Create(context.Background(), session)
+
+	if revoked, err := revocations.IsRevoked(context.Background(), "jti-1", session.ID, 1, now); err != nil || revoked {
+		t.Fatalf("expected token of active session to be valid, got revoked=%v err=%v", revoked, err)
+	}
+
+	sessions.Revoke(context.Background(), session.ID)
+	if revoked, _ := revocations.IsRevoked(context.Background(), "jti-1", session.ID, 1, now); !revoked {
+		t.Error("expected token of revoked session to be rejected")
+	}
+	if revoked, _ := revocations.IsRevoked(context.Background(), "jti-2", 0, 1, now); revoked {
+		t.Error("expected token without session to be unaffected")
+	}
+}
+
+func TestTokenRevocation_RevokeAllForUserNeverMovesCutoffBack(t *testing.T) {
+	revocations := repository.NewTokenRevocationRepository(newTestDB(t))
+	now := time.Now().Truncate(time.Second)
+	stolenIssuedAt := now.Add(-10 * time.Minute)
+
+	// Reset password mencabut semua token sampai sekarang
+	if err := revocations.RevokeAllForUser(context.Background(), 1, now, now.Add(15*time.Minute)); err != nil {
+		t.Fatalf("RevokeAllForUser returned unexpected error: %v", err)
+	}
+	// Logout-all dengan before lama (zona waktu lain) tidak boleh memundurkan batas
+	earlier := now.Add(-time.Hour).In(time.FixedZone("WIB", 7*60*60))
+	if err := revocations.RevokeAllForUser(context.Background(), 1, earlier, earlier.Add(15*time.Minute)); err != nil {
+		t.Fatalf("RevokeAllForUser returned unexpected error: %v", err)
+	}
+
+	if revoked, err := revocations.IsRevoked(context.Background(), "jti-stolen", 0, 1, stolenIssuedAt); err != nil || !revoked {
+		t.Errorf("expected token issued before the reset to stay revoked, got revoked=%v err=%v", revoked, err)
+	}
+	if revoked, _ := revocations.IsRevoked(context.Background(), "jti-new", 0, 1, now); revoked {
+		t.Error("expected token issued at the cutoff second to stay valid")
+	}
+}
+
+func TestTokenRevocation_CanceledContext(t *testing.T) {
+	db := newTestDB(t)
+	revocations := repository.NewTokenRevocationRepository(db)
+	attempts := repository.NewLoginAttemptRepository(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := revocations.IsRevoked(ctx, "jti-1", 0, 1, time.Now()); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from IsRevoked, got %v", err)
+	}
+	if _, err := attempts.Get(ctx, "ip:10.0.0.1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from Get, got %v", err)
+	}
+}
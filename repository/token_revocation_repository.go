@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"api-user-crud-go/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenRevocationRepository adalah interface untuk daftar pencabutan access token.
+// Dicek oleh middleware REST dan interceptor gRPC pada setiap request.
+type TokenRevocationRepository interface {
+	Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uint, before time.Time, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string, sessionID uint, userID uint, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// tokenRevocationRepositoryImpl adalah implementasi dari TokenRevocationRepository.
+type tokenRevocationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTokenRevocationRepository membuat instance baru TokenRevocationRepository.
+func NewTokenRevocationRepository(db *gorm.DB) TokenRevocationRepository {
+	return &tokenRevocationRepositoryImpl{db: db}
+}
+
+// Revoke mencabut satu access token berdasarkan jti.
+func (r *tokenRevocationRepositoryImpl) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// RevokeAllForUser mencabut semua token user yang diterbitkan sebelum waktu tertentu.
+// expiresAt adalah kapan entri ini boleh dibersihkan (setelah token terakhir yang
+// terdampak pasti sudah expired). Batas yang sudah ada tidak pernah dimundurkan,
+// sehingga logout-all dengan before lama tidak menghidupkan lagi token yang
+// dicabut oleh reset atau ganti password.
+func (r *tokenRevocationRepositoryImpl) RevokeAllForUser(ctx context.Context, userID uint, before time.Time, expiresAt time.Time) error {
+	// Waktu disimpan dalam UTC agar perbandingan teks di SQLite (MAX, >) konsisten
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "revoked_before"}, Value: gorm.Expr("MAX(revoked_before, excluded.revoked_before)")},
+			{Column: clause.Column{Name: "expires_at"}, Value: gorm.Expr("MAX(expires_at, excluded.expires_at)")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(&entity.UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: before.UTC(),
+		ExpiresAt:     expiresAt.UTC(),
+	}).Error
+}
+
+// IsRevoked mengecek apakah token sudah dicabut, baik satu per satu (jti),
+// melalui session-nya (sessionID 0 = token tanpa session), maupun melalui
+// pencabutan semua token milik user.
+func (r *tokenRevocationRepositoryImpl) IsRevoked(ctx context.Context, jti string, sessionID uint, userID uint, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if sessionID != 0 {
+		err = r.db.WithContext(ctx).Model(&entity.Session{}).
+			Where("id = ? AND revoked_at IS NOT NULL", sessionID).
+			Count(&count).Error
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	err = r.db.WithContext(ctx).Model(&entity.UserTokenRevocation{}).
+		Where("user_id = ? AND revoked_before > ?", userID, issuedAt.UTC()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired menghapus entri yang sudah tidak diperlukan lagi.
+func (r *tokenRevocationRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.RevokedToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	deleted := result.RowsAffected
+
+	result = r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entity.UserTokenRevocation{})
+	if result.Error != nil {
+		return deleted, result.Error
+	}
+	return deleted + result.RowsAffected, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories adalah kumpulan repository yang berbagi satu transaksi database.
+// Login attempt sengaja tidak termasuk: percobaan login gagal harus tetap
+// tercatat walaupun operasi yang sedang berjalan di-rollback.
+type Repositories struct {
+	Users          UserRepository
+	RefreshTokens  RefreshTokenRepository
+	Sessions       SessionRepository
+	Revocations    TokenRevocationRepository
+	PasswordResets PasswordResetRepository
+	RecoveryCodes  RecoveryCodeRepository
+}
+
+// UnitOfWork menjalankan beberapa operasi repository sebagai satu transaksi.
+type UnitOfWork interface {
+	// WithTx menjalankan fn di dalam transaksi. Semua perubahan lewat repos
+	// di-commit jika fn mengembalikan nil dan di-rollback jika fn mengembalikan
+	// error atau panic. Pemanggilan bersarang memakai savepoint.
+	WithTx(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// unitOfWorkImpl adalah implementasi dari UnitOfWork.
+type unitOfWorkImpl struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork membuat instance baru UnitOfWork.
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWorkImpl{db: db}
+}
+
+// WithTx menjalankan fn dengan repository yang terikat ke transaksi yang sama.
+func (u *unitOfWorkImpl) WithTx(ctx context.Context, fn func(repos Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Users:          NewUserRepository(tx),
+			RefreshTokens:  NewRefreshTokenRepository(tx),
+			Sessions:       NewSessionRepository(tx),
+			Revocations:    NewTokenRevocationRepository(tx),
+			PasswordResets: NewPasswordResetRepository(tx),
+			RecoveryCodes:  NewRecoveryCodeRepository(tx),
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUnitOfWork_CommitsAndRollsBackAcrossRepositories(t *testing.T) {
+	db := newTestDB(t)
+	uow := repository.NewUnitOfWork(db)
+	users := repository.NewUserRepository(db)
+	sessions := repository.NewSessionRepository(db)
+	now := time.Now()
+
+	createUserWithSession := func(repos repository.Repositories, email, family string) error {
+		user := &entity.User{Name: "Alice", Email: email, Password: "x"}
+		if err := repos.Users.Create(context.Background(), user); err != nil {
+			return err
+		}
+		return repos.Sessions.Create(context.Background(), &entity.Session{UserID: user.ID, FamilyID: family, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)})
+	}
+
+	err := uow.WithTx(context.Background(), func(repos repository.Repositories) error {
+		return createUserWithSession(repos, "alice@example.com", "f1")
+	})
+	if err != nil {
+		t.Fatalf("WithTx returned unexpected error: %v", err)
+	}
+	alice, err := users.FindByEmail(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatalf("expected Alice to be committed, got %v", err)
+	}
+	if _, err := sessions.FindByFamily(context.Background(), "f1"); err != nil {
+		t.Errorf("expected session to be committed, got %v", err)
+	}
+
+	// Session kedua gagal (family duplikat), user yang dibuat sebelumnya ikut di-rollback
+	err = uow.WithTx(context.Background(), func(repos repository.Repositories) error {
+		return createUserWithSession(repos, "bob@example.com", "f1")
+	})
+	if err == nil {
+		t.Fatal("expected duplicate session family to fail")
+	}
+	if _, err := users.FindByEmail(context.Background(), "bob@example.com"); err == nil {
+		t.Error("expected Bob to be rolled back with the failed session")
+	}
+
+	// Email yang sudah dipakai user aktif dilaporkan sebagai ErrDuplicateEmail
+	err = uow.WithTx(context.Background(), func(repos repository.Repositories) error {
+		return createUserWithSession(repos, alice.Email, "f2")
+	})
+	if !errors.Is(err, repository.ErrDuplicateEmail) {
+		t.Errorf("expected ErrDuplicateEmail, got %v", err)
+	}
+}
+
+func TestUserRepository_DuplicateEmail(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+
+	alice := &entity.User{Name: "Alice", Email: "alice@example.com", Password: "x"}
+	bob := &entity.User{Name: "Bob", Email: "bob@example.com", Password: "x"}
+	for _, u := range []*entity.User{alice, bob} {
+		if err := repo.Create(context.Background(), u); err != nil {
+			t.Fatalf("Create returned unexpected error: %v", err)
+		}
+	}
+
+	if err := repo.Create(context.Background(), &entity.User{Name: "Alice 2", Email: alice.Email, Password: "x"}); !errors.Is(err, repository.ErrDuplicateEmail) {
+		t.Errorf("expected ErrDuplicateEmail from Create, got %v", err)
+	}
+
+	bob.Email = alice.Email
+	if err := repo.Update(context.Background(), bob); !errors.Is(err, repository.ErrDuplicateEmail) {
+		t.Errorf("expected ErrDuplicateEmail from Update, got %v", err)
+	}
+	if bob.Version != 1 {
+		t.Errorf("expected version to be restored after failed update, got %d", bob.Version)
+	}
+}
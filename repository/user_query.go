@@ -0,0 +1,73 @@
+package repository
+
+import "time"
+
+// UserQuery adalah spesifikasi query untuk listing user:
+// filter, urutan, dan batas halaman.
+type UserQuery struct {
+	Name   string // filter nama (mengandung, case-insensitive)
+	Email  string // filter email (mengandung, case-insensitive)
+	MinAge *int   // batas bawah umur (inklusif)
+	MaxAge *int   // batas atas umur (inklusif)
+
+	Deleted DeletedFilter // ikut sertakan user yang sudah di-soft-delete
+
+	Sort []SortField // urutan; kosong berarti (created_at, id) ascending
+
+	// Keyset pagination: hanya berlaku dengan urutan default (Sort kosong).
+	// After mengambil baris setelah posisi, Before mengambil baris sebelum posisi.
+	After  *UserKeyset
+	Before *UserKeyset
+
+	Limit     int // 0 berarti tanpa batas
+	Offset    int
+	SkipCount bool // lewati COUNT(*) yang mahal untuk tabel besar
+}
+
+// DeletedFilter menentukan apakah user yang sudah di-soft-delete ikut di-listing.
+type DeletedFilter int
+
+const (
+	// ExcludeDeleted hanya mengambil user aktif (default).
+	ExcludeDeleted DeletedFilter = iota
+	// IncludeDeleted mengambil user aktif dan yang sudah dihapus.
+	IncludeDeleted
+	// OnlyDeleted hanya mengambil user yang sudah dihapus.
+	OnlyDeleted
+)
+
+// UserStamp adalah ringkasan murah dari tabel users (termasuk yang di-soft-delete)
+// yang berubah setiap kali ada user dibuat, diubah, dihapus, atau di-restore.
+// Dipakai untuk ETag/Last-Modified listing tanpa mengambil baris user.
+type UserStamp struct {
+	Count        int64
+	LastModified time.Time // updated_at atau deleted_at terbaru
+}
+
+// UserKeyset adalah posisi sebuah baris dalam urutan default (created_at, id).
+type UserKeyset struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// SortField adalah satu kolom pengurutan.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// userSortColumns memetakan nama field yang boleh dipakai untuk sort ke kolom database.
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"age":        "age",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// IsSortableUserField mengecek apakah field boleh dipakai untuk sort.
+func IsSortableUserField(field string) bool {
+	_, ok := userSortColumns[field]
+	return ok
+}
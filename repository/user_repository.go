@@ -1,21 +1,48 @@
 package repository
 
 import (
+	"api-user-crud-go/apperror"
 	"api-user-crud-go/entity"
+	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// streamBatchSize adalah jumlah user yang dibaca per query oleh Stream.
+const streamBatchSize = 500
+
+// ErrVersionConflict dikembalikan ketika versi user di database sudah berbeda
+// dengan versi yang diharapkan (diubah request lain).
+var ErrVersionConflict = apperror.Conflict("user was modified by another request")
+
+// ErrDuplicateEmail dikembalikan ketika email sudah dipakai user aktif lain
+// (melanggar unique index idx_users_email_active). Menangkap race antara
+// pengecekan FindByEmail dan Create/Update/Restore pada request paralel.
+var ErrDuplicateEmail = apperror.AlreadyExists("email is already used by another user")
+
 // UserRepository adalah interface untuk operasi database User.
 // Menggunakan pattern repository untuk memisahkan logika data access.
+// Semua query memakai ctx, sehingga query berhenti saat request dibatalkan
+// atau melewati deadline.
 type UserRepository interface {
-	Create(user *entity.User) error
-	FindAll() ([]entity.User, error)
-	FindByID(id uint) (*entity.User, error)
-	FindByEmail(email string) (*entity.User, error)
-	Update(user *entity.User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *entity.User) error
+	List(ctx context.Context, query UserQuery) ([]entity.User, int64, error)
+	Stream(ctx context.Context, query UserQuery, fn func(user *entity.User) error) error
+	FindByID(ctx context.Context, id uint) (*entity.User, error)
+	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	Stamp(ctx context.Context) (UserStamp, error)
+	Update(ctx context.Context, user *entity.User) error
+	AdvanceMFAStep(ctx context.Context, id uint, step int64) (bool, error)
+	Delete(ctx context.Context, id uint, version uint) error
+	FindDeletedByID(ctx context.Context, id uint) (*entity.User, error)
+	Restore(ctx context.Context, id uint) error
+	Purge(ctx context.Context, id uint, version uint) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	Transaction(ctx context.Context, fn func(repo UserRepository) error) error
 }
 
 // userRepositoryImpl adalah implementasi dari UserRepository.
@@ -29,24 +56,131 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 // Create menambahkan user baru ke database.
-func (r *userRepositoryImpl) Create(user *entity.User) error {
-	return r.db.Create(user).Error
+func (r *userRepositoryImpl) Create(ctx context.Context, user *entity.User) error {
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	return r.translateError(r.db.WithContext(ctx).Create(user).Error)
 }
 
-// FindAll mengambil semua user dari database.
-func (r *userRepositoryImpl) FindAll() ([]entity.User, error) {
+// List mengambil satu halaman user sesuai filter dan urutan,
+// beserta total user yang cocok dengan filter (tanpa limit/offset).
+// Total bernilai 0 jika query.SkipCount diset.
+func (r *userRepositoryImpl) List(ctx context.Context, query UserQuery) ([]entity.User, int64, error) {
+	db := r.filter(ctx, query)
+
+	var total int64
+	if !query.SkipCount {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if (query.After != nil || query.Before != nil) && len(query.Sort) > 0 {
+		return nil, 0, apperror.Validation("keyset pagination requires the default sort order")
+	}
+
+	switch {
+	case len(query.Sort) > 0:
+		for _, s := range query.Sort {
+			column, ok := userSortColumns[s.Field]
+			if !ok {
+				return nil, 0, apperror.Validation("invalid sort field: " + s.Field)
+			}
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: s.Desc})
+		}
+		// Tie-breaker agar urutan stabil antar halaman
+		db = db.Order("id")
+	case query.Before != nil:
+		// Ambil baris terdekat sebelum posisi (urutan terbalik), lalu dibalik lagi di bawah
+		db = db.Where("created_at < ? OR (created_at = ? AND id < ?)",
+			query.Before.CreatedAt, query.Before.CreatedAt, query.Before.ID).
+			Order("created_at DESC").Order("id DESC")
+	default:
+		if query.After != nil {
+			db = db.Where("created_at > ? OR (created_at = ? AND id > ?)",
+				query.After.CreatedAt, query.After.CreatedAt, query.After.ID)
+		}
+		db = db.Order("created_at").Order("id")
+	}
+
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+
 	var users []entity.User
-	err := r.db.Find(&users).Error
-	return users, err
+	if err := db.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if query.Before != nil {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+	return users, total, nil
+}
+
+// Stream memanggil fn untuk setiap user yang cocok dengan filter, berurutan
+// berdasarkan ID. User dibaca per streamBatchSize baris dengan keyset (id > terakhir),
+// sehingga tabel besar tidak dimuat sekaligus ke memori dan tidak ada cursor
+// database yang menahan lock selama client lambat membaca. Paginasi dan sort diabaikan.
+// Iterasi berhenti jika fn mengembalikan error.
+func (r *userRepositoryImpl) Stream(ctx context.Context, query UserQuery, fn func(user *entity.User) error) error {
+	var lastID uint
+	for {
+		var users []entity.User
+		err := r.filter(ctx, query).Where("id > ?", lastID).Order("id").Limit(streamBatchSize).Find(&users).Error
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			if err := fn(&users[i]); err != nil {
+				return err
+			}
+		}
+		if len(users) < streamBatchSize {
+			return nil
+		}
+		lastID = users[len(users)-1].ID
+	}
+}
+
+// filter membangun query users sesuai filter (tanpa urutan dan paginasi).
+func (r *userRepositoryImpl) filter(ctx context.Context, query UserQuery) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&entity.User{})
+
+	switch query.Deleted {
+	case IncludeDeleted:
+		db = db.Unscoped()
+	case OnlyDeleted:
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if query.Name != "" {
+		db = db.Where("name LIKE ? ESCAPE '\\'", "%"+escapeLike(query.Name)+"%")
+	}
+	if query.Email != "" {
+		db = db.Where("email LIKE ? ESCAPE '\\'", "%"+escapeLike(query.Email)+"%")
+	}
+	if query.MinAge != nil {
+		db = db.Where("age >= ?", *query.MinAge)
+	}
+	if query.MaxAge != nil {
+		db = db.Where("age <= ?", *query.MaxAge)
+	}
+	return db
 }
 
 // FindByID mencari user berdasarkan ID.
-func (r *userRepositoryImpl) FindByID(id uint) (*entity.User, error) {
+func (r *userRepositoryImpl) FindByID(ctx context.Context, id uint) (*entity.User, error) {
 	var user entity.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperror.NotFound("user not found")
 		}
 		return nil, err
 	}
@@ -54,28 +188,249 @@ func (r *userRepositoryImpl) FindByID(id uint) (*entity.User, error) {
 }
 
 // FindByEmail mencari user berdasarkan email.
-func (r *userRepositoryImpl) FindByEmail(email string) (*entity.User, error) {
+func (r *userRepositoryImpl) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperror.NotFound("user not found")
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-// Update mengupdate data user yang sudah ada.
-func (r *userRepositoryImpl) Update(user *entity.User) error {
-	return r.db.Save(user).Error
+// Stamp menghitung ringkasan perubahan tabel users. Query memakai index
+// updated_at dan deleted_at sehingga jauh lebih murah daripada listing.
+func (r *userRepositoryImpl) Stamp(ctx context.Context) (UserStamp, error) {
+	var stamp UserStamp
+	if err := r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).Count(&stamp.Count).Error; err != nil {
+		return stamp, err
+	}
+
+	var updated, deleted []time.Time
+	if err := r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).Order("updated_at DESC").Limit(1).Pluck("updated_at", &updated).Error; err != nil {
+		return stamp, err
+	}
+	if err := r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Limit(1).Pluck("deleted_at", &deleted).Error; err != nil {
+		return stamp, err
+	}
+	for _, t := range append(updated, deleted...) {
+		if t.After(stamp.LastModified) {
+			stamp.LastModified = t
+		}
+	}
+	return stamp, nil
+}
+
+// Update mengupdate data user yang sudah ada dengan optimistic locking:
+// baris hanya ditulis jika versinya masih sama dengan saat dibaca, lalu versi dinaikkan.
+// Mengembalikan ErrVersionConflict jika user sudah diubah request lain.
+func (r *userRepositoryImpl) Update(ctx context.Context, user *entity.User) error {
+	current := user.Version
+	user.Version = current + 1
+
+	result := r.db.WithContext(ctx).Model(user).Where("version = ?", current).Select("*").Updates(user)
+	if result.Error != nil {
+		user.Version = current
+		return r.translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		user.Version = current
+		return ErrVersionConflict
+	}
+	return nil
 }
 
-// Delete menghapus user berdasarkan ID.
-func (r *userRepositoryImpl) Delete(id uint) error {
-	result := r.db.Delete(&entity.User{}, id)
+// AdvanceMFAStep menyimpan time step TOTP yang baru dipakai, hanya jika lebih
+// baru dari step terakhir. Update bersyarat ini membuat dua verifikasi paralel
+// dengan kode yang sama tidak bisa sama-sama berhasil; yang kalah mendapat false.
+// Version dan updated_at tidak diubah karena step bukan bagian dari data user.
+func (r *userRepositoryImpl) AdvanceMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ? AND mfa_last_step < ?", id, step).
+		UpdateColumn("mfa_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Delete menghapus user berdasarkan ID (soft delete).
+// Jika version bukan 0, user hanya dihapus bila versinya masih sama.
+func (r *userRepositoryImpl) Delete(ctx context.Context, id uint, version uint) error {
+	db := r.db.WithContext(ctx)
+	if version > 0 {
+		db = db.Where("version = ?", version)
+	}
+	result := db.Delete(&entity.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
 	if result.RowsAffected == 0 {
-		return errors.New("user not found")
+		return missingOrConflict(r.db.WithContext(ctx), id, version)
 	}
-	return result.Error
+	return nil
+}
+
+// FindDeletedByID mencari user yang sudah di-soft-delete berdasarkan ID.
+func (r *userRepositoryImpl) FindDeletedByID(ctx context.Context, id uint) (*entity.User, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("deleted user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore mengembalikan user yang sudah di-soft-delete.
+func (r *userRepositoryImpl) Restore(ctx context.Context, id uint) error {
+	// Versi ikut naik agar ETag dari sebelum user dihapus tidak berlaku lagi
+	result := r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1"), "updated_at": time.Now()})
+	if result.Error != nil {
+		return r.translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("deleted user not found")
+	}
+	return nil
+}
+
+// Purge menghapus user secara permanen (aktif maupun yang sudah di-soft-delete),
+// beserta credential miliknya, dalam satu transaksi.
+// Jika version bukan 0, user hanya dihapus bila versinya masih sama.
+func (r *userRepositoryImpl) Purge(ctx context.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return purgeUser(tx, id, version)
+	})
+}
+
+// PurgeDeletedBefore menghapus permanen user yang di-soft-delete sebelum cutoff.
+// Mengembalikan jumlah user yang dihapus. Syarat deleted_at dicek ulang di
+// DELETE-nya sendiri, sehingga user yang di-restore setelah daftar ID diambil
+// tidak ikut terhapus.
+func (r *userRepositoryImpl) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, id := range ids {
+		var deleted bool
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			deleted, err = purgeDeletedUser(tx, id, cutoff)
+			return err
+		})
+		if err != nil {
+			return purged, err
+		}
+		if deleted {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Transaction menjalankan fn dengan repository yang terikat ke satu transaksi.
+// Transaksi di-commit jika fn mengembalikan nil dan di-rollback jika error.
+// Transaction yang dipanggil lagi dari repository di dalam fn memakai savepoint,
+// sehingga kegagalannya hanya membatalkan perubahan di dalam savepoint tersebut.
+func (r *userRepositoryImpl) Transaction(ctx context.Context, fn func(repo UserRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&userRepositoryImpl{db: tx})
+	})
+}
+
+// translateError mengubah pelanggaran unique index menjadi ErrDuplicateEmail.
+// Satu-satunya unique index selain primary key di tabel users adalah email.
+func (r *userRepositoryImpl) translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+// purgeUser menghapus baris user dan semua data yang terikat ke user_id-nya.
+// Daftar revocation tidak ikut dihapus; baris tersebut dibersihkan job cleanup saat expired.
+func purgeUser(tx *gorm.DB, id uint, version uint) error {
+	db := tx.Unscoped()
+	if version > 0 {
+		db = db.Where("version = ?", version)
+	}
+	result := db.Delete(&entity.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return missingOrConflict(tx.Unscoped(), id, version)
+	}
+	return deleteOwnedCredentials(tx, id)
+}
+
+// purgeDeletedUser menghapus permanen user id hanya jika masih di-soft-delete
+// sebelum cutoff. Mengembalikan false tanpa menyentuh credential jika user
+// sudah di-restore (atau dihapus ulang setelah cutoff).
+func purgeDeletedUser(tx *gorm.DB, id uint, cutoff time.Time) (bool, error) {
+	result := tx.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&entity.User{}, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	return true, deleteOwnedCredentials(tx, id)
+}
+
+// deleteOwnedCredentials menghapus credential dan data milik user yang dihapus permanen.
+func deleteOwnedCredentials(tx *gorm.DB, id uint) error {
+	owned := []any{
+		&entity.RefreshToken{},
+		&entity.Session{},
+		&entity.PasswordResetToken{},
+		&entity.RecoveryCode{},
+		&entity.APIKey{},
+		&entity.OAuthAuthorizationCode{},
+		&entity.ImportJob{},
+	}
+	for _, model := range owned {
+		if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingOrConflict menjelaskan kenapa write bersyarat versi tidak mengenai baris:
+// user tidak ada, atau ada tetapi versinya sudah berubah.
+func missingOrConflict(db *gorm.DB, id uint, version uint) error {
+	if version > 0 {
+		var count int64
+		if err := db.Model(&entity.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVersionConflict
+		}
+	}
+	return apperror.NotFound("user not found")
+}
+
+// escapeLike meng-escape karakter wildcard LIKE pada input user.
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
 }
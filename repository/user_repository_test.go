@@ -0,0 +1,528 @@
+package repository_test
+
+import (
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB membuat database SQLite in-memory yang terisolasi untuk setiap test.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&entity.User{}, &entity.LoginAttempt{}, &entity.IdempotencyKey{}, &entity.Session{}, &entity.RevokedToken{}, &entity.UserTokenRevocation{},
+		&entity.RefreshToken{}, &entity.PasswordResetToken{}, &entity.RecoveryCode{}, &entity.APIKey{}, &entity.OAuthAuthorizationCode{}, &entity.ImportJob{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func seedUsers(t *testing.T, repo repository.UserRepository) {
+	t.Helper()
+	users := []entity.User{
+		{Name: "Alice", Email: "alice@example.com", Age: 25},
+		{Name: "Bob", Email: "bob@corp.com", Age: 35},
+		{Name: "Carol", Email: "carol@example.com", Age: 45},
+		{Name: "100%_real", Email: "real@example.com", Age: 30},
+	}
+	for i := range users {
+		users[i].Password = "x"
+		if err := repo.Create(context.Background(), &users[i]); err != nil {
+			t.Fatalf("failed to seed user: %v", err)
+		}
+	}
+}
+
+func names(users []entity.User) []string {
+	var result []string
+	for _, u := range users {
+		result = append(result, u.Name)
+	}
+	return result
+}
+
+func TestList_FilterSortPaginate(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	seedUsers(t, repo)
+
+	minAge, maxAge := 26, 45
+
+	tests := []struct {
+		name      string
+		query     repository.UserQuery
+		wantNames []string
+		wantTotal int64
+	}{
+		{"no filter", repository.UserQuery{}, []string{"Alice", "Bob", "Carol", "100%_real"}, 4},
+		{"email contains", repository.UserQuery{Email: "example"}, []string{"Alice", "Carol", "100%_real"}, 3},
+		{"name case-insensitive", repository.UserQuery{Name: "ALI"}, []string{"Alice"}, 1},
+		{"wildcards are literal", repository.UserQuery{Name: "%_"}, []string{"100%_real"}, 1},
+		{"age range", repository.UserQuery{MinAge: &minAge, MaxAge: &maxAge}, []string{"Bob", "Carol", "100%_real"}, 3},
+		{"sort by age desc", repository.UserQuery{Sort: []repository.SortField{{Field: "age", Desc: true}}}, []string{"Carol", "Bob", "100%_real", "Alice"}, 4},
+		{"limit and offset", repository.UserQuery{Limit: 2, Offset: 1}, []string{"Bob", "Carol"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.List(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("List returned unexpected error: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("expected total %d, got %d", tt.wantTotal, total)
+			}
+			got := names(users)
+			if len(got) != len(tt.wantNames) {
+				t.Fatalf("expected %v, got %v", tt.wantNames, got)
+			}
+			for i := range got {
+				if got[i] != tt.wantNames[i] {
+					t.Fatalf("expected %v, got %v", tt.wantNames, got)
+				}
+			}
+		})
+	}
+}
+
+func TestList_InvalidSortField(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+
+	_, _, err := repo.List(context.Background(), repository.UserQuery{Sort: []repository.SortField{{Field: "password"}}})
+	if err == nil {
+		t.Error("expected error for unsortable field, got nil")
+	}
+}
+
+func TestList_Keyset(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	seedUsers(t, repo)
+
+	first, _, err := repo.List(context.Background(), repository.UserQuery{Limit: 2, SkipCount: true})
+	if err != nil {
+		t.Fatalf("List returned unexpected error: %v", err)
+	}
+	last := first[len(first)-1]
+
+	next, total, err := repo.List(context.Background(), repository.UserQuery{
+		After:     &repository.UserKeyset{CreatedAt: last.CreatedAt, ID: last.ID},
+		Limit:     2,
+		SkipCount: true,
+	})
+	if err != nil {
+		t.Fatalf("List returned unexpected error: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("expected total to be skipped, got %d", total)
+	}
+	if got := names(next); len(got) != 2 || got[0] != "Carol" || got[1] != "100%_real" {
+		t.Fatalf("expected [Carol 100%%_real] after keyset, got %v", got)
+	}
+
+	prev, _, err := repo.List(context.Background(), repository.UserQuery{
+		Before: &repository.UserKeyset{CreatedAt: next[0].CreatedAt, ID: next[0].ID},
+		Limit:  2,
+	})
+	if err != nil {
+		t.Fatalf("List returned unexpected error: %v", err)
+	}
+	if got := names(prev); len(got) != 2 || got[0] != "Alice" || got[1] != "Bob" {
+		t.Fatalf("expected [Alice Bob] before keyset, got %v", got)
+	}
+}
+
+func TestSoftDelete_EmailReuseRestoreAndPurge(t *testing.T) {
+	db := newTestDB(t)
+	repo := repository.NewUserRepository(db)
+	seedUsers(t, repo)
+
+	alice, _ := repo.FindByEmail(context.Background(), "alice@example.com")
+	if err := repo.Delete(context.Background(), alice.ID, 0); err != nil {
+		t.Fatalf("Delete returned unexpected error: %v", err)
+	}
+
+	// Email user yang sudah dihapus boleh didaftarkan ulang
+	again := entity.User{Name: "Alice 2", Email: "alice@example.com", Age: 30, Password: "x"}
+	if err := repo.Create(context.Background(), &again); err != nil {
+		t.Fatalf("expected email of deleted user to be reusable, got %v", err)
+	}
+
+	only, total, err := repo.List(context.Background(), repository.UserQuery{Deleted: repository.OnlyDeleted})
+	if err != nil {
+		t.Fatalf("List returned unexpected error: %v", err)
+	}
+	if got := names(only); total != 1 || len(got) != 1 || got[0] != "Alice" {
+		t.Fatalf("expected only [Alice], got %v (total %d)", got, total)
+	}
+	_, total, _ = repo.List(context.Background(), repository.UserQuery{Deleted: repository.IncludeDeleted})
+	if total != 5 {
+		t.Errorf("expected 5 users including deleted, got %d", total)
+	}
+
+	// Restore bentrok dengan unique index selama email masih dipakai
+	if err := repo.Restore(context.Background(), alice.ID); err == nil {
+		t.Fatal("expected restore to fail while email is taken")
+	}
+	if err := repo.Purge(context.Background(), again.ID, 0); err != nil {
+		t.Fatalf("Purge returned unexpected error: %v", err)
+	}
+	if err := repo.Restore(context.Background(), alice.ID); err != nil {
+		t.Fatalf("Restore returned unexpected error: %v", err)
+	}
+	if _, err := repo.FindByID(context.Background(), alice.ID); err != nil {
+		t.Errorf("expected restored user to be found, got %v", err)
+	}
+	if err := repo.Restore(context.Background(), alice.ID); err == nil {
+		t.Error("expected restoring an active user to fail")
+	}
+}
+
+func TestPurge_RemovesOwnedCredentials(t *testing.T) {
+	db := newTestDB(t)
+	repo := repository.NewUserRepository(db)
+	seedUsers(t, repo)
+
+	bob, _ := repo.FindByEmail(context.Background(), "bob@corp.com")
+	db.Create(&entity.APIKey{UserID: bob.ID, Name: "ci", Prefix: "abc", KeyHash: "h"})
+	db.Create(&entity.RecoveryCode{UserID: bob.ID, CodeHash: "h"})
+
+	if err := repo.Purge(context.Background(), bob.ID, 0); err != nil {
+		t.Fatalf("Purge returned unexpected error: %v", err)
+	}
+	if err := repo.Purge(context.Background(), bob.ID, 0); err == nil {
+		t.Error("expected error when purging a missing user")
+	}
+
+	var keys, codes, rows int64
+	db.Model(&entity.APIKey{}).Where("user_id = ?", bob.ID).Count(&keys)
+	db.Model(&entity.RecoveryCode{}).Where("user_id = ?", bob.ID).Count(&codes)
+	db.Unscoped().Model(&entity.User{}).Where("id = ?", bob.ID).Count(&rows)
+	if keys != 0 || codes != 0 || rows != 0 {
+		t.Errorf("expected user and credentials to be gone, got user=%d keys=%d codes=%d", rows, keys, codes)
+	}
+}
+
+func TestPurgeDeletedBefore(t *testing.T) {
+	db := newTestDB(t)
+	repo := repository.NewUserRepository(db)
+	seedUsers(t, repo)
+
+	alice, _ := repo.FindByEmail(context.Background(), "alice@example.com")
+	bob, _ := repo.FindByEmail(context.Background(), "bob@corp.com")
+	repo.Delete(context.Background(), alice.ID, 0)
+	repo.Delete(context.Background(), bob.ID, 0)
+	db.Unscoped().Model(&entity.User{}).Where("id = ?", alice.ID).Update("deleted_at", time.Now().Add(-48*time.Hour))
+
+	purged, err := repo.PurgeDeletedBefore(context.Background(), time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDeletedBefore returned unexpected error: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged user, got %d", purged)
+	}
+	if _, err := repo.FindDeletedByID(context.Background(), alice.ID); err == nil {
+		t.Error("expected old deleted user to be purged")
+	}
+	if _, err := repo.FindDeletedByID(context.Background(), bob.ID); err != nil {
+		t.Errorf("expected recently deleted user to be kept, got %v", err)
+	}
+}
+
+func TestPurgeDeletedBefore_SkipsUserRestoredMidway(t *testing.T) {
+	db := newTestDB(t)
+	repo := repository.NewUserRepository(db)
+	seedUsers(t, repo)
+
+	alice, _ := repo.FindByEmail(context.Background(), "alice@example.com")
+	repo.Delete(context.Background(), alice.ID, 0)
+	db.Unscoped().Model(&entity.User{}).Where("id = ?", alice.ID).Update("deleted_at", time.Now().Add(-48*time.Hour))
+	db.Create(&entity.APIKey{UserID: alice.ID, Name: "ci", Prefix: "restored", KeyHash: "h-restored"})
+
+	// Admin me-restore user tepat setelah PurgeDeletedBefore mengambil daftar ID
+	restored := false
+	db.Callback().Query().After("gorm:query").Register("test:restore_midway", func(tx *gorm.DB) {
+		if restored || tx.Statement.Table != "users" {
+			return
+		}
+		restored = true
+		if err := repo.Restore(context.Background(), alice.ID); err != nil {
+			t.Errorf("Restore returned unexpected error: %v", err)
+		}
+	})
+
+	purged, err := repo.PurgeDeletedBefore(context.Background(), time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDeletedBefore returned unexpected error: %v", err)
+	}
+	if !restored {
+		t.Fatal("expected restore hook to run")
+	}
+	if purged != 0 {
+		t.Errorf("expected restored user not to be counted as purged, got %d", purged)
+	}
+	if _, err := repo.FindByID(context.Background(), alice.ID); err != nil {
+		t.Errorf("expected restored user to survive, got %v", err)
+	}
+	var keys int64
+	db.Model(&entity.APIKey{}).Where("user_id = ?", alice.ID).Count(&keys)
+	if keys != 1 {
+		t.Errorf("expected restored user's API key to be kept, got %d", keys)
+	}
+}
+
+func TestUpdate_OptimisticLocking(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	seedUsers(t, repo)
+
+	first, _ := repo.FindByEmail(context.Background(), "alice@example.com")
+	second, _ := repo.FindByEmail(context.Background(), "alice@example.com")
+	if first.Version != 1 {
+		t.Fatalf("expected new user to start at version 1, got %d", first.Version)
+	}
+
+	first.Name = "Alice A"
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatalf("Update returned unexpected error: %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", first.Version)
+	}
+
+	// Salinan kedua masih membawa versi lama, jadi tidak boleh menimpa perubahan pertama
+	second.Name = "Alice B"
+	if err := repo.Update(context.Background(), second); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict for stale update, got %v", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("expected stale copy to keep version 1, got %d", second.Version)
+	}
+	stored, _ := repo.FindByID(context.Background(), first.ID)
+	if stored.Name != "Alice A" {
+		t.Errorf("expected first update to win, got %q", stored.Name)
+	}
+
+	if err := repo.Delete(context.Background(), first.ID, 1); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict for stale delete, got %v", err)
+	}
+	if err := repo.Delete(context.Background(), first.ID, 2); err != nil {
+		t.Fatalf("Delete returned unexpected error: %v", err)
+	}
+	if err := repo.Delete(context.Background(), first.ID, 2); err == nil || errors.Is(err, repository.ErrVersionConflict) {
+		t.Errorf("expected not found for deleted user, got %v", err)
+	}
+
+	if err := repo.Restore(context.Background(), first.ID); err != nil {
+		t.Fatalf("Restore returned unexpected error: %v", err)
+	}
+	restored, _ := repo.FindByID(context.Background(), first.ID)
+	if restored.Version != 3 {
+		t.Errorf("expected restore to bump version to 3, got %d", restored.Version)
+	}
+}
+
+func TestStamp_ChangesOnEveryWrite(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	seedUsers(t, repo)
+	alice, _ := repo.FindByEmail(context.Background(), "alice@example.com")
+
+	previous, err := repo.Stamp(context.Background())
+	if err != nil {
+		t.Fatalf("Stamp returned unexpected error: %v", err)
+	}
+	if previous.Count != 4 || previous.LastModified.IsZero() {
+		t.Fatalf("unexpected initial stamp: %+v", previous)
+	}
+
+	steps := []struct {
+		name  string
+		write func() error
+	}{
+		{"update", func() error { alice.Age++; return repo.Update(context.Background(), alice) }},
+		{"soft delete", func() error { return repo.Delete(context.Background(), alice.ID, 0) }},
+		{"restore", func() error { return repo.Restore(context.Background(), alice.ID) }},
+		{"purge", func() error { return repo.Purge(context.Background(), alice.ID, 0) }},
+	}
+	for _, step := range steps {
+		if err := step.write(); err != nil {
+			t.Fatalf("%s returned unexpected error: %v", step.name, err)
+		}
+		current, err := repo.Stamp(context.Background())
+		if err != nil {
+			t.Fatalf("Stamp returned unexpected error: %v", err)
+		}
+		if current == previous {
+			t.Errorf("expected stamp to change after %s", step.name)
+		}
+		previous = current
+	}
+
+	again, _ := repo.Stamp(context.Background())
+	if again != previous {
+		t.Errorf("expected stamp to be stable without writes, got %+v then %+v", previous, again)
+	}
+}
+
+func TestTransaction_SavepointAndRollback(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	errItem := errors.New("item failed")
+
+	// Savepoint yang gagal hanya membatalkan perubahannya sendiri
+	err := repo.Transaction(context.Background(), func(tx repository.UserRepository) error {
+		if err := tx.Create(context.Background(), &entity.User{Name: "Alice", Email: "alice@example.com", Password: "x"}); err != nil {
+			return err
+		}
+		err := tx.Transaction(context.Background(), func(item repository.UserRepository) error {
+			if err := item.Create(context.Background(), &entity.User{Name: "Bob", Email: "bob@example.com", Password: "x"}); err != nil {
+				return err
+			}
+			return errItem
+		})
+		if !errors.Is(err, errItem) {
+			t.Errorf("expected nested error to be returned, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction returned unexpected error: %v", err)
+	}
+	if _, err := repo.FindByEmail(context.Background(), "alice@example.com"); err != nil {
+		t.Errorf("expected Alice to be committed, got %v", err)
+	}
+	if _, err := repo.FindByEmail(context.Background(), "bob@example.com"); err == nil {
+		t.Error("expected Bob to be rolled back to the savepoint")
+	}
+
+	// Error dari fn me-rollback seluruh transaksi
+	err = repo.Transaction(context.Background(), func(tx repository.UserRepository) error {
+		if err := tx.Create(context.Background(), &entity.User{Name: "Carol", Email: "carol@example.com", Password: "x"}); err != nil {
+			return err
+		}
+		return errItem
+	})
+	if !errors.Is(err, errItem) {
+		t.Errorf("expected Transaction to return fn error, got %v", err)
+	}
+	if _, err := repo.FindByEmail(context.Background(), "carol@example.com"); err == nil {
+		t.Error("expected Carol to be rolled back")
+	}
+}
+
+func TestUserRepository_CanceledContext(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	alice := &entity.User{Name: "Alice", Email: "alice@example.com", Password: "x"}
+	if err := repo.Create(context.Background(), alice); err != nil {
+		t.Fatalf("Create returned unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := repo.FindByID(ctx, alice.ID); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from FindByID, got %v", err)
+	}
+	if err := repo.Create(ctx, &entity.User{Name: "Bob", Email: "bob@example.com", Password: "x"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from Create, got %v", err)
+	}
+	if _, err := repo.FindByEmail(context.Background(), "bob@example.com"); err == nil {
+		t.Error("expected Bob not to be created with a canceled context")
+	}
+}
+
+func TestStream_FiltersAcrossBatches(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+
+	// Lebih dari satu batch Stream (500) agar keyset antar batch ikut teruji
+	users := make([]entity.User, 0, 1201)
+	for i := range 1201 {
+		users = append(users, entity.User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), Age: 20 + i%2, Password: "x"})
+	}
+	for i := range users {
+		if err := repo.Create(context.Background(), &users[i]); err != nil {
+			t.Fatalf("Create returned unexpected error: %v", err)
+		}
+	}
+
+	minAge := 21
+	var ids []uint
+	err := repo.Stream(context.Background(), repository.UserQuery{MinAge: &minAge}, func(user *entity.User) error {
+		ids = append(ids, user.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream returned unexpected error: %v", err)
+	}
+	if len(ids) != 600 {
+		t.Fatalf("expected 600 users aged 21, got %d", len(ids))
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("expected ascending unique IDs, got %d after %d", ids[i], ids[i-1])
+		}
+	}
+
+	// Error dari fn menghentikan iterasi
+	errStop := errors.New("stop")
+	count := 0
+	err = repo.Stream(context.Background(), repository.UserQuery{}, func(user *entity.User) error {
+		count++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || count != 1 {
+		t.Errorf("expected Stream to stop on first error, got err=%v after %d users", err, count)
+	}
+}
+
+func TestAdvanceMFAStep_ConcurrentSameStep(t *testing.T) {
+	repo := repository.NewUserRepository(newTestDB(t))
+	alice := &entity.User{Name: "Alice", Email: "alice@example.com", Password: "x", MFALastStep: 100}
+	if err := repo.Create(context.Background(), alice); err != nil {
+		t.Fatalf("Create returned unexpected error: %v", err)
+	}
+
+	const requests = 8
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := repo.AdvanceMFAStep(context.Background(), alice.ID, 101)
+			if err != nil {
+				t.Errorf("AdvanceMFAStep returned unexpected error: %v", err)
+				return
+			}
+			if ok {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := accepted.Load(); got != 1 {
+		t.Errorf("expected exactly one request to use the step, got %d", got)
+	}
+	if ok, _ := repo.AdvanceMFAStep(context.Background(), alice.ID, 100); ok {
+		t.Error("expected an older step to be rejected")
+	}
+	user, _ := repo.FindByID(context.Background(), alice.ID)
+	if user.MFALastStep != 101 || user.Version != alice.Version {
+		t.Errorf("expected step 101 with unchanged version %d, got step %d version %d", alice.Version, user.MFALastStep, user.Version)
+	}
+}
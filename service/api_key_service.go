@@ -0,0 +1,166 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/authz"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix menandai API key agar mudah dikenali (misalnya oleh secret scanner).
+	apiKeyPrefix = "uak_"
+	// apiKeyLookupLength adalah panjang bagian lookup setelah apiKeyPrefix.
+	apiKeyLookupLength = 12
+	// apiKeyTouchInterval membatasi seberapa sering last_used_at ditulis ke database.
+	apiKeyTouchInterval = time.Minute
+)
+
+var errInvalidAPIKey = apperror.Unauthorized("invalid api key")
+
+// APIKeyService adalah interface untuk API key milik user.
+// Format key: "uak_<lookup>_<secret>"; hanya hash SHA-256 dari key lengkap yang disimpan.
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID uint, req dto.CreateAPIKeyRequest) (*dto.APIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID uint) ([]dto.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uint) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*entity.User, []authz.Permission, error)
+}
+
+// apiKeyServiceImpl adalah implementasi dari APIKeyService.
+type apiKeyServiceImpl struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+}
+
+// NewAPIKeyService membuat instance baru APIKeyService
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository) APIKeyService {
+	return &apiKeyServiceImpl{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+// CreateAPIKey membuat API key baru. Key lengkap hanya dikembalikan di sini.
+func (s *apiKeyServiceImpl) CreateAPIKey(ctx context.Context, userID uint, req dto.CreateAPIKeyRequest) (*dto.APIKeyResponse, error) {
+	scopes := slices.Clone(req.Scopes)
+	for _, scope := range scopes {
+		if !authz.IsKnownPermission(authz.Permission(scope)) {
+			return nil, apperror.Validation("unknown scope: "+scope, apperror.FieldViolation{Field: "scopes", Description: "unknown scope " + scope})
+		}
+	}
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	lookup := make([]byte, apiKeyLookupLength/2)
+	if _, err := rand.Read(lookup); err != nil {
+		return nil, err
+	}
+	secret, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	prefix := hex.EncodeToString(lookup)
+	rawKey := apiKeyPrefix + prefix + "_" + secret
+
+	key := &entity.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: hashToken(rawKey),
+		Scopes:  strings.Join(scopes, " "),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	resp := toAPIKeyResponse(key)
+	resp.Key = rawKey
+	return &resp, nil
+}
+
+// ListAPIKeys mengambil semua API key milik user (tanpa key lengkap).
+func (s *apiKeyServiceImpl) ListAPIKeys(ctx context.Context, userID uint) ([]dto.APIKeyResponse, error) {
+	keys, err := s.apiKeyRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, toAPIKeyResponse(&keys[i]))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey menghapus API key milik user; key langsung tidak bisa dipakai.
+func (s *apiKeyServiceImpl) RevokeAPIKey(ctx context.Context, userID, keyID uint) error {
+	return s.apiKeyRepo.Delete(ctx, userID, keyID)
+}
+
+// AuthenticateAPIKey memvalidasi key dan mengembalikan pemiliknya beserta scope key.
+// Data user dibaca ulang setiap request, jadi perubahan role langsung berlaku.
+func (s *apiKeyServiceImpl) AuthenticateAPIKey(ctx context.Context, rawKey string) (*entity.User, []authz.Permission, error) {
+	rest, ok := strings.CutPrefix(rawKey, apiKeyPrefix)
+	if !ok || len(rest) <= apiKeyLookupLength || rest[apiKeyLookupLength] != '_' {
+		return nil, nil, errInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindByPrefix(ctx, rest[:apiKeyLookupLength])
+	if err != nil {
+		return nil, nil, errInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(rawKey)), []byte(key.KeyHash)) != 1 {
+		return nil, nil, errInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.IsExpired(now) {
+		return nil, nil, apperror.Unauthorized("api key has expired")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, key.UserID)
+	if err != nil {
+		return nil, nil, errInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("Gagal memperbarui last_used_at API key %d: %v", key.ID, err)
+		}
+	}
+
+	var scopes []authz.Permission
+	for _, scope := range key.ScopeList() {
+		scopes = append(scopes, authz.Permission(scope))
+	}
+	return user, scopes, nil
+}
+
+// toAPIKeyResponse mengkonversi entity.APIKey ke DTO (tanpa key lengkap).
+func toAPIKeyResponse(key *entity.APIKey) dto.APIKeyResponse {
+	scopes := key.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return dto.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     apiKeyPrefix + key.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
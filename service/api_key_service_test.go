@@ -0,0 +1,189 @@
+package service_test
+
+import (
+	"api-user-crud-go/authz"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/service"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ==========================================
+// MOCK API KEY REPOSITORY
+// ==========================================
+
+// mockAPIKeyRepo adalah implementasi mock dari repository.APIKeyRepository.
+type mockAPIKeyRepo struct {
+	keys    map[uint]*entity.APIKey
+	nextID  uint
+	touches int
+}
+
+func newMockAPIKeyRepo() *mockAPIKeyRepo {
+	return &mockAPIKeyRepo{keys: make(map[uint]*entity.APIKey), nextID: 1}
+}
+
+func (m *mockAPIKeyRepo) Create(ctx context.Context, key *entity.APIKey) error {
+	key.ID = m.nextID
+	key.CreatedAt = time.Now()
+	m.nextID++
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *mockAPIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	for _, k := range m.keys {
+		if k.Prefix == prefix {
+			copied := *k
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *mockAPIKeyRepo) FindByUser(ctx context.Context, userID uint) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	for _, k := range m.keys {
+		if k.UserID == userID {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyRepo) Delete(ctx context.Context, userID, id uint) error {
+	k, ok := m.keys[id]
+	if !ok || k.UserID != userID {
+		return errors.New("api key not found")
+	}
+	delete(m.keys, id)
+	return nil
+}
+
+func (m *mockAPIKeyRepo) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	m.touches++
+	m.keys[id].LastUsedAt = &usedAt
+	return nil
+}
+
+// ==========================================
+// TESTS
+// ==========================================
+
+func newAPIKeyFixture(t *testing.T) (service.APIKeyService, *mockAPIKeyRepo, *dto.LoginResponse) {
+	t.Helper()
+	f := newAuthFixture()
+	alice := registerAlice(t, f.svc)
+	repo := newMockAPIKeyRepo()
+	return service.NewAPIKeyService(repo, f.users), repo, alice
+}
+
+func TestAPIKey_CreateAndAuthenticate(t *testing.T) {
+	svc, repo, alice := newAPIKeyFixture(t)
+
+	created, err := svc.CreateAPIKey(context.Background(), alice.User.ID, dto.CreateAPIKeyRequest{Name: "cron", Scopes: []string{"users:read", "users:read"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey returned unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, created.Prefix+"_") {
+		t.Errorf("expected key %q to start with prefix %q", created.Key, created.Prefix)
+	}
+	if len(created.Scopes) != 1 {
+		t.Errorf("expected duplicate scopes to be collapsed, got %v", created.Scopes)
+	}
+	if repo.keys[created.ID].KeyHash == created.Key {
+		t.Error("expected key to be stored hashed")
+	}
+
+	user, scopes, err := svc.AuthenticateAPIKey(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey returned unexpected error: %v", err)
+	}
+	if user.ID != alice.User.ID {
+		t.Errorf("expected user %d, got %d", alice.User.ID, user.ID)
+	}
+	if len(scopes) != 1 || scopes[0] != authz.PermUserRead {
+		t.Errorf("expected [users:read], got %v", scopes)
+	}
+
+	// last_used_at dicatat, tapi tidak ditulis ulang untuk setiap request
+	svc.AuthenticateAPIKey(context.Background(), created.Key)
+	if repo.touches != 1 || repo.keys[created.ID].LastUsedAt == nil {
+		t.Errorf("expected exactly one last_used_at update, got %d", repo.touches)
+	}
+
+	list, _ := svc.ListAPIKeys(context.Background(), alice.User.ID)
+	if len(list) != 1 || list[0].Key != "" || list[0].LastUsedAt == nil {
+		t.Errorf("expected listed key without secret and with last_used_at, got %+v", list)
+	}
+}
+
+func TestAPIKey_UnscopedKeyHasNoScopes(t *testing.T) {
+	svc, _, alice := newAPIKeyFixture(t)
+	created, _ := svc.CreateAPIKey(context.Background(), alice.User.ID, dto.CreateAPIKeyRequest{Name: "script"})
+
+	_, scopes, err := svc.AuthenticateAPIKey(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey returned unexpected error: %v", err)
+	}
+	if scopes != nil {
+		t.Errorf("expected nil scopes for unscoped key, got %v", scopes)
+	}
+}
+
+func TestAPIKey_RejectsUnknownScope(t *testing.T) {
+	svc, _, alice := newAPIKeyFixture(t)
+	if _, err := svc.CreateAPIKey(context.Background(), alice.User.ID, dto.CreateAPIKeyRequest{Name: "x", Scopes: []string{"users:everything"}}); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
+
+func TestAPIKey_AuthenticateRejectsInvalidKeys(t *testing.T) {
+	svc, repo, alice := newAPIKeyFixture(t)
+	created, _ := svc.CreateAPIKey(context.Background(), alice.User.ID, dto.CreateAPIKeyRequest{Name: "cron", ExpiresInDays: 30})
+	if created.ExpiresAt == nil {
+		t.Fatal("expected expires_at to be set")
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"empty", ""},
+		{"wrong format", "not-an-api-key"},
+		{"prefix only", created.Prefix},
+		{"wrong secret", created.Prefix + "_wrong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := svc.AuthenticateAPIKey(context.Background(), tt.key); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	past := time.Now().Add(-time.Minute)
+	repo.keys[created.ID].ExpiresAt = &past
+	if _, _, err := svc.AuthenticateAPIKey(context.Background(), created.Key); err == nil {
+		t.Error("expected error for expired key")
+	}
+}
+
+func TestAPIKey_Revoke(t *testing.T) {
+	svc, _, alice := newAPIKeyFixture(t)
+	created, _ := svc.CreateAPIKey(context.Background(), alice.User.ID, dto.CreateAPIKeyRequest{Name: "cron"})
+
+	if err := svc.RevokeAPIKey(context.Background(), alice.User.ID+1, created.ID); err == nil {
+		t.Error("expected other users to be unable to revoke the key")
+	}
+	if err := svc.RevokeAPIKey(context.Background(), alice.User.ID, created.ID); err != nil {
+		t.Fatalf("RevokeAPIKey returned unexpected error: %v", err)
+	}
+	if _, _, err := svc.AuthenticateAPIKey(context.Background(), created.Key); err == nil {
+		t.Error("expected revoked key to be rejected")
+	}
+}
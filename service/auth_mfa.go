@@ -0,0 +1,263 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+)
+
+// recoveryCodeCount adalah jumlah recovery code yang dibuat saat 2FA diaktifkan.
+const recoveryCodeCount = 10
+
+// mfaPendingPayload adalah isi token "mfa pending" yang diterbitkan setelah
+// password benar, sebelum kode 2FA diverifikasi.
+type mfaPendingPayload struct {
+	UserID    uint  `json:"u"`
+	ExpiresAt int64 `json:"x"` // unix detik
+}
+
+// EnrollMFA memulai enrollment 2FA: membuat secret TOTP baru dan provisioning URI.
+// 2FA baru aktif setelah ConfirmMFA berhasil dengan kode dari authenticator.
+func (s *authServiceImpl) EnrollMFA(ctx context.Context, userID uint) (*dto.MFAEnrollResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabledAt != nil {
+		return nil, apperror.Conflict("two-factor authentication already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFASecret = secret
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &dto.MFAEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA mengaktifkan 2FA setelah user membuktikan authenticator-nya bekerja.
+// Recovery code baru dibuat, semua sesi lain dicabut, dan pemanggil mendapat
+// pasangan token baru yang sudah membawa claim mfa.
+func (s *authServiceImpl) ConfirmMFA(ctx context.Context, userID, sessionID uint, req dto.MFACodeRequest) (*dto.MFAConfirmResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabledAt != nil {
+		return nil, apperror.Conflict("two-factor authentication already enabled")
+	}
+	if user.MFASecret == "" {
+		return nil, apperror.Conflict("two-factor enrollment has not been started")
+	}
+
+	step, ok := totp.Validate(user.MFASecret, strings.TrimSpace(req.Code), time.Now(), 1)
+	if !ok {
+		return nil, apperror.Validation("invalid two-factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	// 2FA tidak boleh aktif tanpa recovery code yang sudah diberikan ke user
+	var tokens *dto.LoginResponse
+	err = s.inTx(ctx, func(tx *authServiceImpl) error {
+		now := time.Now()
+		user.MFAEnabledAt = &now
+		user.MFALastStep = step
+		if err := tx.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := tx.recoveryRepo.ReplaceForUser(ctx, user.ID, hashes); err != nil {
+			return err
+		}
+
+		// Sesi yang dibuat sebelum 2FA aktif tidak pernah melewati verifikasi kode
+		session := tx.currentSession(ctx, user.ID, sessionID)
+		if err := tx.revokeAllSessions(ctx, user.ID, now, now, session.ID); err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = tx.issueTokens(ctx, user, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MFAConfirmResponse{RecoveryCodes: codes, LoginResponse: *tokens}, nil
+}
+
+// DisableMFA menonaktifkan 2FA. Perlu kode TOTP atau recovery code yang valid.
+func (s *authServiceImpl) DisableMFA(ctx context.Context, userID uint, req dto.MFACodeRequest) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.MFAEnabledAt == nil {
+		return apperror.Conflict("two-factor authentication is not enabled")
+	}
+	if s.cfg.RequireAdminMFA && user.Role == entity.RoleAdmin {
+		return apperror.Forbidden("two-factor authentication is required for admin accounts")
+	}
+
+	// Recovery code yang dipakai untuk menonaktifkan 2FA ikut di-rollback jika gagal
+	return s.inTx(ctx, func(tx *authServiceImpl) error {
+		if err := tx.checkMFACode(ctx, user, req.Code); err != nil {
+			return err
+		}
+
+		user.MFASecret = ""
+		user.MFAEnabledAt = nil
+		user.MFALastStep = 0
+		if err := tx.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		return tx.recoveryRepo.DeleteForUser(ctx, user.ID)
+	})
+}
+
+// VerifyMFA menyelesaikan login 2FA: menukar token "mfa pending" dan kode
+// (TOTP atau recovery code) dengan pasangan access token + refresh token.
+// Kode yang salah dihitung sebagai login gagal, sama seperti password yang salah.
+func (s *authServiceImpl) VerifyMFA(ctx context.Context, clientIP, userAgent string, req dto.MFAVerifyRequest) (*dto.LoginResponse, error) {
+	user, err := s.authenticateMFA(ctx, clientIP, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user, newSession(clientIP, userAgent))
+}
+
+// AuthenticateMFA menyelesaikan Authenticate untuk user dengan 2FA, tanpa menerbitkan token.
+func (s *authServiceImpl) AuthenticateMFA(ctx context.Context, clientIP string, req dto.MFAVerifyRequest) (*dto.UserResponse, error) {
+	user, err := s.authenticateMFA(ctx, clientIP, req)
+	if err != nil {
+		return nil, err
+	}
+	return toUserResponse(user), nil
+}
+
+// authenticateMFA memvalidasi token "mfa pending" dan kode 2FA dengan proteksi brute-force.
+func (s *authServiceImpl) authenticateMFA(ctx context.Context, clientIP string, req dto.MFAVerifyRequest) (*entity.User, error) {
+	var p mfaPendingPayload
+	err := decodeSignedToken(s.cfg.JWTSecret, purposeMFAPending, req.MFAToken, &p)
+	if err != nil || time.Now().Unix() > p.ExpiresAt {
+		return nil, apperror.Unauthorized("invalid or expired mfa token")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, p.UserID)
+	if err != nil || user.MFAEnabledAt == nil {
+		return nil, apperror.Unauthorized("invalid or expired mfa token")
+	}
+
+	if err := s.checkLoginAllowed(ctx, user.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	// Kode salah pada login adalah kegagalan autentikasi, bukan input yang tidak valid
+	if err := s.checkMFACode(ctx, user, req.Code); err != nil {
+		if errors.Is(err, apperror.ErrValidation) {
+			err = apperror.Unauthorized(err.Error())
+		}
+		return nil, s.loginFailed(ctx, user.Email, clientIP, err)
+	}
+
+	if err := s.recordLoginSuccess(ctx, user.Email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// mfaChallenge membuat response login untuk user dengan 2FA aktif.
+// Token "mfa pending" bukan JWT sehingga tidak bisa dipakai sebagai access token.
+func (s *authServiceImpl) mfaChallenge(user *entity.User) (*dto.LoginResponse, error) {
+	token, err := encodeSignedToken(s.cfg.JWTSecret, purposeMFAPending, mfaPendingPayload{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Duration(s.cfg.MFAPendingTokenMinutes) * time.Minute).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{MFARequired: true, MFAToken: token}, nil
+}
+
+// checkMFACode memvalidasi kode TOTP atau recovery code milik user.
+// Kode TOTP yang sudah pernah dipakai (time step yang sama atau lebih lama) ditolak,
+// termasuk jika kode yang sama dikirim oleh dua request paralel.
+func (s *authServiceImpl) checkMFACode(ctx context.Context, user *entity.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.MFASecret, code, time.Now(), 1)
+		if !ok {
+			return apperror.Validation("invalid two-factor code")
+		}
+
+		advanced, err := s.userRepo.AdvanceMFAStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return apperror.Validation("invalid two-factor code")
+		}
+		user.MFALastStep = step
+		return nil
+	}
+
+	ok, err := s.recoveryRepo.Use(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperror.Validation("invalid two-factor code")
+	}
+	return nil
+}
+
+// generateRecoveryCodes membuat recovery code baru beserta hash-nya.
+// Format kode: "xxxx-xxxx" (base32 huruf kecil, 40 bit acak).
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode menyamakan format input user (huruf besar, tanpa strip, dll).
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
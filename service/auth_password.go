@@ -0,0 +1,159 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/notification"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword mengganti password user yang sedang login.
+// Semua sesi lain dicabut; pemanggil mendapat pasangan token baru dalam
+// session sessionID miliknya.
+func (s *authServiceImpl) ChangePassword(ctx context.Context, userID, sessionID uint, req dto.ChangePasswordRequest) (*dto.LoginResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword))
+	if err != nil {
+		return nil, apperror.Validation("current password is incorrect", apperror.FieldViolation{Field: "current_password", Description: "is incorrect"})
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return nil, apperror.Validation("new password must be different from the current password", apperror.FieldViolation{Field: "new_password", Description: "must be different from the current password"})
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	// Password baru, pencabutan sesi lain, dan token baru diterapkan bersama
+	var resp *dto.LoginResponse
+	err = s.inTx(ctx, func(tx *authServiceImpl) error {
+		user.Password = hashedPassword
+		if err := tx.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Token baru diterbitkan di bawah ini setelah session lain dicabut
+		session := tx.currentSession(ctx, user.ID, sessionID)
+		now := time.Now()
+		if err := tx.revokeAllSessions(ctx, user.ID, now, now, session.ID); err != nil {
+			return err
+		}
+
+		var err error
+		resp, err = tx.issueTokens(ctx, user, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ForgotPassword membuat token reset password dan mengirimkannya lewat notifier.
+// Selalu berhasil walaupun email tidak terdaftar, agar endpoint tidak bisa
+// dipakai untuk mengecek email mana yang terdaftar.
+func (s *authServiceImpl) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return nil
+	}
+
+	// Hanya token terbaru yang berlaku
+	if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.PasswordResetExpiryMinutes) * time.Minute)
+	err = s.resetRepo.Create(ctx, &entity.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Send(notification.Message{
+		To:      user.Email,
+		Subject: "Reset password",
+		Body: fmt.Sprintf(
+			"Gunakan token berikut untuk reset password Anda:\n\n%s\n\nToken berlaku sampai %s dan hanya bisa dipakai sekali.",
+			token, expiresAt.Format(time.RFC1123),
+		),
+	})
+	if err != nil {
+		// Jangan bocorkan kegagalan pengiriman ke client
+		log.Printf("Gagal mengirim email reset password ke user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword mengganti password menggunakan token reset.
+// Token hanya bisa dipakai sekali dan semua sesi user dicabut.
+func (s *authServiceImpl) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	stored, err := s.resetRepo.FindByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return apperror.Validation("invalid or expired reset token")
+	}
+
+	if stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return apperror.Validation("invalid or expired reset token")
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// Token hanya terpakai jika password benar-benar berganti
+	return s.inTx(ctx, func(tx *authServiceImpl) error {
+		// Update bersyarat: request paralel dengan token yang sama hanya satu yang menang
+		ok, err := tx.resetRepo.MarkUsed(ctx, stored.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apperror.Validation("invalid or expired reset token")
+		}
+
+		user, err := tx.userRepo.FindByID(ctx, stored.UserID)
+		if err != nil {
+			return apperror.Validation("invalid or expired reset token")
+		}
+
+		user.Password = hashedPassword
+		if err := tx.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.revokeAllSessions(ctx, user.ID, now, now, 0)
+	})
+}
+
+// hashPassword meng-hash password baru user. Dipanggil sebelum transaksi
+// dibuka agar lock database tidak tertahan selama bcrypt.
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
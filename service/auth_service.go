@@ -1,45 +1,104 @@
 package service
 
 import (
+	"api-user-crud-go/apperror"
 	"api-user-crud-go/config"
 	"api-user-crud-go/dto"
 	"api-user-crud-go/entity"
+	"api-user-crud-go/jwtkeys"
 	"api-user-crud-go/middleware"
+	"api-user-crud-go/notification"
 	"api-user-crud-go/repository"
+	"context"
 	"errors"
+	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrEmailRegistered dikembalikan Register ketika email sudah dipakai user lain,
+// termasuk ketika dua registrasi dengan email yang sama berjalan bersamaan.
+var ErrEmailRegistered = apperror.AlreadyExists("email already registered")
+
 // AuthService adalah interface untuk authentication logic
 type AuthService interface {
-	Register(req dto.RegisterRequest) (*dto.LoginResponse, error)
-	Login(req dto.LoginRequest) (*dto.LoginResponse, error)
+	Register(ctx context.Context, clientIP, userAgent string, req dto.RegisterRequest) (*dto.LoginResponse, error)
+	Login(ctx context.Context, clientIP, userAgent string, req dto.LoginRequest) (*dto.LoginResponse, error)
+	Refresh(ctx context.Context, req dto.RefreshTokenRequest) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, userID uint, jti string, tokenExpiresAt time.Time, req dto.LogoutRequest) error
+	LogoutAll(ctx context.Context, userID uint, req dto.LogoutAllRequest) error
+	ChangePassword(ctx context.Context, userID, sessionID uint, req dto.ChangePasswordRequest) (*dto.LoginResponse, error)
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+	VerifyEmail(ctx context.Context, token string) (*dto.UserResponse, error)
+	ResendVerification(ctx context.Context, userID uint) error
+	EnrollMFA(ctx context.Context, userID uint) (*dto.MFAEnrollResponse, error)
+	ConfirmMFA(ctx context.Context, userID, sessionID uint, req dto.MFACodeRequest) (*dto.MFAConfirmResponse, error)
+	DisableMFA(ctx context.Context, userID uint, req dto.MFACodeRequest) error
+	VerifyMFA(ctx context.Context, clientIP, userAgent string, req dto.MFAVerifyRequest) (*dto.LoginResponse, error)
+	UnlockAccount(ctx context.Context, adminID uint, userID uint) error
+	Authenticate(ctx context.Context, clientIP string, req dto.LoginRequest) (*dto.LoginResponse, error)
+	AuthenticateMFA(ctx context.Context, clientIP string, req dto.MFAVerifyRequest) (*dto.UserResponse, error)
+	ListSessions(ctx context.Context, userID, currentSessionID uint) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uint) error
 }
 
 // authServiceImpl adalah implementasi dari AuthService
 type authServiceImpl struct {
-	userRepo repository.UserRepository
-	cfg      *config.Config
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	sessionRepo      repository.SessionRepository
+	revocationRepo   repository.TokenRevocationRepository
+	resetRepo        repository.PasswordResetRepository
+	recoveryRepo     repository.RecoveryCodeRepository
+	attemptRepo      repository.LoginAttemptRepository
+	uow              repository.UnitOfWork
+	notifier         notification.Notifier
+	keys             *jwtkeys.KeySet
+	cfg              *config.Config
+
+	// inTransaction menandai salinan service yang dibuat inTx
+	inTransaction bool
 }
 
 // NewAuthService membuat instance baru AuthService
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	sessionRepo repository.SessionRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	resetRepo repository.PasswordResetRepository,
+	recoveryRepo repository.RecoveryCodeRepository,
+	attemptRepo repository.LoginAttemptRepository,
+	uow repository.UnitOfWork,
+	notifier notification.Notifier,
+	keys *jwtkeys.KeySet,
+	cfg *config.Config,
+) AuthService {
 	return &authServiceImpl{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		revocationRepo:   revocationRepo,
+		resetRepo:        resetRepo,
+		recoveryRepo:     recoveryRepo,
+		attemptRepo:      attemptRepo,
+		uow:              uow,
+		notifier:         notifier,
+		keys:             keys,
+		cfg:              cfg,
 	}
 }
 
-// Register mendaftarkan user baru
-func (s *authServiceImpl) Register(req dto.RegisterRequest) (*dto.LoginResponse, error) {
-	// Cek apakah email sudah terdaftar
-	existingUser, _ := s.userRepo.FindByEmail(req.Email)
-	if existingUser != nil {
-		return nil, errors.New("email already registered")
+// Register mendaftarkan user baru dan langsung membuka session untuknya.
+func (s *authServiceImpl) Register(ctx context.Context, clientIP, userAgent string, req dto.RegisterRequest) (*dto.LoginResponse, error) {
+	// Cek awal agar email yang jelas sudah terdaftar tidak perlu di-hash
+	if _, err := s.userRepo.FindByEmail(ctx, req.Email); err == nil {
+		return nil, ErrEmailRegistered
 	}
 
-	// Hash password
+	// Hash password di luar transaksi agar lock database tidak tertahan selama bcrypt
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -51,55 +110,302 @@ func (s *authServiceImpl) Register(req dto.RegisterRequest) (*dto.LoginResponse,
 		Email:    req.Email,
 		Password: string(hashedPassword),
 		Age:      req.Age,
+		Role:     entity.RoleUser,
 	}
 
-	err = s.userRepo.Create(user)
-	if err != nil {
-		return nil, err
+	// Email yang dikonfigurasi sebagai admin awal langsung mendapat role admin
+	// dan dianggap sudah terverifikasi (alamatnya dipercaya oleh operator)
+	if s.cfg.BootstrapAdminEmail != "" && req.Email == s.cfg.BootstrapAdminEmail {
+		now := time.Now()
+		user.Role = entity.RoleAdmin
+		user.EmailVerifiedAt = &now
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateToken(user.ID, user.Email, s.cfg)
+	// User, session, dan refresh token dibuat bersama: jika salah satu gagal,
+	// email tidak tertahan oleh akun setengah jadi yang tidak bisa dipakai login
+	var resp *dto.LoginResponse
+	err = s.inTx(ctx, func(tx *authServiceImpl) error {
+		if _, err := tx.userRepo.FindByEmail(ctx, req.Email); err == nil {
+			return ErrEmailRegistered
+		}
+		// Unique index tetap menjadi penjaga terakhir untuk registrasi paralel
+		if err := tx.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicateEmail) {
+				return ErrEmailRegistered
+			}
+			return err
+		}
+
+		var err error
+		resp, err = tx.issueTokens(ctx, user, newSession(clientIP, userAgent))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.LoginResponse{
-		Token: token,
-		User: dto.UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-			Age:   user.Age,
-		},
-	}, nil
+	// User tetap terdaftar walaupun link gagal dikirim; bisa minta kirim ulang
+	if user.EmailVerifiedAt == nil {
+		if err := s.sendVerification(ctx, user); err != nil {
+			log.Printf("Gagal membuat token verifikasi untuk user %d: %v", user.ID, err)
+		}
+	}
+	return resp, nil
+}
+
+// inTx menjalankan fn dengan salinan service yang repository-nya terikat ke satu
+// transaksi (lihat repository.UnitOfWork), sehingga operasi beberapa langkah
+// bersifat atomik. Jika service sudah berada di dalam transaksi, fn langsung
+// dijalankan di transaksi yang sama. Transaksi di-rollback jika ctx dibatalkan.
+func (s *authServiceImpl) inTx(ctx context.Context, fn func(tx *authServiceImpl) error) error {
+	if s.inTransaction {
+		return fn(s)
+	}
+	return s.uow.WithTx(ctx, func(repos repository.Repositories) error {
+		tx := *s
+		tx.userRepo = repos.Users
+		tx.refreshTokenRepo = repos.RefreshTokens
+		tx.sessionRepo = repos.Sessions
+		tx.revocationRepo = repos.Revocations
+		tx.resetRepo = repos.PasswordResets
+		tx.recoveryRepo = repos.RecoveryCodes
+		tx.inTransaction = true
+		return fn(&tx)
+	})
+}
+
+// Login mengautentikasi user dengan email dan password.
+// Login gagal dicatat per akun dan per IP client (lihat login_throttle.go).
+func (s *authServiceImpl) Login(ctx context.Context, clientIP, userAgent string, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	user, challenge, err := s.authenticatePassword(ctx, clientIP, req)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	// Generate access token + refresh token (session baru)
+	return s.issueTokens(ctx, user, newSession(clientIP, userAgent))
+}
+
+// Authenticate memeriksa email dan password seperti Login, tapi tanpa menerbitkan
+// token. Dipakai oleh alur lain yang butuh identitas user (misalnya OAuth authorize).
+// Response hanya berisi User, atau MFARequired + MFAToken untuk user dengan 2FA.
+func (s *authServiceImpl) Authenticate(ctx context.Context, clientIP string, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	user, challenge, err := s.authenticatePassword(ctx, clientIP, req)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+	return &dto.LoginResponse{User: toUserResponse(user)}, nil
 }
-func (s *authServiceImpl) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
+
+// authenticatePassword memeriksa email dan password dengan proteksi brute-force.
+// Untuk user dengan 2FA aktif, yang dikembalikan adalah challenge, bukan user.
+func (s *authServiceImpl) authenticatePassword(ctx context.Context, clientIP string, req dto.LoginRequest) (*entity.User, *dto.LoginResponse, error) {
+	if err := s.checkLoginAllowed(ctx, req.Email, clientIP); err != nil {
+		return nil, nil, err
+	}
+
 	// Cari user berdasarkan email
-	user, err := s.userRepo.FindByEmail(req.Email)
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		// Request yang dibatalkan bukan percobaan login gagal
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		return nil, nil, s.loginFailed(ctx, req.Email, clientIP, apperror.Unauthorized("invalid email or password"))
 	}
 
 	// Verifikasi password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, nil, s.loginFailed(ctx, req.Email, clientIP, apperror.Unauthorized("invalid email or password"))
+	}
+
+	// User dengan 2FA aktif harus memverifikasi kode dulu (POST /auth/mfa/verify).
+	// Hitungan login gagal baru di-reset setelah kode 2FA benar.
+	if user.MFAEnabledAt != nil {
+		challenge, err := s.mfaChallenge(user)
+		return nil, challenge, err
+	}
+
+	if err := s.recordLoginSuccess(ctx, user.Email); err != nil {
+		return nil, nil, err
+	}
+	return user, nil, nil
+}
+
+// Refresh menukar refresh token dengan pasangan token baru (rotasi).
+// Token lama langsung tidak berlaku. Jika token yang sudah pernah dipakai
+// dikirim lagi, seluruh family dicabut karena kemungkinan besar token bocor.
+func (s *authServiceImpl) Refresh(ctx context.Context, req dto.RefreshTokenRequest) (*dto.LoginResponse, error) {
+	stored, err := s.refreshTokenRepo.FindByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, apperror.Unauthorized("invalid refresh token")
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, apperror.Unauthorized("refresh token has been revoked")
+	}
+
+	if stored.UsedAt != nil {
+		if err := s.revokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, apperror.Unauthorized("refresh token reuse detected")
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, apperror.Unauthorized("refresh token expired")
+	}
+
+	// Token lama ditandai terpakai di transaksi yang sama dengan penerbitan token
+	// baru, sehingga kegagalan di tengah jalan tidak membuat client kehilangan session
+	var resp *dto.LoginResponse
+	reused := false
+	err = s.inTx(ctx, func(tx *authServiceImpl) error {
+		// Gagal menandai berarti ada request lain yang menang
+		ok, err := tx.refreshTokenRepo.MarkUsed(ctx, stored.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			reused = true
+			return nil
+		}
+
+		user, err := tx.userRepo.FindByID(ctx, stored.UserID)
+		if err != nil {
+			return apperror.Unauthorized("invalid refresh token")
+		}
+
+		session, err := tx.sessionForFamily(ctx, user.ID, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+		resp, err = tx.issueTokens(ctx, user, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		if err := s.revokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, apperror.Unauthorized("refresh token reuse detected")
+	}
+	return resp, nil
+}
+
+// Logout mencabut access token yang sedang dipakai.
+// Jika refresh token ikut dikirim, seluruh family-nya juga dicabut.
+func (s *authServiceImpl) Logout(ctx context.Context, userID uint, jti string, tokenExpiresAt time.Time, req dto.LogoutRequest) error {
+	return s.inTx(ctx, func(tx *authServiceImpl) error {
+		if err := tx.revocationRepo.Revoke(ctx, jti, userID, tokenExpiresAt); err != nil {
+			return err
+		}
+
+		if req.RefreshToken == "" {
+			return nil
+		}
+
+		stored, err := tx.refreshTokenRepo.FindByHash(ctx, hashToken(req.RefreshToken))
+		if err != nil || stored.UserID != userID {
+			return apperror.Unauthorized("invalid refresh token")
+		}
+		return tx.revokeFamily(ctx, stored.FamilyID)
+	})
+}
+
+// LogoutAll mencabut semua token user yang diterbitkan sebelum waktu tertentu
+// (default: sekarang), termasuk semua refresh token.
+func (s *authServiceImpl) LogoutAll(ctx context.Context, userID uint, req dto.LogoutAllRequest) error {
+	before := time.Now()
+	if req.Before != nil {
+		if req.Before.After(before) {
+			return apperror.Validation("before must not be in the future", apperror.FieldViolation{Field: "before", Description: "must not be in the future"})
+		}
+		before = *req.Before
+	}
+
+	return s.revokeAllSessions(ctx, userID, before, before, 0)
+}
+
+// revokeAllSessions mencabut access token yang diterbitkan sebelum accessBefore
+// serta refresh token dan session yang terakhir diperbarui sebelum refreshBefore.
+// Session keepSessionID (0 = tidak ada) tetap aktif agar pemanggil bisa
+// melanjutkan session-nya dengan token baru.
+func (s *authServiceImpl) revokeAllSessions(ctx context.Context, userID uint, accessBefore, refreshBefore time.Time, keepSessionID uint) error {
+	// Claim iat hanya berpresisi detik, jadi batas access token dibulatkan ke bawah
+	// agar token yang diterbitkan setelahnya di detik yang sama tidak ikut tercabut
+	accessBefore = accessBefore.Truncate(time.Second)
+
+	// Entri boleh dibersihkan setelah access token terakhir yang terdampak expired
+	expiresAt := accessBefore.Add(middleware.AccessTokenTTL(s.cfg))
+	return s.inTx(ctx, func(tx *authServiceImpl) error {
+		if err := tx.revocationRepo.RevokeAllForUser(ctx, userID, accessBefore, expiresAt); err != nil {
+			return err
+		}
+		if err := tx.refreshTokenRepo.RevokeAllForUser(ctx, userID, refreshBefore); err != nil {
+			return err
+		}
+		return tx.sessionRepo.RevokeAllForUser(ctx, userID, refreshBefore, keepSessionID)
+	})
+}
+
+// issueTokens membuat access token dan refresh token baru dalam session.
+// Session yang belum tersimpan (lihat newSession) dibuat dulu dengan family
+// refresh token baru; untuk session lama, last_seen_at dan expires_at diperbarui.
+func (s *authServiceImpl) issueTokens(ctx context.Context, user *entity.User, session *entity.Session) (*dto.LoginResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(s.cfg.RefreshTokenExpiryHours) * time.Hour)
+
+	if session.ID == 0 && session.FamilyID == "" {
+		familyID, err := generateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		session.FamilyID = familyID
+	}
+
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Session dan refresh token disimpan bersama agar tidak ada session tanpa token
+	err = s.inTx(ctx, func(tx *authServiceImpl) error {
+		if session.ID == 0 {
+			session.UserID = user.ID
+			session.LastSeenAt = now
+			session.ExpiresAt = expiresAt
+			if err := tx.sessionRepo.Create(ctx, session); err != nil {
+				return err
+			}
+		} else if err := tx.sessionRepo.Touch(ctx, session.ID, now, expiresAt); err != nil {
+			return err
+		}
+
+		return tx.refreshTokenRepo.Create(ctx, &entity.RefreshToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(refreshToken),
+			FamilyID:  session.FamilyID,
+			ExpiresAt: expiresAt,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateToken(user.ID, user.Email, s.cfg)
+	accessToken, err := middleware.GenerateToken(user, session.ID, s.keys, s.cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &dto.LoginResponse{
-		Token: token,
-		User: dto.UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-			Age:   user.Age,
-		},
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(middleware.AccessTokenTTL(s.cfg).Seconds()),
+		User:         toUserResponse(user),
 	}, nil
 }
@@ -0,0 +1,1271 @@
+package service_test
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/notification"
+	"api-user-crud-go/repository"
+	"api-user-crud-go/service"
+	"api-user-crud-go/totp"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ==========================================
+// MOCK REFRESH TOKEN REPOSITORY
+// ==========================================
+
+// mockRefreshTokenRepo adalah implementasi mock dari repository.RefreshTokenRepository.
+type mockRefreshTokenRepo struct {
+	tokens map[uint]*entity.RefreshToken
+	nextID uint
+}
+
+func newMockRefreshTokenRepo() *mockRefreshTokenRepo {
+	return &mockRefreshTokenRepo{tokens: make(map[uint]*entity.RefreshToken), nextID: 1}
+}
+
+func (m *mockRefreshTokenRepo) Create(ctx context.Context, token *entity.RefreshToken) error {
+	token.CreatedAt = time.Now()
+	token.ID = m.nextID
+	m.nextID++
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) FindByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("refresh token not found")
+}
+
+func (m *mockRefreshTokenRepo) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	t, ok := m.tokens[id]
+	if !ok || t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.CreatedAt.Before(before) && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for id, t := range m.tokens {
+		if t.ExpiresAt.Before(now) {
+			delete(m.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ==========================================
+// MOCK SESSION REPOSITORY
+// ==========================================
+
+// mockSessionRepo adalah implementasi mock dari repository.SessionRepository.
+type mockSessionRepo struct {
+	sessions map[uint]*entity.Session
+	nextID   uint
+}
+
+func newMockSessionRepo() *mockSessionRepo {
+	return &mockSessionRepo{sessions: make(map[uint]*entity.Session), nextID: 1}
+}
+
+func (m *mockSessionRepo) Create(ctx context.Context, session *entity.Session) error {
+	session.ID = m.nextID
+	session.CreatedAt = time.Now()
+	m.nextID++
+	copied := *session
+	m.sessions[session.ID] = &copied
+	return nil
+}
+
+func (m *mockSessionRepo) FindByID(ctx context.Context, id uint) (*entity.Session, error) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (m *mockSessionRepo) FindByFamily(ctx context.Context, familyID string) (*entity.Session, error) {
+	for id, s := range m.sessions {
+		if s.FamilyID == familyID {
+			return m.FindByID(ctx, id)
+		}
+	}
+	return nil, errors.New("session not found")
+}
+
+func (m *mockSessionRepo) FindActiveByUser(ctx context.Context, userID uint, now time.Time) ([]entity.Session, error) {
+	var sessions []entity.Session
+	for id := uint(1); id < m.nextID; id++ {
+		if s, ok := m.sessions[id]; ok && s.UserID == userID && s.IsActive(now) {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *mockSessionRepo) Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error {
+	if s, ok := m.sessions[id]; ok {
+		s.LastSeenAt = lastSeenAt
+		s.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) Revoke(ctx context.Context, id uint) error {
+	if s, ok := m.sessions[id]; ok && s.RevokedAt == nil {
+		now := time.Now()
+		s.RevokedAt = &now
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) RevokeByFamily(ctx context.Context, familyID string) error {
+	for id, s := range m.sessions {
+		if s.FamilyID == familyID {
+			return m.Revoke(ctx, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) RevokeAllForUser(ctx context.Context, userID uint, before time.Time, exceptID uint) error {
+	for id, s := range m.sessions {
+		if s.UserID == userID && s.LastSeenAt.Before(before) && id != exceptID {
+			m.Revoke(ctx, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ==========================================
+// MOCK TOKEN REVOCATION REPOSITORY
+// ==========================================
+
+// mockRevocationRepo adalah implementasi mock dari repository.TokenRevocationRepository.
+// Seperti implementasi aslinya, session yang dicabut dibaca dari tabel session.
+type mockRevocationRepo struct {
+	jtis          map[string]time.Time
+	revokedBefore map[uint]time.Time
+	sessions      *mockSessionRepo
+}
+
+func newMockRevocationRepo(sessions *mockSessionRepo) *mockRevocationRepo {
+	return &mockRevocationRepo{jtis: make(map[string]time.Time), revokedBefore: make(map[uint]time.Time), sessions: sessions}
+}
+
+func (m *mockRevocationRepo) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	m.jtis[jti] = expiresAt
+	return nil
+}
+
+func (m *mockRevocationRepo) RevokeAllForUser(ctx context.Context, userID uint, before time.Time, expiresAt time.Time) error {
+	// Seperti implementasi aslinya, batas yang sudah ada tidak dimundurkan
+	if current, ok := m.revokedBefore[userID]; !ok || before.After(current) {
+		m.revokedBefore[userID] = before
+	}
+	return nil
+}
+
+func (m *mockRevocationRepo) IsRevoked(ctx context.Context, jti string, sessionID uint, userID uint, issuedAt time.Time) (bool, error) {
+	if _, ok := m.jtis[jti]; ok {
+		return true, nil
+	}
+	if s, ok := m.sessions.sessions[sessionID]; ok && s.RevokedAt != nil {
+		return true, nil
+	}
+	before, ok := m.revokedBefore[userID]
+	return ok && issuedAt.Before(before), nil
+}
+
+func (m *mockRevocationRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for jti, exp := range m.jtis {
+		if exp.Before(now) {
+			delete(m.jtis, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ==========================================
+// MOCK PASSWORD RESET REPOSITORY
+// ==========================================
+
+// mockPasswordResetRepo adalah implementasi mock dari repository.PasswordResetRepository.
+type mockPasswordResetRepo struct {
+	tokens map[uint]*entity.PasswordResetToken
+	nextID uint
+}
+
+func newMockPasswordResetRepo() *mockPasswordResetRepo {
+	return &mockPasswordResetRepo{tokens: make(map[uint]*entity.PasswordResetToken), nextID: 1}
+}
+
+func (m *mockPasswordResetRepo) Create(ctx context.Context, token *entity.PasswordResetToken) error {
+	token.ID = m.nextID
+	m.nextID++
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *mockPasswordResetRepo) FindByHash(ctx context.Context, tokenHash string) (*entity.PasswordResetToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("password reset token not found")
+}
+
+func (m *mockPasswordResetRepo) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	t, ok := m.tokens[id]
+	if !ok || t.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (m *mockPasswordResetRepo) InvalidateForUser(ctx context.Context, userID uint) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockPasswordResetRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for id, t := range m.tokens {
+		if t.ExpiresAt.Before(now) {
+			delete(m.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ==========================================
+// MOCK RECOVERY CODE REPOSITORY
+// ==========================================
+
+// mockRecoveryCodeRepo adalah implementasi mock dari repository.RecoveryCodeRepository.
+type mockRecoveryCodeRepo struct {
+	// hashes menyimpan hash kode per user; true berarti sudah dipakai
+	hashes map[uint]map[string]bool
+}
+
+func newMockRecoveryCodeRepo() *mockRecoveryCodeRepo {
+	return &mockRecoveryCodeRepo{hashes: make(map[uint]map[string]bool)}
+}
+
+func (m *mockRecoveryCodeRepo) ReplaceForUser(ctx context.Context, userID uint, codeHashes []string) error {
+	m.hashes[userID] = make(map[string]bool)
+	for _, h := range codeHashes {
+		m.hashes[userID][h] = false
+	}
+	return nil
+}
+
+func (m *mockRecoveryCodeRepo) Use(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	used, ok := m.hashes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.hashes[userID][codeHash] = true
+	return true, nil
+}
+
+func (m *mockRecoveryCodeRepo) DeleteForUser(ctx context.Context, userID uint) error {
+	delete(m.hashes, userID)
+	return nil
+}
+
+// ==========================================
+// MOCK LOGIN ATTEMPT REPOSITORY
+// ==========================================
+
+// mockLoginAttemptRepo adalah implementasi mock dari repository.LoginAttemptRepository.
+type mockLoginAttemptRepo struct {
+	attempts map[string]*entity.LoginAttempt
+}
+
+func newMockLoginAttemptRepo() *mockLoginAttemptRepo {
+	return &mockLoginAttemptRepo{attempts: make(map[string]*entity.LoginAttempt)}
+}
+
+func (m *mockLoginAttemptRepo) Get(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	a, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *mockLoginAttemptRepo) RecordFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (*entity.LoginAttempt, error) {
+	a, ok := m.attempts[key]
+	if !ok || a.ExpiresAt.Before(now) {
+		a = &entity.LoginAttempt{Key: key}
+		m.attempts[key] = a
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	a.ExpiresAt = expiresAt
+	return m.Get(ctx, key)
+}
+
+func (m *mockLoginAttemptRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	if a, ok := m.attempts[key]; ok {
+		a.LockedUntil = &until
+		a.ExpiresAt = until
+	}
+	return nil
+}
+
+func (m *mockLoginAttemptRepo) Reset(ctx context.Context, key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *mockLoginAttemptRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for key, a := range m.attempts {
+		if a.ExpiresAt.Before(now) {
+			delete(m.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ==========================================
+// MOCK NOTIFIER
+// ==========================================
+
+// mockNotifier menyimpan pesan yang dikirim agar bisa diperiksa test.
+type mockNotifier struct {
+	messages []notification.Message
+}
+
+func (m *mockNotifier) Send(msg notification.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last mengembalikan pesan terakhir yang dikirim.
+func (m *mockNotifier) last(t *testing.T) notification.Message {
+	t.Helper()
+	if len(m.messages) == 0 {
+		t.Fatal("expected a message to be sent, got none")
+	}
+	return m.messages[len(m.messages)-1]
+}
+
+// ==========================================
+// MOCK UNIT OF WORK
+// ==========================================
+
+// mockUnitOfWork menjalankan fn dengan repository mock yang sama. Perubahan user
+// di-rollback lewat mockUserRepo.Transaction; repository lain tidak.
+type mockUnitOfWork struct {
+	repos repository.Repositories
+}
+
+func (m *mockUnitOfWork) WithTx(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return m.repos.Users.Transaction(ctx, func(users repository.UserRepository) error {
+		repos := m.repos
+		repos.Users = users
+		return fn(repos)
+	})
+}
+
+// ==========================================
+// TESTS
+// ==========================================
+
+// authFixture mengumpulkan mock yang dipakai AuthService agar bisa diperiksa test.
+// testKeys adalah key set HS256 dengan secret dari testConfig.
+var testKeys = jwtkeys.NewHMACKeySet(testConfig.JWTSecret)
+
+type authFixture struct {
+	svc           service.AuthService
+	users         *mockUserRepo
+	sessions      *mockSessionRepo
+	revocations   *mockRevocationRepo
+	resets        *mockPasswordResetRepo
+	recoveryCodes *mockRecoveryCodeRepo
+	attempts      *mockLoginAttemptRepo
+	notifier      *mockNotifier
+	cfg           *config.Config
+}
+
+func newAuthFixture() *authFixture {
+	return newAuthFixtureWithConfig(testConfig)
+}
+
+func newAuthFixtureWithConfig(cfg *config.Config) *authFixture {
+	sessions := newMockSessionRepo()
+	f := &authFixture{
+		cfg:           cfg,
+		attempts:      newMockLoginAttemptRepo(),
+		users:         newMockRepo(),
+		sessions:      sessions,
+		revocations:   newMockRevocationRepo(sessions),
+		resets:        newMockPasswordResetRepo(),
+		recoveryCodes: newMockRecoveryCodeRepo(),
+		notifier:      &mockNotifier{},
+	}
+	refreshTokens := newMockRefreshTokenRepo()
+	uow := &mockUnitOfWork{repos: repository.Repositories{
+		Users:          f.users,
+		RefreshTokens:  refreshTokens,
+		Sessions:       f.sessions,
+		Revocations:    f.revocations,
+		PasswordResets: f.resets,
+		RecoveryCodes:  f.recoveryCodes,
+	}}
+	f.svc = service.NewAuthService(f.users, refreshTokens, f.sessions, f.revocations, f.resets, f.recoveryCodes, f.attempts, uow, f.notifier, testKeys, f.cfg)
+	return f
+}
+
+func newAuthService() service.AuthService {
+	return newAuthFixture().svc
+}
+
+func newAuthServiceWithRevocations() (service.AuthService, *mockRevocationRepo) {
+	f := newAuthFixture()
+	return f.svc, f.revocations
+}
+
+func registerAlice(t *testing.T, svc service.AuthService) *dto.LoginResponse {
+	t.Helper()
+	resp, err := svc.Register(context.Background(), "", "", dto.RegisterRequest{
+		Name:     "Alice",
+		Email:    "alice@example.com",
+		Password: "password123",
+		Age:      25,
+	})
+	if err != nil {
+		t.Fatalf("Register returned unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestRegister_ReturnsRefreshToken(t *testing.T) {
+	svc := newAuthService()
+
+	resp := registerAlice(t, svc)
+	if resp.Token == "" {
+		t.Error("expected non-empty access token")
+	}
+	if resp.RefreshToken == "" {
+		t.Error("expected non-empty refresh token")
+	}
+	if resp.ExpiresIn != 15*60 {
+		t.Errorf("expected expires_in 900, got %d", resp.ExpiresIn)
+	}
+}
+
+// racingUserRepo mensimulasikan registrasi paralel: FindByEmail tidak melihat user
+// yang dibuat request lain, sehingga hanya unique index yang menolak duplikat.
+type racingUserRepo struct {
+	*mockUserRepo
+}
+
+func (r racingUserRepo) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return nil, errors.New("user not found")
+}
+
+func (r racingUserRepo) Create(ctx context.Context, user *entity.User) error {
+	if _, err := r.mockUserRepo.FindByEmail(ctx, user.Email); err == nil {
+		return repository.ErrDuplicateEmail
+	}
+	return r.mockUserRepo.Create(ctx, user)
+}
+
+func (r racingUserRepo) Transaction(ctx context.Context, fn func(repository.UserRepository) error) error {
+	return r.mockUserRepo.Transaction(ctx, func(repository.UserRepository) error {
+		return fn(r)
+	})
+}
+
+// failingRefreshTokenRepo menolak setiap refresh token baru.
+type failingRefreshTokenRepo struct {
+	*mockRefreshTokenRepo
+}
+
+func (r failingRefreshTokenRepo) Create(ctx context.Context, token *entity.RefreshToken) error {
+	return errors.New("disk full")
+}
+
+func TestRegister_ConcurrentDuplicateEmail(t *testing.T) {
+	users := racingUserRepo{newMockRepo()}
+	refreshTokens, sessions := newMockRefreshTokenRepo(), newMockSessionRepo()
+	uow := &mockUnitOfWork{repos: repository.Repositories{Users: users, RefreshTokens: refreshTokens, Sessions: sessions}}
+	svc := service.NewAuthService(users, refreshTokens, sessions, nil, nil, nil, newMockLoginAttemptRepo(), uow, &mockNotifier{}, testKeys, testConfig)
+
+	registerAlice(t, svc)
+	_, err := svc.Register(context.Background(), "", "", dto.RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "password123", Age: 25})
+	if !errors.Is(err, service.ErrEmailRegistered) {
+		t.Errorf("expected ErrEmailRegistered from unique index, got %v", err)
+	}
+}
+
+func TestRegister_RollsBackWhenTokensFail(t *testing.T) {
+	users, sessions := newMockRepo(), newMockSessionRepo()
+	refreshTokens := failingRefreshTokenRepo{newMockRefreshTokenRepo()}
+	uow := &mockUnitOfWork{repos: repository.Repositories{Users: users, RefreshTokens: refreshTokens, Sessions: sessions}}
+	svc := service.NewAuthService(users, refreshTokens, sessions, nil, nil, nil, newMockLoginAttemptRepo(), uow, &mockNotifier{}, testKeys, testConfig)
+
+	_, err := svc.Register(context.Background(), "", "", dto.RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "password123", Age: 25})
+	if err == nil {
+		t.Fatal("expected Register to fail when the refresh token cannot be stored")
+	}
+	// Email tidak tertahan oleh akun setengah jadi
+	if _, err := users.FindByEmail(context.Background(), "alice@example.com"); err == nil {
+		t.Error("expected user creation to be rolled back")
+	}
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	svc := newAuthService()
+	first := registerAlice(t, svc)
+
+	second, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh returned unexpected error: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("expected a new refresh token after rotation")
+	}
+	if second.User.Email != "alice@example.com" {
+		t.Errorf("expected email 'alice@example.com', got '%s'", second.User.Email)
+	}
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	svc := newAuthService()
+	first := registerAlice(t, svc)
+
+	second, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh returned unexpected error: %v", err)
+	}
+
+	// Token pertama dipakai lagi -> reuse terdeteksi
+	if _, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: first.RefreshToken}); err == nil {
+		t.Fatal("expected error when reusing a rotated refresh token, got nil")
+	}
+
+	// Token hasil rotasi ikut dicabut
+	if _, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: second.RefreshToken}); err == nil {
+		t.Error("expected error for token in revoked family, got nil")
+	}
+}
+
+func TestRefresh_InvalidToken(t *testing.T) {
+	svc := newAuthService()
+
+	_, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: "does-not-exist"})
+	if err == nil {
+		t.Error("expected error for unknown refresh token, got nil")
+	}
+}
+
+func TestLogout_RevokesAccessAndRefreshToken(t *testing.T) {
+	svc, revocations := newAuthServiceWithRevocations()
+	resp := registerAlice(t, svc)
+
+	claims, err := middleware.ParseToken(context.Background(), resp.Token, testKeys, revocations)
+	if err != nil {
+		t.Fatalf("ParseToken returned unexpected error: %v", err)
+	}
+
+	err = svc.Logout(context.Background(), claims.UserID, claims.ID, claims.ExpiresAt.Time, dto.LogoutRequest{RefreshToken: resp.RefreshToken})
+	if err != nil {
+		t.Fatalf("Logout returned unexpected error: %v", err)
+	}
+
+	if _, err := middleware.ParseToken(context.Background(), resp.Token, testKeys, revocations); err == nil {
+		t.Error("expected revoked access token to be rejected, got nil")
+	}
+	if _, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: resp.RefreshToken}); err == nil {
+		t.Error("expected revoked refresh token to be rejected, got nil")
+	}
+}
+
+func TestLogoutAll_RevokesEarlierTokens(t *testing.T) {
+	svc, revocations := newAuthServiceWithRevocations()
+	resp := registerAlice(t, svc)
+
+	if err := svc.LogoutAll(context.Background(), resp.User.ID, dto.LogoutAllRequest{}); err != nil {
+		t.Fatalf("LogoutAll returned unexpected error: %v", err)
+	}
+
+	if _, err := middleware.ParseToken(context.Background(), resp.Token, testKeys, revocations); err == nil {
+		t.Error("expected access token issued before logout-all to be rejected, got nil")
+	}
+	if _, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: resp.RefreshToken}); err == nil {
+		t.Error("expected refresh token issued before logout-all to be rejected, got nil")
+	}
+}
+
+func TestLogoutAll_RejectsFutureCutoff(t *testing.T) {
+	svc := newAuthService()
+	resp := registerAlice(t, svc)
+
+	future := time.Now().Add(time.Hour)
+	if err := svc.LogoutAll(context.Background(), resp.User.ID, dto.LogoutAllRequest{Before: &future}); err == nil {
+		t.Error("expected error for cutoff in the future, got nil")
+	}
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	svc := newAuthService()
+	resp := registerAlice(t, svc)
+
+	_, err := svc.ChangePassword(context.Background(), resp.User.ID, 0, dto.ChangePasswordRequest{
+		CurrentPassword: "wrong-password",
+		NewPassword:     "newpassword123",
+	})
+	if err == nil {
+		t.Error("expected error for wrong current password, got nil")
+	}
+}
+
+func TestChangePassword_RevokesOtherSessions(t *testing.T) {
+	svc, revocations := newAuthServiceWithRevocations()
+	old := registerAlice(t, svc)
+
+	// Pastikan token lama diterbitkan di detik yang berbeda dari cutoff
+	time.Sleep(1100 * time.Millisecond)
+
+	fresh, err := svc.ChangePassword(context.Background(), old.User.ID, 0, dto.ChangePasswordRequest{
+		CurrentPassword: "password123",
+		NewPassword:     "newpassword123",
+	})
+	if err != nil {
+		t.Fatalf("ChangePassword returned unexpected error: %v", err)
+	}
+
+	if _, err := middleware.ParseToken(context.Background(), old.Token, testKeys, revocations); err == nil {
+		t.Error("expected access token issued before password change to be rejected, got nil")
+	}
+	if _, err := svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: old.RefreshToken}); err == nil {
+		t.Error("expected refresh token issued before password change to be rejected, got nil")
+	}
+	if _, err := middleware.ParseToken(context.Background(), fresh.Token, testKeys, revocations); err != nil {
+		t.Errorf("expected new access token to be valid, got %v", err)
+	}
+
+	if _, err := svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "newpassword123"}); err != nil {
+		t.Errorf("expected login with new password to succeed, got %v", err)
+	}
+}
+
+func TestResetPassword_ImmediateLoginKeepsToken(t *testing.T) {
+	f := newAuthFixture()
+	registerAlice(t, f.svc)
+
+	if err := f.svc.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword returned unexpected error: %v", err)
+	}
+	token := resetTokenFromMessage(t, f.notifier.last(t))
+	if err := f.svc.ResetPassword(context.Background(), dto.ResetPasswordRequest{Token: token, NewPassword: "newpassword123"}); err != nil {
+		t.Fatalf("ResetPassword returned unexpected error: %v", err)
+	}
+
+	// Login di detik yang sama dengan reset tidak boleh ikut tercabut
+	resp, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "newpassword123"})
+	if err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+	if _, err := middleware.ParseToken(context.Background(), resp.Token, testKeys, f.revocations); err != nil {
+		t.Errorf("expected token issued right after reset to be valid, got %v", err)
+	}
+}
+
+func TestForgotPassword_UnknownEmailSendsNothing(t *testing.T) {
+	f := newAuthFixture()
+	registerAlice(t, f.svc)
+	f.notifier.messages = nil
+
+	if err := f.svc.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: "nobody@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword returned unexpected error: %v", err)
+	}
+	if len(f.notifier.messages) != 0 {
+		t.Errorf("expected no message for unknown email, got %d", len(f.notifier.messages))
+	}
+}
+
+// resetTokenFromMessage mengambil token reset dari isi pesan notifier.
+func resetTokenFromMessage(t *testing.T, msg notification.Message) string {
+	t.Helper()
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if len(line) == 43 && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+	t.Fatalf("reset token not found in message: %q", msg.Body)
+	return ""
+}
+
+func TestResetPassword_SingleUse(t *testing.T) {
+	f := newAuthFixture()
+	registerAlice(t, f.svc)
+
+	if err := f.svc.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword returned unexpected error: %v", err)
+	}
+	token := resetTokenFromMessage(t, f.notifier.last(t))
+
+	req := dto.ResetPasswordRequest{Token: token, NewPassword: "newpassword123"}
+	if err := f.svc.ResetPassword(context.Background(), req); err != nil {
+		t.Fatalf("ResetPassword returned unexpected error: %v", err)
+	}
+	if err := f.svc.ResetPassword(context.Background(), req); err == nil {
+		t.Error("expected error when reusing reset token, got nil")
+	}
+
+	if _, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "newpassword123"}); err != nil {
+		t.Errorf("expected login with new password to succeed, got %v", err)
+	}
+}
+
+func TestResetPassword_ExpiredToken(t *testing.T) {
+	f := newAuthFixture()
+	registerAlice(t, f.svc)
+
+	if err := f.svc.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword returned unexpected error: %v", err)
+	}
+	for _, stored := range f.resets.tokens {
+		stored.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+
+	token := resetTokenFromMessage(t, f.notifier.last(t))
+	if err := f.svc.ResetPassword(context.Background(), dto.ResetPasswordRequest{Token: token, NewPassword: "newpassword123"}); err == nil {
+		t.Error("expected error for expired reset token, got nil")
+	}
+}
+
+// verificationTokenFromMessage mengambil token dari link verifikasi di isi pesan.
+func verificationTokenFromMessage(t *testing.T, msg notification.Message) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(msg.Body, "/auth/verify?token=")
+	if !ok {
+		t.Fatalf("verification link not found in message: %q", msg.Body)
+	}
+	token, _, _ := strings.Cut(rest, "\n")
+	unescaped, err := url.QueryUnescape(token)
+	if err != nil {
+		t.Fatalf("invalid token in link: %v", err)
+	}
+	return unescaped
+}
+
+func TestRegister_SendsVerificationEmail(t *testing.T) {
+	f := newAuthFixture()
+	resp := registerAlice(t, f.svc)
+
+	if resp.User.EmailVerified {
+		t.Error("expected new user to be unverified")
+	}
+	msg := f.notifier.last(t)
+	if msg.To != "alice@example.com" {
+		t.Errorf("expected message to alice@example.com, got %s", msg.To)
+	}
+
+	user, err := f.svc.VerifyEmail(context.Background(), verificationTokenFromMessage(t, msg))
+	if err != nil {
+		t.Fatalf("VerifyEmail returned unexpected error: %v", err)
+	}
+	if !user.EmailVerified {
+		t.Error("expected user to be verified")
+	}
+
+	// Token berikutnya membawa status terverifikasi
+	login, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+	claims, err := middleware.ParseToken(context.Background(), login.Token, testKeys, f.revocations)
+	if err != nil {
+		t.Fatalf("ParseToken returned unexpected error: %v", err)
+	}
+	if !claims.EmailVerified {
+		t.Error("expected email_verified claim to be true after verification")
+	}
+
+	if err := f.svc.ResendVerification(context.Background(), user.ID); err == nil {
+		t.Error("expected error when resending to a verified email, got nil")
+	}
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	f := newAuthFixture()
+	registerAlice(t, f.svc)
+	token := verificationTokenFromMessage(t, f.notifier.last(t))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not-a-token"},
+		{"tampered signature", token + "x"},
+		{"tampered payload", "x" + token},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.svc.VerifyEmail(context.Background(), tt.token); !errors.Is(err, service.ErrInvalidVerificationToken) {
+				t.Errorf("expected ErrInvalidVerificationToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail_TokenForOldEmailRejected(t *testing.T) {
+	f := newAuthFixture()
+	resp := registerAlice(t, f.svc)
+	token := verificationTokenFromMessage(t, f.notifier.last(t))
+
+	userService := service.NewUserService(f.users, testConfig)
+	if _, err := userService.PatchUser(context.Background(), resp.User.ID, 0, dto.PatchUserRequest{Email: dto.OptionalOf("alice@new.com")}); err != nil {
+		t.Fatalf("PatchUser returned unexpected error: %v", err)
+	}
+
+	if _, err := f.svc.VerifyEmail(context.Background(), token); !errors.Is(err, service.ErrInvalidVerificationToken) {
+		t.Errorf("expected ErrInvalidVerificationToken for token issued to old email, got %v", err)
+	}
+}
+
+// enrollMFA mengaktifkan 2FA untuk user dan mengembalikan secret serta
+// time step kode yang dipakai saat konfirmasi.
+func enrollMFA(t *testing.T, svc service.AuthService, userID uint) (string, int64, *dto.MFAConfirmResponse) {
+	t.Helper()
+	enroll, err := svc.EnrollMFA(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("EnrollMFA returned unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(enroll.ProvisioningURI, "otpauth://totp/") {
+		t.Errorf("unexpected provisioning URI %s", enroll.ProvisioningURI)
+	}
+
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(enroll.Secret, step)
+	confirm, err := svc.ConfirmMFA(context.Background(), userID, 0, dto.MFACodeRequest{Code: code})
+	if err != nil {
+		t.Fatalf("ConfirmMFA returned unexpected error: %v", err)
+	}
+	return enroll.Secret, step, confirm
+}
+
+func TestConfirmMFA_InvalidCode(t *testing.T) {
+	svc := newAuthService()
+	resp := registerAlice(t, svc)
+
+	if _, err := svc.ConfirmMFA(context.Background(), resp.User.ID, 0, dto.MFACodeRequest{Code: "123456"}); err == nil {
+		t.Error("expected error when confirming before enrollment, got nil")
+	}
+	if _, err := svc.EnrollMFA(context.Background(), resp.User.ID); err != nil {
+		t.Fatalf("EnrollMFA returned unexpected error: %v", err)
+	}
+	if _, err := svc.ConfirmMFA(context.Background(), resp.User.ID, 0, dto.MFACodeRequest{Code: "000000x"}); err == nil {
+		t.Error("expected error for invalid code, got nil")
+	}
+}
+
+func TestLogin_WithMFA(t *testing.T) {
+	f := newAuthFixture()
+	resp := registerAlice(t, f.svc)
+	secret, step, confirm := enrollMFA(t, f.svc, resp.User.ID)
+
+	if len(confirm.RecoveryCodes) != 10 {
+		t.Errorf("expected 10 recovery codes, got %d", len(confirm.RecoveryCodes))
+	}
+	if confirm.Token == "" || !confirm.User.MFAEnabled {
+		t.Error("expected confirm to return new tokens for an MFA-enabled user")
+	}
+
+	login, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+	if !login.MFARequired || login.MFAToken == "" || login.Token != "" {
+		t.Fatalf("expected only an mfa token from login, got %+v", login)
+	}
+
+	// Token "mfa pending" tidak boleh diterima sebagai access token
+	if _, err := middleware.ParseToken(context.Background(), login.MFAToken, testKeys, f.revocations); err == nil {
+		t.Error("expected mfa token to be rejected as access token, got nil")
+	}
+
+	// Kode dari step yang sudah dipakai saat confirm ditolak (replay)
+	used, _ := totp.Code(secret, step)
+	if _, err := f.svc.VerifyMFA(context.Background(), "", "", dto.MFAVerifyRequest{MFAToken: login.MFAToken, Code: used}); err == nil {
+		t.Error("expected replayed code to be rejected, got nil")
+	}
+
+	next, _ := totp.Code(secret, step+1)
+	verified, err := f.svc.VerifyMFA(context.Background(), "", "", dto.MFAVerifyRequest{MFAToken: login.MFAToken, Code: next})
+	if err != nil {
+		t.Fatalf("VerifyMFA returned unexpected error: %v", err)
+	}
+
+	claims, err := middleware.ParseToken(context.Background(), verified.Token, testKeys, f.revocations)
+	if err != nil {
+		t.Fatalf("ParseToken returned unexpected error: %v", err)
+	}
+	if !claims.MFA {
+		t.Error("expected mfa claim to be true after VerifyMFA")
+	}
+}
+
+func TestVerifyMFA_RecoveryCodeSingleUse(t *testing.T) {
+	svc := newAuthService()
+	resp := registerAlice(t, svc)
+	_, _, confirm := enrollMFA(t, svc, resp.User.ID)
+
+	login, err := svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+
+	// Recovery code diterima tanpa memperhatikan huruf besar/kecil
+	req := dto.MFAVerifyRequest{MFAToken: login.MFAToken, Code: strings.ToUpper(confirm.RecoveryCodes[0])}
+	if _, err := svc.VerifyMFA(context.Background(), "", "", req); err != nil {
+		t.Fatalf("VerifyMFA returned unexpected error: %v", err)
+	}
+	if _, err := svc.VerifyMFA(context.Background(), "", "", req); err == nil {
+		t.Error("expected used recovery code to be rejected, got nil")
+	}
+}
+
+// staleUserRepo mensimulasikan verifikasi 2FA paralel: FindByID selalu mengembalikan
+// salinan user seperti saat pertama dibaca, sebelum request lain menyimpan perubahan.
+type staleUserRepo struct {
+	*mockUserRepo
+	snapshot map[uint]entity.User
+}
+
+func (r staleUserRepo) FindByID(ctx context.Context, id uint) (*entity.User, error) {
+	if _, ok := r.snapshot[id]; !ok {
+		u, err := r.mockUserRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		r.snapshot[id] = *u
+	}
+	u := r.snapshot[id]
+	return &u, nil
+}
+
+func TestVerifyMFA_ConcurrentSameCode(t *testing.T) {
+	f := newAuthFixture()
+	resp := registerAlice(t, f.svc)
+	secret, step, _ := enrollMFA(t, f.svc, resp.User.ID)
+
+	login, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+
+	users := staleUserRepo{mockUserRepo: f.users, snapshot: map[uint]entity.User{}}
+	refreshTokens := newMockRefreshTokenRepo()
+	uow := &mockUnitOfWork{repos: repository.Repositories{Users: users, RefreshTokens: refreshTokens, Sessions: f.sessions, Revocations: f.revocations}}
+	svc := service.NewAuthService(users, refreshTokens, f.sessions, f.revocations, f.resets, f.recoveryCodes, f.attempts, uow, f.notifier, testKeys, f.cfg)
+
+	// Kedua request membaca user sebelum salah satunya menyimpan time step
+	code, _ := totp.Code(secret, step+1)
+	req := dto.MFAVerifyRequest{MFAToken: login.MFAToken, Code: code}
+	if _, err := svc.VerifyMFA(context.Background(), "", "", req); err != nil {
+		t.Fatalf("VerifyMFA returned unexpected error: %v", err)
+	}
+	if _, err := svc.VerifyMFA(context.Background(), "", "", req); !errors.Is(err, apperror.ErrUnauthorized) {
+		t.Errorf("expected the second request with the same code to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyMFA_InvalidToken(t *testing.T) {
+	svc := newAuthService()
+
+	if _, err := svc.VerifyMFA(context.Background(), "", "", dto.MFAVerifyRequest{MFAToken: "garbage", Code: "123456"}); err == nil {
+		t.Error("expected error for invalid mfa token, got nil")
+	}
+}
+
+// lockoutConfig adalah testConfig dengan lockout login diaktifkan.
+func lockoutConfig(maxAttempts, maxPerIP, backoffSeconds int) *config.Config {
+	cfg := *testConfig
+	cfg.LoginMaxAttempts = maxAttempts
+	cfg.LoginMaxAttemptsPerIP = maxPerIP
+	cfg.LoginLockoutMinutes = 15
+	cfg.LoginBackoffBaseSeconds = backoffSeconds
+	return &cfg
+}
+
+func TestLogin_LockoutAfterMaxAttempts(t *testing.T) {
+	f := newAuthFixtureWithConfig(lockoutConfig(3, 0, 0))
+	resp := registerAlice(t, f.svc)
+
+	wrong := dto.LoginRequest{Email: "alice@example.com", Password: "wrong-password"}
+	for i := 0; i < 3; i++ {
+		if _, err := f.svc.Login(context.Background(), "10.0.0.1", "", wrong); err == nil {
+			t.Fatal("expected error for wrong password, got nil")
+		}
+	}
+
+	// Password benar pun ditolak selama akun dikunci, dari IP mana pun
+	// dan tanpa memperhatikan huruf besar/kecil email
+	_, err := f.svc.Login(context.Background(), "10.0.0.2", "", dto.LoginRequest{Email: "ALICE@example.com", Password: "password123"})
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected LoginThrottledError, got %v", err)
+	}
+	if throttled.RetryAfter <= 14*time.Minute {
+		t.Errorf("expected retry after ~15m, got %v", throttled.RetryAfter)
+	}
+
+	if err := f.svc.UnlockAccount(context.Background(), 1, resp.User.ID); err != nil {
+		t.Fatalf("UnlockAccount returned unexpected error: %v", err)
+	}
+	if _, err := f.svc.Login(context.Background(), "10.0.0.2", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"}); err != nil {
+		t.Errorf("expected login to succeed after unlock, got %v", err)
+	}
+}
+
+func TestLogin_ExponentialBackoff(t *testing.T) {
+	f := newAuthFixtureWithConfig(lockoutConfig(5, 0, 60))
+	registerAlice(t, f.svc)
+
+	wrong := dto.LoginRequest{Email: "alice@example.com", Password: "wrong-password"}
+	if _, err := f.svc.Login(context.Background(), "", "", wrong); err == nil {
+		t.Fatal("expected error for wrong password, got nil")
+	}
+
+	_, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected LoginThrottledError during backoff, got %v", err)
+	}
+	if throttled.RetryAfter > time.Minute || throttled.RetryAfterSeconds() < 59 {
+		t.Errorf("expected retry after ~60s, got %v", throttled.RetryAfter)
+	}
+
+	// Percobaan yang ditolak karena backoff tidak menambah hitungan
+	if got := f.attempts.attempts["account:alice@example.com"].Failures; got != 1 {
+		t.Errorf("expected 1 recorded failure, got %d", got)
+	}
+}
+
+func TestLogin_IPLockout(t *testing.T) {
+	f := newAuthFixtureWithConfig(lockoutConfig(0, 2, 0))
+	registerAlice(t, f.svc)
+
+	for _, email := range []string{"bob@example.com", "carol@example.com"} {
+		if _, err := f.svc.Login(context.Background(), "10.0.0.1", "", dto.LoginRequest{Email: email, Password: "guess123"}); err == nil {
+			t.Fatal("expected error for unknown email, got nil")
+		}
+	}
+
+	correct := dto.LoginRequest{Email: "alice@example.com", Password: "password123"}
+	var throttled *service.LoginThrottledError
+	if _, err := f.svc.Login(context.Background(), "10.0.0.1", "", correct); !errors.As(err, &throttled) {
+		t.Errorf("expected LoginThrottledError from locked IP, got %v", err)
+	}
+	if _, err := f.svc.Login(context.Background(), "10.0.0.2", "", correct); err != nil {
+		t.Errorf("expected login from another IP to succeed, got %v", err)
+	}
+}
+
+func TestLogin_SuccessResetsFailures(t *testing.T) {
+	f := newAuthFixtureWithConfig(lockoutConfig(3, 0, 0))
+	registerAlice(t, f.svc)
+
+	f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "wrong-password"})
+	if _, err := f.svc.Login(context.Background(), "", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"}); err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+	if _, ok := f.attempts.attempts["account:alice@example.com"]; ok {
+		t.Error("expected failures to be reset after successful login")
+	}
+}
+
+func TestLogin_CanceledRequestIsNotAFailure(t *testing.T) {
+	f := newAuthFixtureWithConfig(lockoutConfig(1, 1, 0))
+	registerAlice(t, f.svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := f.svc.Login(ctx, "10.0.0.1", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(f.attempts.attempts) != 0 {
+		t.Errorf("expected canceled login not to be recorded, got %d attempts", len(f.attempts.attempts))
+	}
+
+	if _, err := f.svc.Login(context.Background(), "10.0.0.1", "", dto.LoginRequest{Email: "alice@example.com", Password: "password123"}); err != nil {
+		t.Errorf("expected login to succeed after canceled request, got %v", err)
+	}
+}
+
+// ==========================================
+// TESTS: Sessions
+// ==========================================
+
+func TestSessions_LoginRecordsDevice(t *testing.T) {
+	f := newAuthFixture()
+	registerAlice(t, f.svc)
+
+	login, err := f.svc.Login(context.Background(), "10.0.0.7", "curl/8.5.0", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login returned unexpected error: %v", err)
+	}
+	claims, err := middleware.ParseToken(context.Background(), login.Token, testKeys, f.revocations)
+	if err != nil || claims.SessionID == 0 {
+		t.Fatalf("expected token bound to a session, got claims %+v err %v", claims, err)
+	}
+
+	sessions, err := f.svc.ListSessions(context.Background(), login.User.ID, claims.SessionID)
+	if err != nil {
+		t.Fatalf("ListSessions returned unexpected error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected register + login sessions, got %d", len(sessions))
+	}
+	current := sessions[1]
+	if !current.Current || current.IPAddress != "10.0.0.7" || current.UserAgent != "curl/8.5.0" {
+		t.Errorf("unexpected current session %+v", current)
+	}
+	if sessions[0].Current {
+		t.Error("expected only one session to be marked current")
+	}
+}
+
+func TestSessions_RefreshKeepsSession(t *testing.T) {
+	f := newAuthFixture()
+	registered := registerAlice(t, f.svc)
+
+	refreshed, err := f.svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: registered.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh returned unexpected error: %v", err)
+	}
+
+	before, _ := middleware.ParseToken(context.Background(), registered.Token, testKeys, f.revocations)
+	after, _ := middleware.ParseToken(context.Background(), refreshed.Token, testKeys, f.revocations)
+	if before.SessionID != after.SessionID {
+		t.Errorf("expected refresh to keep session %d, got %d", before.SessionID, after.SessionID)
+	}
+	if len(f.sessions.sessions) != 1 {
+		t.Errorf("expected a single session, got %d", len(f.sessions.sessions))
+	}
+}
+
+func TestSessions_RevokeRejectsTokens(t *testing.T) {
+	f := newAuthFixture()
+	registered := registerAlice(t, f.svc)
+	phone, _ := f.svc.Login(context.Background(), "", "phone", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	claims, _ := middleware.ParseToken(context.Background(), phone.Token, testKeys, f.revocations)
+
+	if err := f.svc.RevokeSession(context.Background(), registered.User.ID+1, claims.SessionID); err == nil {
+		t.Error("expected other users to be unable to revoke the session")
+	}
+	if err := f.svc.RevokeSession(context.Background(), registered.User.ID, claims.SessionID); err != nil {
+		t.Fatalf("RevokeSession returned unexpected error: %v", err)
+	}
+
+	if _, err := middleware.ParseToken(context.Background(), phone.Token, testKeys, f.revocations); err == nil {
+		t.Error("expected access token of revoked session to be rejected")
+	}
+	if _, err := f.svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: phone.RefreshToken}); err == nil {
+		t.Error("expected refresh token of revoked session to be rejected")
+	}
+
+	// Session lain tidak terpengaruh
+	if _, err := middleware.ParseToken(context.Background(), registered.Token, testKeys, f.revocations); err != nil {
+		t.Errorf("expected other session to stay valid, got %v", err)
+	}
+	sessions, _ := f.svc.ListSessions(context.Background(), registered.User.ID, 0)
+	if len(sessions) != 1 {
+		t.Errorf("expected revoked session to be hidden, got %d sessions", len(sessions))
+	}
+}
+
+func TestSessions_ChangePasswordKeepsCurrentSession(t *testing.T) {
+	f := newAuthFixture()
+	registered := registerAlice(t, f.svc)
+	other, _ := f.svc.Login(context.Background(), "", "laptop", dto.LoginRequest{Email: "alice@example.com", Password: "password123"})
+	current, _ := middleware.ParseToken(context.Background(), registered.Token, testKeys, f.revocations)
+
+	fresh, err := f.svc.ChangePassword(context.Background(), registered.User.ID, current.SessionID, dto.ChangePasswordRequest{
+		CurrentPassword: "password123",
+		NewPassword:     "newpassword123",
+	})
+	if err != nil {
+		t.Fatalf("ChangePassword returned unexpected error: %v", err)
+	}
+
+	claims, err := middleware.ParseToken(context.Background(), fresh.Token, testKeys, f.revocations)
+	if err != nil || claims.SessionID != current.SessionID {
+		t.Errorf("expected new token in session %d, got %+v (err %v)", current.SessionID, claims, err)
+	}
+	if _, err := f.svc.Refresh(context.Background(), dto.RefreshTokenRequest{RefreshToken: other.RefreshToken}); err == nil {
+		t.Error("expected other session to be revoked")
+	}
+	sessions, _ := f.svc.ListSessions(context.Background(), registered.User.ID, current.SessionID)
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("expected only the current session to remain, got %+v", sessions)
+	}
+}
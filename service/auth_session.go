@@ -0,0 +1,108 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"context"
+	"time"
+)
+
+// maxUserAgentLength membatasi panjang user-agent yang disimpan per session.
+const maxUserAgentLength = 255
+
+// newSession menyiapkan session baru (belum tersimpan) untuk login dari perangkat
+// dengan IP dan user-agent tertentu. Session disimpan oleh issueTokens.
+func newSession(clientIP, userAgent string) *entity.Session {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return &entity.Session{IPAddress: clientIP, UserAgent: userAgent}
+}
+
+// currentSession mengambil session aktif milik user yang sedang dipakai.
+// Token tanpa session (misalnya dari OIDC) mendapat session baru.
+func (s *authServiceImpl) currentSession(ctx context.Context, userID, sessionID uint) *entity.Session {
+	if sessionID != 0 {
+		session, err := s.sessionRepo.FindByID(ctx, sessionID)
+		if err == nil && session.UserID == userID && session.IsActive(time.Now()) {
+			return session
+		}
+	}
+	return newSession("", "")
+}
+
+// sessionForFamily mengambil session pemilik family refresh token.
+// Family yang dibuat sebelum ada tabel session dipindahkan ke session baru.
+func (s *authServiceImpl) sessionForFamily(ctx context.Context, userID uint, familyID string) (*entity.Session, error) {
+	session, err := s.sessionRepo.FindByFamily(ctx, familyID)
+	if err != nil {
+		return &entity.Session{FamilyID: familyID}, nil
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return nil, apperror.Unauthorized("refresh token has been revoked")
+	}
+	return session, nil
+}
+
+// revokeFamily mencabut seluruh family refresh token beserta session-nya,
+// sehingga access token milik session tersebut juga langsung ditolak.
+func (s *authServiceImpl) revokeFamily(ctx context.Context, familyID string) error {
+	return s.inTx(ctx, func(tx *authServiceImpl) error {
+		if err := tx.refreshTokenRepo.RevokeFamily(ctx, familyID); err != nil {
+			return err
+		}
+		return tx.sessionRepo.RevokeByFamily(ctx, familyID)
+	})
+}
+
+// ListSessions mengambil session aktif user. Session yang sedang dipakai
+// pemanggil (currentSessionID) ditandai Current.
+func (s *authServiceImpl) ListSessions(ctx context.Context, userID, currentSessionID uint) ([]dto.SessionResponse, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		item := toSessionResponse(&sessions[i])
+		item.Current = sessions[i].ID == currentSessionID
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// RevokeSession mencabut session milik user: refresh token-nya tidak bisa
+// dipakai lagi dan access token yang masih beredar langsung ditolak.
+func (s *authServiceImpl) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return apperror.NotFound("session not found")
+	}
+
+	err = s.inTx(ctx, func(tx *authServiceImpl) error {
+		if err := tx.sessionRepo.Revoke(ctx, session.ID); err != nil {
+			return err
+		}
+		return tx.refreshTokenRepo.RevokeFamily(ctx, session.FamilyID)
+	})
+	if err != nil {
+		return err
+	}
+
+	logSecurityEvent("session_revoked", "user_id=%d session_id=%d", userID, session.ID)
+	return nil
+}
+
+// toSessionResponse mengkonversi entity.Session ke DTO.
+func toSessionResponse(session *entity.Session) dto.SessionResponse {
+	return dto.SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"api-user-crud-go/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Arah cursor relatif terhadap posisi yang disimpan.
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// cursorPayload adalah isi cursor sebelum di-encode.
+// Field dibuat pendek karena cursor dikirim bolak-balik di URL.
+type cursorPayload struct {
+	CreatedAt int64  `json:"t"` // unix nano
+	ID        uint   `json:"i"`
+	Direction string `json:"d"`
+}
+
+// encodeCursor membuat cursor opaque "payload.signature" untuk posisi sebuah baris.
+// Signature HMAC mencegah client merakit cursor sendiri.
+func encodeCursor(secret []byte, key repository.UserKeyset, direction string) (string, error) {
+	raw, err := json.Marshal(cursorPayload{
+		CreatedAt: key.CreatedAt.UnixNano(),
+		ID:        key.ID,
+		Direction: direction,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + signCursor(secret, payload), nil
+}
+
+// decodeCursor memverifikasi signature cursor dan mengembalikan posisinya.
+func decodeCursor(secret []byte, cursor string) (repository.UserKeyset, string, error) {
+	payload, signature, ok := strings.Cut(cursor, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signCursor(secret, payload))) {
+		return repository.UserKeyset{}, "", fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return repository.UserKeyset{}, "", fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+	}
+
+	var p cursorPayload
+	if err := json.Unmarshal(raw, &p); err != nil || (p.Direction != cursorNext && p.Direction != cursorPrev) {
+		return repository.UserKeyset{}, "", fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+	}
+
+	return repository.UserKeyset{CreatedAt: time.Unix(0, p.CreatedAt), ID: p.ID}, p.Direction, nil
+}
+
+// signCursor menghitung HMAC-SHA256 dari payload cursor.
+func signCursor(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatETag membentuk entity tag (strong) dari versi user, misalnya "3".
+// Nilai yang sama dipakai untuk header ETag REST dan field etag gRPC.
+func FormatETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// ParseETag mengambil versi dari header If-Match atau field etag gRPC.
+// String kosong dan "*" berarti tanpa syarat versi (0). Tag weak, daftar
+// beberapa tag, atau tag yang bukan buatan server tidak akan pernah cocok,
+// sehingga dikembalikan sebagai ErrVersionConflict.
+func ParseETag(tag string) (uint, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || tag == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(tag, `"`), 10, 32)
+	if err != nil || version == 0 {
+		return 0, ErrVersionConflict
+	}
+	return uint(version), nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// LoginThrottledError dikembalikan saat login ditolak karena terlalu banyak
+// percobaan gagal (backoff atau lockout). Password tidak diperiksa sama sekali.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", retryAfterSeconds(e.RetryAfter))
+}
+
+// RetryAfterSeconds mengembalikan RetryAfter dalam detik (dibulatkan ke atas),
+// untuk header Retry-After.
+func (e *LoginThrottledError) RetryAfterSeconds() int64 {
+	return retryAfterSeconds(e.RetryAfter)
+}
+
+func retryAfterSeconds(d time.Duration) int64 {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// accountAttemptKey dan ipAttemptKey membuat kunci pencatatan login gagal.
+// Akun dicatat per email (bukan per user ID) agar email yang tidak terdaftar
+// diperlakukan sama dan tidak bisa dipakai untuk menebak email.
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// checkLoginAllowed menolak percobaan login jika akun atau IP sedang dikunci,
+// atau jika akun masih dalam masa backoff setelah login gagal terakhir.
+func (s *authServiceImpl) checkLoginAllowed(ctx context.Context, email, clientIP string) error {
+	now := time.Now()
+
+	account, err := s.attemptRepo.Get(ctx, accountAttemptKey(email))
+	if err != nil {
+		return err
+	}
+	if account != nil && now.Before(account.ExpiresAt) {
+		if account.LockedUntil != nil && now.Before(*account.LockedUntil) {
+			return &LoginThrottledError{RetryAfter: account.LockedUntil.Sub(now)}
+		}
+
+		// Exponential backoff: base, 2x base, 4x base, ... sejak kegagalan terakhir
+		if next := account.LastFailureAt.Add(s.loginBackoff(account.Failures)); now.Before(next) {
+			return &LoginThrottledError{RetryAfter: next.Sub(now)}
+		}
+	}
+
+	if clientIP == "" {
+		return nil
+	}
+	ip, err := s.attemptRepo.Get(ctx, ipAttemptKey(clientIP))
+	if err != nil {
+		return err
+	}
+	if ip != nil && ip.LockedUntil != nil && now.Before(*ip.LockedUntil) {
+		return &LoginThrottledError{RetryAfter: ip.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+// recordLoginFailure mencatat login gagal untuk akun dan IP, lalu mengunci
+// yang sudah mencapai batas.
+func (s *authServiceImpl) recordLoginFailure(ctx context.Context, email, clientIP string) error {
+
+	err := s.recordAttemptFailure(ctx, accountAttemptKey(email), s.cfg.LoginMaxAttempts, "account_locked", email, clientIP)
+	if err != nil || clientIP == "" {
+		return err
+	}
+	return s.recordAttemptFailure(ctx, ipAttemptKey(clientIP), s.cfg.LoginMaxAttemptsPerIP, "ip_locked", email, clientIP)
+}
+
+// recordAttemptFailure mencatat satu kegagalan untuk sebuah kunci.
+// maxAttempts <= 0 berarti lockout untuk kunci tersebut dinonaktifkan.
+func (s *authServiceImpl) recordAttemptFailure(ctx context.Context, key string, maxAttempts int, event, email, clientIP string) error {
+	now := time.Now()
+	lockout := time.Duration(s.cfg.LoginLockoutMinutes) * time.Minute
+
+	attempt, err := s.attemptRepo.RecordFailure(ctx, key, now, now.Add(lockout))
+	if err != nil {
+		return err
+	}
+
+	if maxAttempts > 0 && attempt.Failures >= maxAttempts && attempt.LockedUntil == nil {
+		until := now.Add(lockout)
+		if err := s.attemptRepo.Lock(ctx, key, until); err != nil {
+			return err
+		}
+		logSecurityEvent(event, "email=%q ip=%q failures=%d locked_until=%s",
+			email, clientIP, attempt.Failures, until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// recordLoginSuccess menghapus catatan login gagal akun.
+// Catatan IP sengaja tidak dihapus agar penyerang tidak bisa me-reset hitungan
+// IP dengan login ke akunnya sendiri.
+func (s *authServiceImpl) recordLoginSuccess(ctx context.Context, email string) error {
+	return s.attemptRepo.Reset(ctx, accountAttemptKey(email))
+}
+
+// loginBackoff menghitung jeda minimum setelah sejumlah kegagalan berturut-turut.
+func (s *authServiceImpl) loginBackoff(failures int) time.Duration {
+	if failures <= 0 || s.cfg.LoginBackoffBaseSeconds <= 0 {
+		return 0
+	}
+
+	backoff := time.Duration(s.cfg.LoginBackoffBaseSeconds) * time.Second
+	lockout := time.Duration(s.cfg.LoginLockoutMinutes) * time.Minute
+	for i := 1; i < failures && backoff < lockout; i++ {
+		backoff *= 2
+	}
+	if backoff > lockout {
+		return lockout
+	}
+	return backoff
+}
+
+// logSecurityEvent menulis event keamanan ke log dengan prefix yang mudah difilter.
+func logSecurityEvent(event string, format string, args ...interface{}) {
+	log.Printf("[SECURITY] event=%s "+format, append([]interface{}{event}, args...)...)
+}
+
+// loginFailed mencatat login gagal lalu mengembalikan err untuk pemanggil.
+// Pencatatan tidak ikut dibatalkan bersama request, agar client tidak bisa
+// menghindari hitungan login gagal dengan memutus koneksi.
+func (s *authServiceImpl) loginFailed(ctx context.Context, email, clientIP string, err error) error {
+	if recordErr := s.recordLoginFailure(context.WithoutCancel(ctx), email, clientIP); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+// UnlockAccount membuka lockout akun user (dipanggil oleh admin).
+func (s *authServiceImpl) UnlockAccount(ctx context.Context, adminID uint, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.attemptRepo.Reset(ctx, accountAttemptKey(user.Email)); err != nil {
+		return err
+	}
+
+	logSecurityEvent("account_unlocked", "email=%q user_id=%d admin_id=%d", user.Email, user.ID, adminID)
+	return nil
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/repository"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Scope OIDC yang didukung.
+var supportedScopes = []string{"openid", "profile", "email"}
+
+// OAuthError adalah error OAuth2 dengan kode standar (RFC 6749 bagian 4.1.2.1 dan 5.2).
+type OAuthError struct {
+	Code        string // misalnya "invalid_request", "invalid_grant"
+	Description string
+	// Redirect bernilai false jika client_id atau redirect_uri tidak valid;
+	// error seperti itu harus ditampilkan ke user, bukan di-redirect ke client.
+	Redirect bool
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// RedirectURL membuat URL redirect error ke client sesuai authorization request.
+func (e *OAuthError) RedirectURL(req dto.AuthorizeRequest) string {
+	params := url.Values{"error": {e.Code}, "error_description": {e.Description}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return appendQuery(req.RedirectURI, params)
+}
+
+// OAuthService adalah interface untuk provider OpenID Connect
+// (authorization code flow dengan PKCE).
+type OAuthService interface {
+	CreateClient(ctx context.Context, req dto.CreateOAuthClientRequest) (*dto.OAuthClientResponse, error)
+	ListClients(ctx context.Context) ([]dto.OAuthClientResponse, error)
+	DeleteClient(ctx context.Context, clientID string) error
+	ValidateAuthorizeRequest(ctx context.Context, req dto.AuthorizeRequest) error
+	Authorize(ctx context.Context, clientIP string, req dto.AuthorizeRequest) (*dto.AuthorizeResponse, error)
+	Token(ctx context.Context, req dto.TokenRequest) (*dto.TokenResponse, error)
+	UserInfo(ctx context.Context, userID uint, scope string) (*dto.UserInfoResponse, error)
+	Discovery() dto.OpenIDConfiguration
+}
+
+// oauthServiceImpl adalah implementasi dari OAuthService.
+// Autentikasi user (password, 2FA, proteksi brute-force) didelegasikan ke AuthService.
+type oauthServiceImpl struct {
+	authService AuthService
+	userRepo    repository.UserRepository
+	clientRepo  repository.OAuthClientRepository
+	codeRepo    repository.OAuthCodeRepository
+	keys        *jwtkeys.KeySet
+	cfg         *config.Config
+}
+
+// NewOAuthService membuat instance baru OAuthService
+func NewOAuthService(
+	authService AuthService,
+	userRepo repository.UserRepository,
+	clientRepo repository.OAuthClientRepository,
+	codeRepo repository.OAuthCodeRepository,
+	keys *jwtkeys.KeySet,
+	cfg *config.Config,
+) OAuthService {
+	return &oauthServiceImpl{
+		authService: authService,
+		userRepo:    userRepo,
+		clientRepo:  clientRepo,
+		codeRepo:    codeRepo,
+		keys:        keys,
+		cfg:         cfg,
+	}
+}
+
+// idTokenClaims adalah claims ID token OIDC.
+type idTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	AuthTime      int64  `json:"auth_time"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// CreateClient mendaftarkan client baru. Client secret hanya dikembalikan sekali.
+func (s *oauthServiceImpl) CreateClient(ctx context.Context, req dto.CreateOAuthClientRequest) (*dto.OAuthClientResponse, error) {
+	for _, uri := range req.RedirectURIs {
+		if strings.ContainsAny(uri, " #") {
+			return nil, apperror.Validation("redirect_uris must not contain spaces or fragments", apperror.FieldViolation{Field: "redirect_uris", Description: "must not contain spaces or fragments"})
+		}
+	}
+
+	clientID, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	client := &entity.OAuthClient{
+		ID:           clientID[:22],
+		Name:         req.Name,
+		RedirectURIs: strings.Join(req.RedirectURIs, " "),
+	}
+
+	var secret string
+	if !req.Public {
+		if secret, err = generateOpaqueToken(); err != nil {
+			return nil, err
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+
+	resp := toOAuthClientResponse(client)
+	resp.ClientSecret = secret
+	return &resp, nil
+}
+
+// ListClients mengambil semua client yang terdaftar (tanpa secret).
+func (s *oauthServiceImpl) ListClients(ctx context.Context) ([]dto.OAuthClientResponse, error) {
+	clients, err := s.clientRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.OAuthClientResponse, 0, len(clients))
+	for i := range clients {
+		resp = append(resp, toOAuthClientResponse(&clients[i]))
+	}
+	return resp, nil
+}
+
+// DeleteClient menghapus client. Token yang sudah diterbitkan tetap berlaku sampai expired.
+func (s *oauthServiceImpl) DeleteClient(ctx context.Context, clientID string) error {
+	return s.clientRepo.Delete(ctx, clientID)
+}
+
+// ValidateAuthorizeRequest memvalidasi parameter authorization request.
+// Error selalu bertipe *OAuthError.
+func (s *oauthServiceImpl) ValidateAuthorizeRequest(ctx context.Context, req dto.AuthorizeRequest) error {
+	_, err := s.validateAuthorizeRequest(ctx, req)
+	return err
+}
+
+func (s *oauthServiceImpl) validateAuthorizeRequest(ctx context.Context, req dto.AuthorizeRequest) (*entity.OAuthClient, error) {
+	// Error client_id/redirect_uri tidak boleh di-redirect (mencegah open redirect)
+	client, err := s.clientRepo.FindByID(ctx, req.ClientID)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_request", Description: "unknown client_id"}
+	}
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return nil, &OAuthError{Code: "invalid_request", Description: "redirect_uri is not registered for this client"}
+	}
+
+	if req.ResponseType != "code" {
+		return nil, &OAuthError{Code: "unsupported_response_type", Description: "only response_type=code is supported", Redirect: true}
+	}
+	if !hasScope(req.Scope, "openid") {
+		return nil, &OAuthError{Code: "invalid_scope", Description: "scope must include openid", Redirect: true}
+	}
+
+	// PKCE: hanya S256; wajib untuk public client
+	if req.CodeChallenge == "" && client.IsPublic() {
+		return nil, &OAuthError{Code: "invalid_request", Description: "code_challenge is required for public clients", Redirect: true}
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return nil, &OAuthError{Code: "invalid_request", Description: "code_challenge_method must be S256", Redirect: true}
+	}
+
+	// Provider tidak menyimpan sesi login, jadi login tanpa interaksi tidak mungkin
+	if hasScope(req.Prompt, "none") {
+		return nil, &OAuthError{Code: "login_required", Description: "user must log in", Redirect: true}
+	}
+
+	return client, nil
+}
+
+// Authorize mengautentikasi user dari form login lalu menerbitkan authorization code.
+// Untuk user dengan 2FA, panggilan pertama (email + password) mengembalikan MFARequired,
+// lalu form dikirim ulang dengan MFAToken dan Code.
+func (s *oauthServiceImpl) Authorize(ctx context.Context, clientIP string, req dto.AuthorizeRequest) (*dto.AuthorizeResponse, error) {
+	client, err := s.validateAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *dto.UserResponse
+	if req.MFAToken != "" {
+		user, err = s.authService.AuthenticateMFA(ctx, clientIP, dto.MFAVerifyRequest{MFAToken: req.MFAToken, Code: req.Code})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := s.authService.Authenticate(ctx, clientIP, dto.LoginRequest{Email: req.Email, Password: req.Password})
+		if err != nil {
+			return nil, err
+		}
+		if resp.MFARequired {
+			return &dto.AuthorizeResponse{MFARequired: true, MFAToken: resp.MFAToken}, nil
+		}
+		user = resp.User
+	}
+	// Sama seperti RequireVerifiedEmail untuk API: user yang belum verifikasi email tidak mendapat code
+	if s.cfg.RequireEmailVerification && !user.EmailVerified {
+		return nil, &OAuthError{Code: "access_denied", Description: "email not verified", Redirect: true}
+	}
+
+	code, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = s.codeRepo.Create(ctx, &entity.OAuthAuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         grantedScope(req.Scope),
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      now,
+		ExpiresAt:     now.Add(time.Duration(s.cfg.OAuthCodeExpiryMinutes) * time.Minute),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return &dto.AuthorizeResponse{RedirectURL: appendQuery(req.RedirectURI, params)}, nil
+}
+
+// Token menukar authorization code dengan access token dan ID token.
+// Error selalu bertipe *OAuthError.
+func (s *oauthServiceImpl) Token(ctx context.Context, req dto.TokenRequest) (*dto.TokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, &OAuthError{Code: "unsupported_grant_type", Description: "only authorization_code is supported"}
+	}
+
+	client, err := s.clientRepo.FindByID(ctx, req.ClientID)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+	if !client.IsPublic() && subtle.ConstantTimeCompare([]byte(hashToken(req.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	// Code baru dipakai setelah client, redirect_uri, dan PKCE cocok, sehingga
+	// penukaran yang gagal tidak menghanguskan code milik client yang sah
+	codeHash := hashToken(req.Code)
+	code, err := s.codeRepo.FindValid(ctx, codeHash, time.Now())
+	if err != nil || code.ClientID != client.ID {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "invalid, expired or already used authorization code"}
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "redirect_uri does not match the authorization request"}
+	}
+	// code_verifier tanpa code_challenge juga ditolak (mencegah PKCE downgrade)
+	if (code.CodeChallenge == "") != (req.CodeVerifier == "") ||
+		(code.CodeChallenge != "" && !verifyPKCE(code.CodeChallenge, req.CodeVerifier)) {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "invalid code_verifier"}
+	}
+	// Consume atomik: dari dua penukaran paralel, hanya satu yang berhasil
+	if _, err := s.codeRepo.Consume(ctx, codeHash, time.Now()); err != nil {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "invalid, expired or already used authorization code"}
+	}
+
+	user, err := s.userRepo.FindByID(ctx, code.UserID)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "user no longer exists"}
+	}
+
+	// Access token OAuth terikat ke client (aud) dan scope, dan hanya berlaku di /userinfo
+	accessToken, err := middleware.GenerateOAuthAccessToken(user, client.ID, code.Scope, s.keys, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := s.issueIDToken(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(middleware.AccessTokenTTL(s.cfg).Seconds()),
+		IDToken:     idToken,
+		Scope:       code.Scope,
+	}, nil
+}
+
+// UserInfo mengembalikan standard claims untuk pemilik access token.
+// scope adalah scope access token OAuth; claims dibatasi sesuai scope itu
+// seperti di ID token. Kosong untuk token login biasa (semua claims).
+func (s *oauthServiceImpl) UserInfo(ctx context.Context, userID uint, scope string) (*dto.UserInfoResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	info := &dto.UserInfoResponse{Sub: strconv.FormatUint(uint64(user.ID), 10)}
+	if scope == "" || hasScope(scope, "profile") {
+		info.Name = user.Name
+	}
+	if scope == "" || hasScope(scope, "email") {
+		info.Email = user.Email
+		verified := user.EmailVerifiedAt != nil
+		info.EmailVerified = &verified
+	}
+	return info, nil
+}
+
+// Discovery mengembalikan dokumen /.well-known/openid-configuration.
+func (s *oauthServiceImpl) Discovery() dto.OpenIDConfiguration {
+	issuer := s.issuer()
+	return dto.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserinfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   supportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		ResponseModesSupported:            []string{"query"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.keys.SigningAlgorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "email", "email_verified"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+// issueIDToken membuat ID token untuk authorization code yang sudah ditukar.
+// Claims profile/email hanya disertakan sesuai scope yang diberikan.
+func (s *oauthServiceImpl) issueIDToken(user *entity.User, code *entity.OAuthAuthorizationCode) (string, error) {
+	now := time.Now()
+	claims := idTokenClaims{
+		Nonce:    code.Nonce,
+		AuthTime: code.AuthTime.Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer(),
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Audience:  jwt.ClaimStrings{code.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(middleware.AccessTokenTTL(s.cfg))),
+		},
+	}
+	if hasScope(code.Scope, "profile") {
+		claims.Name = user.Name
+	}
+	if hasScope(code.Scope, "email") {
+		verified := user.EmailVerifiedAt != nil
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+	return s.keys.Sign(claims)
+}
+
+// issuer mengembalikan issuer OIDC (OIDC_ISSUER, default PUBLIC_BASE_URL).
+func (s *oauthServiceImpl) issuer() string {
+	if s.cfg.OIDCIssuer != "" {
+		return strings.TrimRight(s.cfg.OIDCIssuer, "/")
+	}
+	return strings.TrimRight(s.cfg.PublicBaseURL, "/")
+}
+
+// verifyPKCE mengecek code_verifier terhadap code_challenge S256 (RFC 7636).
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// hasScope mengecek apakah daftar yang dipisah spasi berisi nilai tertentu.
+func hasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// grantedScope menyaring scope yang diminta menjadi scope yang didukung.
+func grantedScope(requested string) string {
+	var granted []string
+	for _, scope := range supportedScopes {
+		if hasScope(requested, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	return strings.Join(granted, " ")
+}
+
+// appendQuery menambahkan parameter ke URL dengan tetap mempertahankan query yang sudah ada.
+func appendQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	for key, values := range params {
+		q[key] = values
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// toOAuthClientResponse adalah helper untuk konversi dari entity ke DTO.
+func toOAuthClientResponse(client *entity.OAuthClient) dto.OAuthClientResponse {
+	return dto.OAuthClientResponse{
+		ClientID:     client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIList(),
+		Public:       client.IsPublic(),
+		CreatedAt:    client.CreatedAt,
+	}
+}
@@ -0,0 +1,384 @@
+package service_test
+
+import (
+	"api-user-crud-go/config"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/jwtkeys"
+	"api-user-crud-go/middleware"
+	"api-user-crud-go/service"
+	"api-user-crud-go/totp"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ==========================================
+// MOCK OAUTH REPOSITORIES
+// ==========================================
+
+// mockOAuthClientRepo adalah implementasi mock dari repository.OAuthClientRepository.
+type mockOAuthClientRepo struct {
+	clients map[string]*entity.OAuthClient
+}
+
+func newMockOAuthClientRepo() *mockOAuthClientRepo {
+	return &mockOAuthClientRepo{clients: make(map[string]*entity.OAuthClient)}
+}
+
+func (m *mockOAuthClientRepo) Create(ctx context.Context, client *entity.OAuthClient) error {
+	client.CreatedAt = time.Now()
+	m.clients[client.ID] = client
+	return nil
+}
+
+func (m *mockOAuthClientRepo) FindByID(ctx context.Context, clientID string) (*entity.OAuthClient, error) {
+	client, ok := m.clients[clientID]
+	if !ok {
+		return nil, errors.New("client not found")
+	}
+	return client, nil
+}
+
+func (m *mockOAuthClientRepo) FindAll(ctx context.Context) ([]entity.OAuthClient, error) {
+	var clients []entity.OAuthClient
+	for _, c := range m.clients {
+		clients = append(clients, *c)
+	}
+	return clients, nil
+}
+
+func (m *mockOAuthClientRepo) Delete(ctx context.Context, clientID string) error {
+	if _, ok := m.clients[clientID]; !ok {
+		return errors.New("client not found")
+	}
+	delete(m.clients, clientID)
+	return nil
+}
+
+// mockOAuthCodeRepo adalah implementasi mock dari repository.OAuthCodeRepository.
+type mockOAuthCodeRepo struct {
+	codes map[string]*entity.OAuthAuthorizationCode
+}
+
+func newMockOAuthCodeRepo() *mockOAuthCodeRepo {
+	return &mockOAuthCodeRepo{codes: make(map[string]*entity.OAuthAuthorizationCode)}
+}
+
+func (m *mockOAuthCodeRepo) Create(ctx context.Context, code *entity.OAuthAuthorizationCode) error {
+	m.codes[code.CodeHash] = code
+	return nil
+}
+
+func (m *mockOAuthCodeRepo) FindValid(ctx context.Context, codeHash string, now time.Time) (*entity.OAuthAuthorizationCode, error) {
+	code, ok := m.codes[codeHash]
+	if !ok || code.UsedAt != nil || !code.ExpiresAt.After(now) {
+		return nil, errors.New("authorization code not found")
+	}
+	return code, nil
+}
+
+func (m *mockOAuthCodeRepo) Consume(ctx context.Context, codeHash string, now time.Time) (*entity.OAuthAuthorizationCode, error) {
+	code, ok := m.codes[codeHash]
+	if !ok || code.UsedAt != nil || !code.ExpiresAt.After(now) {
+		return nil, errors.New("authorization code not found")
+	}
+	code.UsedAt = &now
+	return code, nil
+}
+
+func (m *mockOAuthCodeRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ==========================================
+// TESTS
+// ==========================================
+
+const testRedirectURI = "https://app.example.com/callback"
+
+type oauthFixture struct {
+	*authFixture
+	oauth service.OAuthService
+	keys  *jwtkeys.KeySet
+}
+
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+	return newOAuthFixtureWithConfig(t, testConfig)
+}
+
+func newOAuthFixtureWithConfig(t *testing.T, cfg *config.Config) *oauthFixture {
+	t.Helper()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	keys, err := jwtkeys.LoadKeySet(path, nil)
+	if err != nil {
+		t.Fatalf("LoadKeySet returned unexpected error: %v", err)
+	}
+
+	f := &oauthFixture{authFixture: newAuthFixtureWithConfig(cfg), keys: keys}
+	f.oauth = service.NewOAuthService(f.svc, f.users, newMockOAuthClientRepo(), newMockOAuthCodeRepo(), keys, f.cfg)
+	return f
+}
+
+// pkcePair membuat code_verifier dan code_challenge S256.
+func pkcePair() (string, string) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func authorizeRequest(clientID, challenge string) dto.AuthorizeRequest {
+	return dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid email",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: "S256",
+		Email:               "alice@example.com",
+		Password:            "password123",
+	}
+}
+
+// codeFromRedirect mengambil authorization code dari URL redirect dan memeriksa state.
+func codeFromRedirect(t *testing.T, redirectURL string) string {
+	t.Helper()
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		t.Fatalf("invalid redirect URL: %v", err)
+	}
+	if u.Query().Get("state") != "xyz" {
+		t.Errorf("expected state to be echoed, got %s", redirectURL)
+	}
+	return u.Query().Get("code")
+}
+
+func TestOAuth_AuthorizationCodeFlowWithPKCE(t *testing.T) {
+	f := newOAuthFixture(t)
+	alice := registerAlice(t, f.svc)
+	client, err := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "SPA", RedirectURIs: []string{testRedirectURI}, Public: true})
+	if err != nil {
+		t.Fatalf("CreateClient returned unexpected error: %v", err)
+	}
+	if client.ClientSecret != "" {
+		t.Error("expected public client to have no secret")
+	}
+
+	verifier, challenge := pkcePair()
+	resp, err := f.oauth.Authorize(context.Background(), "", authorizeRequest(client.ClientID, challenge))
+	if err != nil {
+		t.Fatalf("Authorize returned unexpected error: %v", err)
+	}
+	code := codeFromRedirect(t, resp.RedirectURL)
+
+	tokenReq := dto.TokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, ClientID: client.ClientID, CodeVerifier: verifier}
+	tokens, err := f.oauth.Token(context.Background(), tokenReq)
+	if err != nil {
+		t.Fatalf("Token returned unexpected error: %v", err)
+	}
+	if tokens.TokenType != "Bearer" || tokens.AccessToken == "" || tokens.Scope != "openid email" {
+		t.Errorf("unexpected token response %+v", tokens)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokens.IDToken, claims, f.keys.Keyfunc,
+		jwt.WithIssuer(f.cfg.PublicBaseURL), jwt.WithAudience(client.ClientID))
+	if err != nil {
+		t.Fatalf("expected valid ID token, got %v", err)
+	}
+	if claims["sub"] != "1" || claims["nonce"] != "n-0S6" || claims["email"] != alice.User.Email {
+		t.Errorf("unexpected ID token claims %v", claims)
+	}
+	if _, ok := claims["name"]; ok {
+		t.Error("expected name claim to be omitted without profile scope")
+	}
+
+	// Access token terikat ke client dan scope, dan ditolak oleh API biasa
+	accessClaims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokens.AccessToken, accessClaims, f.keys.Keyfunc, jwt.WithAudience(client.ClientID)); err != nil {
+		t.Fatalf("expected access token with client audience, got %v", err)
+	}
+	if accessClaims["scope"] != "openid email" {
+		t.Errorf("expected access token scope %q, got %v", "openid email", accessClaims["scope"])
+	}
+	if _, err := middleware.ParseToken(context.Background(), tokens.AccessToken, f.keys, f.revocations); err == nil {
+		t.Error("expected OAuth access token to be rejected outside /userinfo")
+	}
+
+	info, err := f.oauth.UserInfo(context.Background(), alice.User.ID, tokens.Scope)
+	if err != nil {
+		t.Fatalf("UserInfo returned unexpected error: %v", err)
+	}
+	if info.Email != alice.User.Email || info.Name != "" {
+		t.Errorf("expected userinfo limited to email scope, got %+v", info)
+	}
+
+	// Authorization code hanya bisa dipakai sekali
+	var oauthErr *service.OAuthError
+	if _, err := f.oauth.Token(context.Background(), tokenReq); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Errorf("expected invalid_grant for reused code, got %v", err)
+	}
+}
+
+func TestOAuth_TokenRejectsWrongVerifierAndSecret(t *testing.T) {
+	f := newOAuthFixture(t)
+	registerAlice(t, f.svc)
+	client, _ := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "Web", RedirectURIs: []string{testRedirectURI}})
+
+	_, challenge := pkcePair()
+	resp, _ := f.oauth.Authorize(context.Background(), "", authorizeRequest(client.ClientID, challenge))
+	code := codeFromRedirect(t, resp.RedirectURL)
+
+	tests := []struct {
+		name    string
+		req     dto.TokenRequest
+		errCode string
+	}{
+		{"wrong secret", dto.TokenRequest{GrantType: "authorization_code", Code: code, ClientID: client.ClientID, ClientSecret: "wrong"}, "invalid_client"},
+		{"unsupported grant", dto.TokenRequest{GrantType: "password", ClientID: client.ClientID, ClientSecret: client.ClientSecret}, "unsupported_grant_type"},
+		{"wrong verifier", dto.TokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, ClientID: client.ClientID, ClientSecret: client.ClientSecret, CodeVerifier: "wrong-verifier-wrong-verifier-wrong-verifier"}, "invalid_grant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var oauthErr *service.OAuthError
+			if _, err := f.oauth.Token(context.Background(), tt.req); !errors.As(err, &oauthErr) || oauthErr.Code != tt.errCode {
+				t.Errorf("expected %s, got %v", tt.errCode, err)
+			}
+		})
+	}
+}
+
+func TestOAuth_FailedExchangeDoesNotBurnCode(t *testing.T) {
+	f := newOAuthFixture(t)
+	registerAlice(t, f.svc)
+	client, _ := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "SPA", RedirectURIs: []string{testRedirectURI}, Public: true})
+	other, _ := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "Other", RedirectURIs: []string{testRedirectURI}, Public: true})
+
+	verifier, challenge := pkcePair()
+	resp, _ := f.oauth.Authorize(context.Background(), "", authorizeRequest(client.ClientID, challenge))
+	code := codeFromRedirect(t, resp.RedirectURL)
+
+	// Penukaran dengan client, redirect_uri, atau verifier yang salah ditolak tanpa memakai code
+	attempts := []dto.TokenRequest{
+		{GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, ClientID: other.ClientID, CodeVerifier: verifier},
+		{GrantType: "authorization_code", Code: code, RedirectURI: "https://evil.example.com/cb", ClientID: client.ClientID, CodeVerifier: verifier},
+		{GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, ClientID: client.ClientID, CodeVerifier: "wrong-verifier-wrong-verifier-wrong-verifier"},
+	}
+	for _, req := range attempts {
+		var oauthErr *service.OAuthError
+		if _, err := f.oauth.Token(context.Background(), req); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+			t.Errorf("expected invalid_grant for %+v, got %v", req, err)
+		}
+	}
+
+	tokenReq := dto.TokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, ClientID: client.ClientID, CodeVerifier: verifier}
+	if _, err := f.oauth.Token(context.Background(), tokenReq); err != nil {
+		t.Fatalf("expected legitimate exchange to succeed after failed attempts, got %v", err)
+	}
+}
+
+func TestOAuth_AuthorizeRequiresVerifiedEmail(t *testing.T) {
+	cfg := *testConfig
+	cfg.RequireEmailVerification = true
+	f := newOAuthFixtureWithConfig(t, &cfg)
+	alice := registerAlice(t, f.svc)
+	client, _ := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "SPA", RedirectURIs: []string{testRedirectURI}, Public: true})
+
+	_, challenge := pkcePair()
+	var oauthErr *service.OAuthError
+	_, err := f.oauth.Authorize(context.Background(), "", authorizeRequest(client.ClientID, challenge))
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "access_denied" || !oauthErr.Redirect {
+		t.Fatalf("expected access_denied redirect for unverified email, got %v", err)
+	}
+
+	now := time.Now()
+	f.users.users[alice.User.ID].EmailVerifiedAt = &now
+	resp, err := f.oauth.Authorize(context.Background(), "", authorizeRequest(client.ClientID, challenge))
+	if err != nil {
+		t.Fatalf("Authorize returned unexpected error after verification: %v", err)
+	}
+	codeFromRedirect(t, resp.RedirectURL)
+}
+
+func TestOAuth_ValidateAuthorizeRequest(t *testing.T) {
+	f := newOAuthFixture(t)
+	public, _ := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "SPA", RedirectURIs: []string{testRedirectURI}, Public: true})
+
+	tests := []struct {
+		name     string
+		modify   func(req *dto.AuthorizeRequest)
+		errCode  string
+		redirect bool
+	}{
+		{"unknown client", func(r *dto.AuthorizeRequest) { r.ClientID = "nope" }, "invalid_request", false},
+		{"unregistered redirect", func(r *dto.AuthorizeRequest) { r.RedirectURI = "https://evil.example.com/cb" }, "invalid_request", false},
+		{"missing openid scope", func(r *dto.AuthorizeRequest) { r.Scope = "email" }, "invalid_scope", true},
+		{"public client without PKCE", func(r *dto.AuthorizeRequest) { r.CodeChallenge = "" }, "invalid_request", true},
+		{"plain PKCE", func(r *dto.AuthorizeRequest) { r.CodeChallengeMethod = "plain" }, "invalid_request", true},
+		{"prompt none", func(r *dto.AuthorizeRequest) { r.Prompt = "none" }, "login_required", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, challenge := pkcePair()
+			req := authorizeRequest(public.ClientID, challenge)
+			tt.modify(&req)
+
+			var oauthErr *service.OAuthError
+			err := f.oauth.ValidateAuthorizeRequest(context.Background(), req)
+			if !errors.As(err, &oauthErr) || oauthErr.Code != tt.errCode || oauthErr.Redirect != tt.redirect {
+				t.Errorf("expected %s (redirect=%v), got %+v", tt.errCode, tt.redirect, err)
+			}
+		})
+	}
+}
+
+func TestOAuth_AuthorizeWithMFA(t *testing.T) {
+	f := newOAuthFixture(t)
+	alice := registerAlice(t, f.svc)
+	secret, step, _ := enrollMFA(t, f.svc, alice.User.ID)
+	client, _ := f.oauth.CreateClient(context.Background(), dto.CreateOAuthClientRequest{Name: "SPA", RedirectURIs: []string{testRedirectURI}, Public: true})
+
+	_, challenge := pkcePair()
+	req := authorizeRequest(client.ClientID, challenge)
+	resp, err := f.oauth.Authorize(context.Background(), "", req)
+	if err != nil {
+		t.Fatalf("Authorize returned unexpected error: %v", err)
+	}
+	if !resp.MFARequired || resp.RedirectURL != "" {
+		t.Fatalf("expected MFA challenge, got %+v", resp)
+	}
+
+	req.MFAToken = resp.MFAToken
+	req.Code, _ = totp.Code(secret, step+1)
+	resp, err = f.oauth.Authorize(context.Background(), "", req)
+	if err != nil {
+		t.Fatalf("Authorize with MFA code returned unexpected error: %v", err)
+	}
+	if codeFromRedirect(t, resp.RedirectURL) == "" {
+		t.Error("expected authorization code after MFA")
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Tujuan token yang ditandatangani dengan secret yang sama.
+// Tujuan ikut di-sign agar token untuk satu keperluan tidak bisa dipakai
+// untuk keperluan lain.
+const (
+	purposeEmailVerification = "email-verification"
+	purposeMFAPending        = "mfa-pending"
+)
+
+// errInvalidSignedToken dikembalikan untuk token yang rusak atau signature-nya salah.
+var errInvalidSignedToken = errors.New("invalid signed token")
+
+// encodeSignedToken membuat token opaque "payload.signature" dari value v.
+// Token seperti ini tidak perlu disimpan di database; validitasnya cukup
+// dicek dari signature HMAC.
+func encodeSignedToken(secret, purpose string, v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + signToken(secret, purpose, payload), nil
+}
+
+// decodeSignedToken memverifikasi signature token dan mengisi v dengan payload-nya.
+// Masa berlaku dicek oleh pemanggil karena tiap payload menyimpannya sendiri.
+func decodeSignedToken(secret, purpose, token string, v interface{}) error {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signToken(secret, purpose, payload))) {
+		return errInvalidSignedToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || json.Unmarshal(raw, v) != nil {
+		return errInvalidSignedToken
+	}
+	return nil
+}
+
+// signToken menghitung HMAC-SHA256 dari tujuan dan payload token.
+func signToken(secret, purpose, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// ExpiredTokenStore adalah repository yang menyimpan token dengan waktu expired,
+// misalnya refresh token, daftar revocation, atau token reset password.
+type ExpiredTokenStore interface {
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// StartTokenCleanup menjalankan job background yang menghapus token yang sudah
+// expired dari setiap store secara berkala. Key map dipakai sebagai nama di log.
+// Fungsi yang dikembalikan dipakai untuk menghentikan job.
+func StartTokenCleanup(interval time.Duration, stores map[string]ExpiredTokenStore) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				cleanupExpiredTokens(stores)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// cleanupExpiredTokens menjalankan satu kali pembersihan.
+func cleanupExpiredTokens(stores map[string]ExpiredTokenStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		deleted, err := stores[name].DeleteExpired(ctx, now)
+		if err != nil {
+			log.Printf("Gagal membersihkan %s: %v", name, err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("✓ Token cleanup: %d %s dihapus", deleted, name)
+		}
+	}
+}
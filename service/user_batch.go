@@ -0,0 +1,165 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxBatchSize adalah jumlah item maksimal dalam satu operasi batch user.
+const MaxBatchSize = 500
+
+// ErrInvalidBatch dikembalikan ketika batch kosong atau melebihi MaxBatchSize.
+var ErrInvalidBatch = apperror.Validation("invalid batch")
+
+// ErrBatchAborted adalah status item valid yang dibatalkan karena item lain
+// pada batch atomic gagal.
+var ErrBatchAborted = apperror.Conflict("not applied: another item in the atomic batch failed")
+
+// errBatchRollback memicu rollback transaksi batch atomic yang memiliki item gagal.
+var errBatchRollback = errors.New("rollback batch")
+
+// errBatchDryRun memicu rollback transaksi batch dry run setelah semua item dicoba.
+var errBatchDryRun = errors.New("dry run batch")
+
+// BatchResult adalah hasil satu item operasi batch. Err nil berarti item berhasil;
+// User kosong untuk item delete.
+type BatchResult struct {
+	User *dto.UserResponse
+	Err  error
+}
+
+// BatchCreateUsers membuat banyak user dalam satu transaksi.
+// Dengan atomic, satu item gagal membatalkan semua item; tanpa atomic setiap
+// item yang valid tetap dibuat. Email harus unik, termasuk di antara item batch.
+func (s *userServiceImpl) BatchCreateUsers(ctx context.Context, reqs []dto.CreateUserRequest, atomic bool) ([]BatchResult, error) {
+	return s.runBatch(ctx, len(reqs), atomic, false, createBatchItem(ctx, reqs))
+}
+
+// ValidateNewUsers menjalankan batch create secara best-effort lalu me-rollback
+// seluruhnya, sehingga hasil per item sama dengan BatchCreateUsers tanpa ada
+// user yang tersimpan. Dipakai untuk dry run import.
+func (s *userServiceImpl) ValidateNewUsers(ctx context.Context, reqs []dto.CreateUserRequest) ([]BatchResult, error) {
+	return s.runBatch(ctx, len(reqs), false, true, createBatchItem(ctx, reqs))
+}
+
+// createBatchItem membuat fungsi apply runBatch untuk item batch create.
+func createBatchItem(ctx context.Context, reqs []dto.CreateUserRequest) func(repo repository.UserRepository, i int) (*dto.UserResponse, error) {
+	return func(repo repository.UserRepository, i int) (*dto.UserResponse, error) {
+		req := reqs[i]
+		if err := validateNewUser(req); err != nil {
+			return nil, err
+		}
+		// Di dalam transaksi, item sebelumnya di batch yang sama ikut terlihat
+		if _, err := repo.FindByEmail(ctx, req.Email); err == nil {
+			return nil, ErrEmailTaken
+		}
+
+		user := &entity.User{Name: req.Name, Email: req.Email, Age: req.Age, Role: req.Role}
+		if user.Role == "" {
+			user.Role = entity.RoleUser
+		}
+		if err := repo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+		return toUserResponse(user), nil
+	}
+}
+
+// BatchUpdateUsers menerapkan merge patch ke banyak user dalam satu transaksi.
+// ETag per item berlaku seperti If-Match pada PATCH /users/:id.
+func (s *userServiceImpl) BatchUpdateUsers(ctx context.Context, items []dto.BatchUpdateUserItem, atomic bool) ([]BatchResult, error) {
+	return s.runBatch(ctx, len(items), atomic, false, func(repo repository.UserRepository, i int) (*dto.UserResponse, error) {
+		item := items[i]
+		if item.ID == 0 {
+			return nil, apperror.Field(ErrInvalidUser, "id", "is required")
+		}
+		version, err := ParseETag(item.ETag)
+		if err != nil {
+			return nil, err
+		}
+		return patchUser(ctx, repo, item.ID, version, item.PatchUserRequest)
+	})
+}
+
+// BatchDeleteUsers menghapus banyak user (soft delete) dalam satu transaksi.
+func (s *userServiceImpl) BatchDeleteUsers(ctx context.Context, items []dto.BatchDeleteUserItem, atomic bool) ([]BatchResult, error) {
+	return s.runBatch(ctx, len(items), atomic, false, func(repo repository.UserRepository, i int) (*dto.UserResponse, error) {
+		item := items[i]
+		if item.ID == 0 {
+			return nil, apperror.Field(ErrInvalidUser, "id", "is required")
+		}
+		version, err := ParseETag(item.ETag)
+		if err != nil {
+			return nil, err
+		}
+		return nil, repo.Delete(ctx, item.ID, version)
+	})
+}
+
+// runBatch menjalankan apply untuk setiap item di dalam satu transaksi, masing-masing
+// di savepoint sendiri agar item yang gagal tidak meninggalkan perubahan setengah jadi.
+// Pada mode atomic, transaksi di-rollback jika ada item yang gagal dan item yang
+// sebenarnya berhasil ditandai ErrBatchAborted. Dengan dryRun, transaksi selalu
+// di-rollback tetapi hasil per item tetap dikembalikan.
+func (s *userServiceImpl) runBatch(ctx context.Context, n int, atomic, dryRun bool, apply func(repo repository.UserRepository, i int) (*dto.UserResponse, error)) ([]BatchResult, error) {
+	if n == 0 || n > MaxBatchSize {
+		return nil, fmt.Errorf("%w: batch must contain between 1 and %d items", ErrInvalidBatch, MaxBatchSize)
+	}
+
+	results := make([]BatchResult, n)
+	err := s.userRepo.Transaction(ctx, func(tx repository.UserRepository) error {
+		failed := false
+		for i := range results {
+			// Request yang dibatalkan menghentikan batch dan me-rollback semua item
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			err := tx.Transaction(ctx, func(item repository.UserRepository) error {
+				user, err := apply(item, i)
+				results[i].User = user
+				return err
+			})
+			if err != nil {
+				results[i] = BatchResult{Err: err}
+				failed = true
+			}
+		}
+		if atomic && failed {
+			return errBatchRollback
+		}
+		if dryRun {
+			return errBatchDryRun
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBatchDryRun) {
+		return results, nil
+	}
+
+	if errors.Is(err, errBatchRollback) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BatchResult{Err: ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// validateNewUser memvalidasi item batch create dengan aturan binding CreateUserRequest.
+func validateNewUser(req dto.CreateUserRequest) error {
+	if req.Age < 1 {
+		return apperror.Field(ErrInvalidUser, "age", "must be at least 1")
+	}
+	return validateUserFields(dto.UpdateUserRequest{Name: req.Name, Email: req.Email, Age: req.Age, Role: req.Role})
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format file export/import user.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+// exportColumns adalah header CSV export. Kolom name, email, age, dan role
+// sama dengan kolom import, sehingga hasil export bisa langsung di-import ulang.
+var exportColumns = []string{"id", "name", "email", "age", "role", "email_verified", "mfa_enabled", "created_at", "updated_at", "deleted_at"}
+
+// ExportUsers menulis semua user yang cocok dengan filter ke w dalam format
+// csv (default), ndjson, atau json. User dibaca bertahap dari repository dan
+// langsung ditulis, sehingga tabel tidak pernah dimuat sekaligus ke memori.
+// Request divalidasi sebelum ada byte yang ditulis ke w.
+func (s *userServiceImpl) ExportUsers(ctx context.Context, req dto.ExportUsersRequest, w io.Writer) error {
+	query, err := buildUserQuery(dto.ListUsersRequest{
+		Name:           req.Name,
+		Email:          req.Email,
+		MinAge:         req.MinAge,
+		MaxAge:         req.MaxAge,
+		IncludeDeleted: req.IncludeDeleted,
+		OnlyDeleted:    req.OnlyDeleted,
+	})
+	if err != nil {
+		return err
+	}
+
+	var enc userEncoder
+	switch cmp.Or(req.Format, FormatCSV) {
+	case FormatCSV:
+		enc = &csvUserEncoder{w: csv.NewWriter(w)}
+	case FormatNDJSON:
+		enc = &ndjsonUserEncoder{enc: json.NewEncoder(w)}
+	case FormatJSON:
+		enc = &jsonArrayUserEncoder{w: w}
+	default:
+		return fmt.Errorf("%w: format must be one of: csv, ndjson, json", ErrInvalidQuery)
+	}
+
+	err = s.userRepo.Stream(ctx, query, func(user *entity.User) error {
+		return enc.Encode(toUserResponse(user))
+	})
+	if err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// userEncoder menulis user satu per satu dalam format export tertentu.
+type userEncoder interface {
+	Encode(user *dto.UserResponse) error
+	Close() error
+}
+
+// csvUserEncoder menulis user sebagai baris CSV dengan header exportColumns.
+type csvUserEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvUserEncoder) Encode(user *dto.UserResponse) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	deletedAt := ""
+	if user.DeletedAt != nil {
+		deletedAt = user.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return e.w.Write([]string{
+		strconv.FormatUint(uint64(user.ID), 10),
+		escapeFormula(user.Name),
+		escapeFormula(user.Email),
+		strconv.Itoa(user.Age),
+		user.Role,
+		strconv.FormatBool(user.EmailVerified),
+		strconv.FormatBool(user.MFAEnabled),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		user.UpdatedAt.UTC().Format(time.RFC3339),
+		deletedAt,
+	})
+}
+
+func (e *csvUserEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// writeHeader menulis header sekali, termasuk untuk export tanpa data.
+func (e *csvUserEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.w.Write(exportColumns)
+}
+
+// ndjsonUserEncoder menulis satu objek JSON per baris.
+type ndjsonUserEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonUserEncoder) Encode(user *dto.UserResponse) error {
+	return e.enc.Encode(user)
+}
+
+func (e *ndjsonUserEncoder) Close() error {
+	return nil
+}
+
+// jsonArrayUserEncoder menulis satu array JSON tanpa menampung semua user di memori.
+type jsonArrayUserEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonArrayUserEncoder) Encode(user *dto.UserResponse) error {
+	b, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if e.count == 0 {
+		sep = "["
+	}
+	e.count++
+	_, err = io.WriteString(e.w, sep+string(b))
+	return err
+}
+
+func (e *jsonArrayUserEncoder) Close() error {
+	end := "]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// formulaPrefixes adalah karakter awal sel yang dieksekusi sebagai formula oleh spreadsheet.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula mencegah CSV injection: sel yang diawali karakter formula
+// diberi prefix tanda kutip satu agar dibaca sebagai teks oleh spreadsheet.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeFormula membalik escapeFormula saat file hasil export di-import ulang.
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/authz"
+	"api-user-crud-go/config"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImportErrors membatasi jumlah error per baris yang disimpan di laporan import.
+const maxImportErrors = 1000
+
+// importFields adalah field dto.CreateUserRequest yang bisa diisi dari file import.
+var importFields = []string{"name", "email", "age", "role"}
+
+// ErrInvalidImport dikembalikan ketika file import tidak bisa dibaca sama sekali
+// (format tidak dikenal, header tidak lengkap, mapping salah, CSV rusak).
+// Error pada baris tertentu tidak menggagalkan import, tetapi masuk ke laporan.
+var ErrInvalidImport = apperror.Validation("invalid import file")
+
+// ImportOptions adalah opsi satu kali import.
+type ImportOptions struct {
+	Format    string            // csv atau ndjson; kosong = dari ekstensi Filename
+	Filename  string            // nama file upload
+	Mapping   map[string]string // kolom/key di file -> field user (name, email, age, role)
+	DryRun    bool              // hanya validasi, tidak ada user yang dibuat
+	Async     bool              // paksa jalan sebagai job background
+	AllowRole bool              // pemanggil boleh mengisi role (authz.PermUserSetRole)
+}
+
+// UserImportService adalah interface untuk import user dari file CSV/NDJSON.
+type UserImportService interface {
+	// ImportUsers membaca file lalu memproses barisnya. Import kecil langsung
+	// mengembalikan laporan; import besar (atau opts.Async) mengembalikan job
+	// background yang bisa dipantau dengan GetImportJob. Job background tidak ikut
+	// berhenti saat request yang memulainya selesai atau dibatalkan.
+	// Import tidak atomik: jika berhenti di tengah karena error, batch sebelumnya
+	// tetap tersimpan dan laporan sebagian dikembalikan bersama error tersebut.
+	ImportUsers(ctx context.Context, ownerID uint, file io.Reader, opts ImportOptions) (*dto.ImportReport, *dto.ImportJobResponse, error)
+	GetImportJob(ctx context.Context, ownerID uint, id string) (*dto.ImportJobResponse, error)
+}
+
+// userImportServiceImpl adalah implementasi dari UserImportService.
+type userImportServiceImpl struct {
+	userService UserService
+	jobRepo     repository.ImportJobRepository
+	syncMaxRows int
+	jobTTL      time.Duration
+}
+
+// NewUserImportService membuat instance baru UserImportService.
+// User dibuat lewat UserService sehingga aturan validasinya sama dengan POST /users.
+func NewUserImportService(userService UserService, jobRepo repository.ImportJobRepository, cfg *config.Config) UserImportService {
+	return &userImportServiceImpl{
+		userService: userService,
+		jobRepo:     jobRepo,
+		syncMaxRows: cfg.ImportSyncMaxRows,
+		jobTTL:      time.Duration(cfg.ImportJobTTLHours) * time.Hour,
+	}
+}
+
+// importRow adalah satu baris data file import.
+type importRow struct {
+	line int
+	req  dto.CreateUserRequest
+	err  error
+}
+
+// ImportUsers membaca seluruh file terlebih dahulu (ukurannya dibatasi controller),
+// sehingga file yang rusak ditolak sebelum ada user yang dibuat.
+func (s *userImportServiceImpl) ImportUsers(ctx context.Context, ownerID uint, file io.Reader, opts ImportOptions) (*dto.ImportReport, *dto.ImportJobResponse, error) {
+	rows, err := parseImportFile(file, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.Async || len(rows) > s.syncMaxRows {
+		job, err := s.startJob(ctx, ownerID, rows, opts)
+		return nil, job, err
+	}
+
+	report, err := s.process(ctx, rows, opts, nil)
+	return report, nil, err
+}
+
+// GetImportJob mengembalikan status job import milik ownerID.
+func (s *userImportServiceImpl) GetImportJob(ctx context.Context, ownerID uint, id string) (*dto.ImportJobResponse, error) {
+	job, err := s.jobRepo.FindByID(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toImportJobResponse(job), nil
+}
+
+// startJob menyimpan job baru lalu memproses baris di goroutine terpisah.
+func (s *userImportServiceImpl) startJob(ctx context.Context, ownerID uint, rows []importRow, opts ImportOptions) (*dto.ImportJobResponse, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	job := &entity.ImportJob{
+		ID:        hex.EncodeToString(id),
+		UserID:    ownerID,
+		Status:    entity.ImportJobPending,
+		DryRun:    opts.DryRun,
+		Total:     len(rows),
+		ExpiresAt: time.Now().Add(s.jobTTL),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	response := toImportJobResponse(job)
+
+	// Nilai request tetap dibawa, tetapi pembatalan dan deadline-nya tidak
+	go s.runJob(context.WithoutCancel(ctx), job, rows, opts)
+	return response, nil
+}
+
+// runJob memproses job import dan mencatat progres serta hasilnya.
+// Panic ditangkap agar job tidak tertinggal berstatus running selamanya.
+func (s *userImportServiceImpl) runJob(ctx context.Context, job *entity.ImportJob, rows []importRow, opts ImportOptions) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Import job %s panic: %v", job.ID, r)
+			s.finishJob(ctx, job, nil, fmt.Errorf("internal error: %v", r))
+		}
+	}()
+
+	job.Status = entity.ImportJobRunning
+	s.saveJob(ctx, job)
+
+	report, err := s.process(ctx, rows, opts, func(processed int) {
+		job.Processed = processed
+		s.saveJob(ctx, job)
+	})
+	s.finishJob(ctx, job, report, err)
+}
+
+// finishJob menyimpan status akhir job beserta laporannya. Job yang gagal di tengah
+// tetap menyimpan laporan sebagian agar terlihat baris mana yang sudah dibuat.
+func (s *userImportServiceImpl) finishJob(ctx context.Context, job *entity.ImportJob, report *dto.ImportReport, err error) {
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = entity.ImportJobCompleted
+	if report != nil {
+		var marshalErr error
+		if job.Report, marshalErr = json.Marshal(report); err == nil {
+			err = marshalErr
+		}
+	}
+	if err == nil {
+		job.Processed = job.Total
+	}
+	if err != nil {
+		job.Status = entity.ImportJobFailed
+		job.Error = err.Error()
+	}
+	s.saveJob(ctx, job)
+}
+
+// saveJob menyimpan job; kegagalan hanya dicatat karena import tetap berjalan.
+func (s *userImportServiceImpl) saveJob(ctx context.Context, job *entity.ImportJob) {
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("Gagal menyimpan import job %s: %v", job.ID, err)
+	}
+}
+
+// process membuat (atau pada dry run hanya memvalidasi) user dari rows per
+// MaxBatchSize baris. Setiap baris diproses sendiri: baris yang gagal tidak
+// membatalkan baris lain. progress dipanggil setelah setiap batch.
+// Jika satu batch gagal, batch sebelumnya tetap tersimpan: laporan sebagian
+// dikembalikan bersama error, dengan baris mulai batch itu sebagai NotProcessed.
+func (s *userImportServiceImpl) process(ctx context.Context, rows []importRow, opts ImportOptions, progress func(processed int)) (*dto.ImportReport, error) {
+	// Email ganda di dalam file dilaporkan dengan baris pertamanya; pada dry run
+	// duplikat di batch yang berbeda tidak akan terdeteksi oleh database
+	firstLine := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		if row.err != nil {
+			continue
+		}
+		if row.req.Role != "" && !opts.AllowRole {
+			row.err = fmt.Errorf("%w: setting role requires %s", authz.ErrForbidden, authz.PermUserSetRole)
+			continue
+		}
+		if line, ok := firstLine[row.req.Email]; ok && row.req.Email != "" {
+			row.err = fmt.Errorf("%w: duplicate of line %d", ErrEmailTaken, line)
+			continue
+		}
+		firstLine[row.req.Email] = row.line
+	}
+
+	for start := 0; start < len(rows); start += MaxBatchSize {
+		chunk := rows[start:min(start+MaxBatchSize, len(rows))]
+
+		var reqs []dto.CreateUserRequest
+		var pending []*importRow
+		for i := range chunk {
+			if chunk[i].err == nil {
+				reqs = append(reqs, chunk[i].req)
+				pending = append(pending, &chunk[i])
+			}
+		}
+
+		if len(reqs) > 0 {
+			var results []BatchResult
+			var err error
+			if opts.DryRun {
+				results, err = s.userService.ValidateNewUsers(ctx, reqs)
+			} else {
+				results, err = s.userService.BatchCreateUsers(ctx, reqs, false)
+			}
+			if err != nil {
+				log.Printf("Import berhenti di baris %d: %v", chunk[0].line, err)
+				report := importReport(rows[:start], opts)
+				report.Total = len(rows)
+				report.NotProcessed = len(rows) - start
+				report.Error = err.Error()
+				return report, err
+			}
+			for i, result := range results {
+				pending[i].err = result.Err
+			}
+		}
+
+		if progress != nil {
+			progress(start + len(chunk))
+		}
+	}
+
+	return importReport(rows, opts), nil
+}
+
+// importReport menyusun laporan dari baris yang sudah diproses.
+func importReport(rows []importRow, opts ImportOptions) *dto.ImportReport {
+	report := &dto.ImportReport{DryRun: opts.DryRun, Total: len(rows), Errors: []dto.ImportError{}}
+	for _, row := range rows {
+		if row.err == nil {
+			report.Valid++
+			continue
+		}
+		report.Failed++
+		if len(report.Errors) == maxImportErrors {
+			report.ErrorsTruncated = true
+			continue
+		}
+		report.Errors = append(report.Errors, dto.ImportError{Line: row.line, Email: row.req.Email, Error: row.err.Error()})
+	}
+	if !opts.DryRun {
+		report.Created = report.Valid
+	}
+	return report
+}
+
+// parseImportFile membaca semua baris file sesuai format dan mapping kolom.
+func parseImportFile(file io.Reader, opts ImportOptions) ([]importRow, error) {
+	for column, field := range opts.Mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("%w: mapping for %q must be one of: %s", ErrInvalidImport, column, strings.Join(importFields, ", "))
+		}
+	}
+
+	format := opts.Format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(opts.Filename)) {
+		case ".csv":
+			format = FormatCSV
+		case ".ndjson", ".jsonl":
+			format = FormatNDJSON
+		}
+	}
+
+	switch format {
+	case FormatCSV:
+		return parseImportCSV(file, opts.Mapping)
+	case FormatNDJSON:
+		return parseImportNDJSON(file, opts.Mapping)
+	default:
+		return nil, fmt.Errorf("%w: format must be one of: csv, ndjson", ErrInvalidImport)
+	}
+}
+
+// parseImportCSV membaca file CSV. Baris pertama adalah header; kolom dicocokkan
+// dengan mapping, lalu dengan nama field (tanpa membedakan huruf besar/kecil).
+// Kolom lain diabaikan, sehingga file hasil export bisa langsung di-import.
+func parseImportCSV(file io.Reader, mapping map[string]string) ([]importRow, error) {
+	br := bufio.NewReader(file)
+	// Excel menyimpan CSV UTF-8 dengan BOM
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+
+	r := csv.NewReader(br)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		field := resolveImportField(strings.TrimSpace(name), mapping)
+		if field == "" {
+			continue
+		}
+		if _, ok := columns[field]; ok {
+			return nil, fmt.Errorf("%w: more than one column maps to %q", ErrInvalidImport, field)
+		}
+		columns[field] = i
+	}
+	for _, field := range []string{"name", "email", "age"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("%w: missing column for %q", ErrInvalidImport, field)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+				rows = append(rows, importRow{line: parseErr.StartLine, err: fmt.Errorf("%w: %v", ErrInvalidUser, parseErr.Err)})
+				continue
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		line, _ := r.FieldPos(0)
+		row := importRow{line: line}
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok {
+				return ""
+			}
+			return unescapeFormula(strings.TrimSpace(record[i]))
+		}
+		row.req = dto.CreateUserRequest{Name: value("name"), Email: value("email"), Role: value("role")}
+		if age := value("age"); age != "" {
+			row.req.Age, err = strconv.Atoi(age)
+			if err != nil {
+				row.err = apperror.Field(ErrInvalidUser, "age", "must be a number")
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseImportNDJSON membaca file NDJSON: satu objek JSON per baris, baris kosong
+// dilewati. Key dicocokkan seperti header CSV; age boleh angka atau string angka.
+func parseImportNDJSON(file io.Reader, mapping map[string]string) ([]importRow, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []importRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if line == 1 {
+			text = bytes.TrimPrefix(text, []byte{0xEF, 0xBB, 0xBF})
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		row := importRow{line: line}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(text, &object); err != nil {
+			row.err = fmt.Errorf("%w: line is not a JSON object", ErrInvalidUser)
+			rows = append(rows, row)
+			continue
+		}
+
+		for key, raw := range object {
+			field := resolveImportField(key, mapping)
+			var err error
+			switch field {
+			case "name":
+				err = json.Unmarshal(raw, &row.req.Name)
+			case "email":
+				err = json.Unmarshal(raw, &row.req.Email)
+			case "role":
+				err = json.Unmarshal(raw, &row.req.Role)
+			case "age":
+				var number json.Number
+				if err = json.Unmarshal(bytes.Trim(raw, `"`), &number); err == nil {
+					var age int64
+					age, err = number.Int64()
+					row.req.Age = int(age)
+				}
+			}
+			if err != nil && row.err == nil {
+				row.err = fmt.Errorf("%w: invalid value for %q", ErrInvalidUser, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, line+1, err)
+	}
+	return rows, nil
+}
+
+// resolveImportField menentukan field user untuk kolom/key file. Mapping
+// didahulukan; tanpa mapping, nama field dicocokkan tanpa membedakan huruf besar/kecil.
+func resolveImportField(column string, mapping map[string]string) string {
+	if field, ok := mapping[column]; ok {
+		return field
+	}
+	if field := strings.ToLower(column); slices.Contains(importFields, field) {
+		return field
+	}
+	return ""
+}
+
+// toImportJobResponse mengubah entity ImportJob menjadi DTO response.
+func toImportJobResponse(job *entity.ImportJob) *dto.ImportJobResponse {
+	response := &dto.ImportJobResponse{
+		ID:          job.ID,
+		Status:      job.Status,
+		DryRun:      job.DryRun,
+		Total:       job.Total,
+		Processed:   job.Processed,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if len(job.Report) > 0 {
+		var report dto.ImportReport
+		if err := json.Unmarshal(job.Report, &report); err == nil {
+			response.Report = &report
+		}
+	}
+	return response
+}
@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"api-user-crud-go/authz"
+	"api-user-crud-go/dto"
+	"api-user-crud-go/entity"
+	"api-user-crud-go/service"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockImportJobRepo adalah implementasi mock dari repository.ImportJobRepository.
+// Job disimpan sebagai salinan karena diperbarui dari goroutine job.
+type mockImportJobRepo struct {
+	mu   sync.Mutex
+	jobs map[string]entity.ImportJob
+}
+
+func (m *mockImportJobRepo) Create(ctx context.Context, job *entity.ImportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.CreatedAt = time.Now()
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+func (m *mockImportJobRepo) Update(ctx context.Context, job *entity.ImportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+func (m *mockImportJobRepo) FindByID(ctx context.Context, userID uint, id string) (*entity.ImportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok || job.UserID != userID {
+		return nil, errors.New("import job not found")
+	}
+	return &job, nil
+}
+
+func (m *mockImportJobRepo) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockImportJobRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newImportService(syncMaxRows int) (service.UserImportService, service.UserService) {
+	cfg := *testConfig
+	cfg.ImportSyncMaxRows = syncMaxRows
+	cfg.ImportJobTTLHours = 24
+	userService := newService()
+	return service.NewUserImportService(userService, &mockImportJobRepo{jobs: make(map[string]entity.ImportJob)}, &cfg), userService
+}
+
+func TestImportUsers_CSVReport(t *testing.T) {
+	svc, users := newImportService(100)
+
+	file := "\ufeffFull Name,Mail,Age,Note\n" +
+		"Alice,alice@example.com,25,ok\n" +
+		"Bob,bob@example.com,abc,bad age\n" +
+		"\"'=Carol\",carol@example.com,30,escaped by export\n" +
+		"Alice Again,alice@example.com,26,duplicate\n" +
+		"Dave,not-an-email,40,bad email\n"
+	report, job, err := svc.ImportUsers(context.Background(), 1, strings.NewReader(file), service.ImportOptions{
+		Filename: "users.csv",
+		Mapping:  map[string]string{"Full Name": "name", "Mail": "email"},
+	})
+	if err != nil || job != nil {
+		t.Fatalf("expected synchronous report, got job %v err %v", job, err)
+	}
+	if report.Total != 5 || report.Created != 2 || report.Failed != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	// Nomor baris mengikuti file (header = baris 1)
+	wantLines := []int{3, 5, 6}
+	for i, e := range report.Errors {
+		if e.Line != wantLines[i] {
+			t.Errorf("error %d: expected line %d, got %d (%s)", i, wantLines[i], e.Line, e.Error)
+		}
+	}
+	if !strings.Contains(report.Errors[1].Error, "duplicate of line 2") {
+		t.Errorf("expected duplicate to reference line 2, got %q", report.Errors[1].Error)
+	}
+
+	list, _ := users.GetAllUsers(context.Background(), dto.ListUsersRequest{})
+	if len(list.Data) != 2 || list.Data[1].Name != "=Carol" {
+		t.Errorf("expected Alice and =Carol to be created, got %+v", list.Data)
+	}
+}
+
+func TestImportUsers_DryRunAndRole(t *testing.T) {
+	svc, users := newImportService(100)
+
+	file := `{"name":"Alice","email":"alice@example.com","age":25}` + "\n\n" +
+		`{"name":"Root","email":"root@example.com","age":"30","role":"admin"}` + "\n" +
+		"not json\n"
+	report, _, err := svc.ImportUsers(context.Background(), 1, strings.NewReader(file), service.ImportOptions{Format: "ndjson", DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportUsers returned unexpected error: %v", err)
+	}
+	if !report.DryRun || report.Valid != 1 || report.Created != 0 || report.Failed != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Errors[0].Line != 3 || !strings.Contains(report.Errors[0].Error, authz.ErrForbidden.Error()) {
+		t.Errorf("expected role to be forbidden on line 3, got %+v", report.Errors[0])
+	}
+	if report.Errors[1].Line != 4 {
+		t.Errorf("expected invalid JSON on line 4, got %+v", report.Errors[1])
+	}
+	if list, _ := users.GetAllUsers(context.Background(), dto.ListUsersRequest{}); len(list.Data) != 0 {
+		t.Errorf("expected dry run to create nothing, got %d users", len(list.Data))
+	}
+
+	// Dengan izin set role, baris admin valid
+	report, _, _ = svc.ImportUsers(context.Background(), 1, strings.NewReader(file), service.ImportOptions{Format: "ndjson", DryRun: true, AllowRole: true})
+	if report.Valid != 2 {
+		t.Errorf("expected 2 valid rows with AllowRole, got %+v", report)
+	}
+}
+
+func TestImportUsers_InvalidFile(t *testing.T) {
+	svc, _ := newImportService(100)
+
+	tests := map[string]struct {
+		file string
+		opts service.ImportOptions
+	}{
+		"unknown format":  {"name,email,age\n", service.ImportOptions{Filename: "users.txt"}},
+		"missing column":  {"name,email\nA,a@example.com\n", service.ImportOptions{Format: "csv"}},
+		"bad mapping":     {"n,email,age\n", service.ImportOptions{Format: "csv", Mapping: map[string]string{"n": "nickname"}}},
+		"duplicate field": {"name,Name,email,age\n", service.ImportOptions{Format: "csv"}},
+		"empty file":      {"", service.ImportOptions{Format: "csv"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := svc.ImportUsers(context.Background(), 1, strings.NewReader(tt.file), tt.opts)
+			if !errors.Is(err, service.ErrInvalidImport) {
+				t.Errorf("expected ErrInvalidImport, got %v", err)
+			}
+		})
+	}
+}
+
+func TestImportUsers_BackgroundJob(t *testing.T) {
+	svc, users := newImportService(2)
+
+	var file strings.Builder
+	file.WriteString("name,email,age\n")
+	for i := range 3 {
+		file.WriteString("User,user" + string(rune('a'+i)) + "@example.com,20\n")
+	}
+	report, job, err := svc.ImportUsers(context.Background(), 7, strings.NewReader(file.String()), service.ImportOptions{Format: "csv"})
+	if err != nil || report != nil || job == nil {
+		t.Fatalf("expected background job, got report %v job %v err %v", report, job, err)
+	}
+	if job.Total != 3 {
+		t.Errorf("expected job total 3, got %d", job.Total)
+	}
+
+	// Job milik user lain tidak terlihat
+	if _, err := svc.GetImportJob(context.Background(), 8, job.ID); err == nil {
+		t.Error("expected job to be hidden from other users")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != entity.ImportJobCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not complete, last status %q", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if job, err = svc.GetImportJob(context.Background(), 7, job.ID); err != nil {
+			t.Fatalf("GetImportJob returned unexpected error: %v", err)
+		}
+	}
+	if job.Processed != 3 || job.Report == nil || job.Report.Created != 3 || job.CompletedAt == nil {
+		t.Errorf("unexpected completed job: %+v", job)
+	}
+	if list, _ := users.GetAllUsers(context.Background(), dto.ListUsersRequest{}); len(list.Data) != 3 {
+		t.Errorf("expected 3 users to be created, got %d", len(list.Data))
+	}
+}
+
+// failingBatchUserService gagal pada panggilan BatchCreateUsers ke-failAt (mulai dari 1).
+type failingBatchUserService struct {
+	service.UserService
+	failAt int
+	calls  *int
+}
+
+func (s failingBatchUserService) BatchCreateUsers(ctx context.Context, reqs []dto.CreateUserRequest, atomic bool) ([]service.BatchResult, error) {
+	*s.calls++
+	if *s.calls == s.failAt {
+		return nil, errors.New("database is locked")
+	}
+	return s.UserService.BatchCreateUsers(ctx, reqs, atomic)
+}
+
+// importFile membuat file CSV dengan n baris user yang valid.
+func importFile(n int) string {
+	var file strings.Builder
+	file.WriteString("name,email,age\n")
+	for i := range n {
+		fmt.Fprintf(&file, "User,user%d@example.com,20\n", i)
+	}
+	return file.String()
+}
+
+func TestImportUsers_ErrorPartwayReturnsPartialReport(t *testing.T) {
+	cfg := *testConfig
+	cfg.ImportSyncMaxRows = 2 * service.MaxBatchSize
+	cfg.ImportJobTTLHours = 24
+	users := newService()
+	svc := service.NewUserImportService(failingBatchUserService{UserService: users, failAt: 2, calls: new(int)},
+		&mockImportJobRepo{jobs: make(map[string]entity.ImportJob)}, &cfg)
+
+	total := service.MaxBatchSize + 10
+	report, _, err := svc.ImportUsers(context.Background(), 1, strings.NewReader(importFile(total)), service.ImportOptions{Format: "csv"})
+	if err == nil {
+		t.Fatal("expected error from the failing batch")
+	}
+	if report == nil {
+		t.Fatal("expected a partial report alongside the error")
+	}
+	if report.Total != total || report.Created != service.MaxBatchSize || report.NotProcessed != 10 || report.Failed != 0 || report.Error == "" {
+		t.Errorf("unexpected partial report: %+v", report)
+	}
+
+	// Batch pertama sudah tersimpan dan tidak di-rollback
+	list, _ := users.GetAllUsers(context.Background(), dto.ListUsersRequest{PageSize: 100})
+	if list.Total == nil || *list.Total != int64(service.MaxBatchSize) {
+		t.Errorf("expected %d users from the first batch, got %v", service.MaxBatchSize, list.Total)
+	}
+}
+
+func TestImportUsers_BackgroundJobErrorKeepsPartialReport(t *testing.T) {
+	cfg := *testConfig
+	cfg.ImportSyncMaxRows = 1
+	cfg.ImportJobTTLHours = 24
+	svc := service.NewUserImportService(failingBatchUserService{UserService: newService(), failAt: 2, calls: new(int)},
+		&mockImportJobRepo{jobs: make(map[string]entity.ImportJob)}, &cfg)
+
+	_, job, err := svc.ImportUsers(context.Background(), 7, strings.NewReader(importFile(service.MaxBatchSize+10)), service.ImportOptions{Format: "csv"})
+	if err != nil || job == nil {
+		t.Fatalf("expected background job, got job %v err %v", job, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.CompletedAt == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish, last status %q", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if job, err = svc.GetImportJob(context.Background(), 7, job.ID); err != nil {
+			t.Fatalf("GetImportJob returned unexpected error: %v", err)
+		}
+	}
+	if job.Status != entity.ImportJobFailed || job.Error == "" || job.Processed != service.MaxBatchSize {
+		t.Errorf("unexpected failed job: %+v", job)
+	}
+	if job.Report == nil || job.Report.Created != service.MaxBatchSize || job.Report.NotProcessed != 10 {
+		t.Errorf("expected partial report on failed job, got %+v", job.Report)
+	}
+}
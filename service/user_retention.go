@@ -0,0 +1,25 @@
+package service
+
+import (
+	"api-user-crud-go/repository"
+	"context"
+	"time"
+)
+
+// deletedUserPurger menghapus permanen user yang sudah di-soft-delete lebih lama
+// dari masa retensi. Memenuhi ExpiredTokenStore agar bisa ikut dijalankan oleh
+// job StartTokenCleanup.
+type deletedUserPurger struct {
+	userRepo  repository.UserRepository
+	retention time.Duration
+}
+
+// NewDeletedUserPurger membuat store cleanup untuk user yang sudah di-soft-delete.
+func NewDeletedUserPurger(userRepo repository.UserRepository, retention time.Duration) ExpiredTokenStore {
+	return &deletedUserPurger{userRepo: userRepo, retention: retention}
+}
+
+// DeleteExpired menghapus permanen user yang dihapus sebelum now - retention.
+func (p *deletedUserPurger) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return p.userRepo.PurgeDeletedBefore(ctx, now.Add(-p.retention))
+}
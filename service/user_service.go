@@ -1,42 +1,93 @@
 package service
 
 import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
 	"api-user-crud-go/dto"
 	"api-user-crud-go/entity"
 	"api-user-crud-go/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Batas ukuran halaman untuk listing user.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
 )
 
+// ErrInvalidQuery dikembalikan ketika parameter listing tidak valid.
+var ErrInvalidQuery = apperror.Validation("invalid query")
+
+// ErrInvalidUser dikembalikan ketika hasil update/patch menghasilkan data user yang tidak valid.
+var ErrInvalidUser = apperror.Validation("invalid user")
+
+// ErrVersionConflict dikembalikan ketika versi user tidak cocok dengan If-Match/etag
+// dari client, atau user diubah request lain di antara baca dan tulis.
+var ErrVersionConflict = repository.ErrVersionConflict
+
+// ErrEmailTaken dikembalikan ketika email user yang dibuat, diubah, atau
+// di-restore sudah dipakai user aktif lain.
+var ErrEmailTaken = repository.ErrDuplicateEmail
+
 // UserService adalah interface untuk business logic User.
 // Layer ini menangani konversi antara DTO dan Entity.
 type UserService interface {
-	CreateUser(req dto.CreateUserRequest) (*dto.UserResponse, error)
-	GetAllUsers() ([]dto.UserResponse, error)
-	GetUserByID(id uint) (*dto.UserResponse, error)
-	UpdateUser(id uint, req dto.UpdateUserRequest) (*dto.UserResponse, error)
-	DeleteUser(id uint) error
+	CreateUser(ctx context.Context, req dto.CreateUserRequest) (*dto.UserResponse, error)
+	GetAllUsers(ctx context.Context, req dto.ListUsersRequest) (*dto.UserListResponse, error)
+	ExportUsers(ctx context.Context, req dto.ExportUsersRequest, w io.Writer) error
+	GetUserByID(ctx context.Context, id uint) (*dto.UserResponse, error)
+	ListFreshness(ctx context.Context) (etag string, lastModified time.Time, err error)
+	UpdateUser(ctx context.Context, id, expectedVersion uint, req dto.UpdateUserRequest) (*dto.UserResponse, error)
+	PatchUser(ctx context.Context, id, expectedVersion uint, req dto.PatchUserRequest) (*dto.UserResponse, error)
+	DeleteUser(ctx context.Context, id, expectedVersion uint) error
+	RestoreUser(ctx context.Context, id uint) (*dto.UserResponse, error)
+	PurgeUser(ctx context.Context, id, expectedVersion uint) error
+	BatchCreateUsers(ctx context.Context, reqs []dto.CreateUserRequest, atomic bool) ([]BatchResult, error)
+	ValidateNewUsers(ctx context.Context, reqs []dto.CreateUserRequest) ([]BatchResult, error)
+	BatchUpdateUsers(ctx context.Context, items []dto.BatchUpdateUserItem, atomic bool) ([]BatchResult, error)
+	BatchDeleteUsers(ctx context.Context, items []dto.BatchDeleteUserItem, atomic bool) ([]BatchResult, error)
 }
 
 // userServiceImpl adalah implementasi dari UserService.
 type userServiceImpl struct {
-	userRepo repository.UserRepository
+	userRepo     repository.UserRepository
+	cursorSecret []byte
 }
 
 // NewUserService membuat instance baru UserService.
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userServiceImpl{userRepo: userRepo}
+func NewUserService(userRepo repository.UserRepository, cfg *config.Config) UserService {
+	// Cursor di-sign dengan CURSOR_SECRET, fallback ke JWT_SECRET jika tidak diset
+	secret := cfg.CursorSecret
+	if secret == "" {
+		secret = cfg.JWTSecret
+	}
+	return &userServiceImpl{userRepo: userRepo, cursorSecret: []byte(secret)}
 }
 
 // CreateUser menambahkan user baru.
-func (s *userServiceImpl) CreateUser(req dto.CreateUserRequest) (*dto.UserResponse, error) {
+func (s *userServiceImpl) CreateUser(ctx context.Context, req dto.CreateUserRequest) (*dto.UserResponse, error) {
 	// Konversi dari DTO ke Entity
 	user := &entity.User{
 		Name:  req.Name,
 		Email: req.Email,
 		Age:   req.Age,
+		Role:  req.Role,
+	}
+	if user.Role == "" {
+		user.Role = entity.RoleUser
 	}
 
 	// Simpan ke database melalui repository
-	err := s.userRepo.Create(user)
+	err := s.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -45,25 +96,93 @@ func (s *userServiceImpl) CreateUser(req dto.CreateUserRequest) (*dto.UserRespon
 	return toUserResponse(user), nil
 }
 
-// GetAllUsers mengambil semua user.
-func (s *userServiceImpl) GetAllUsers() ([]dto.UserResponse, error) {
-	users, err := s.userRepo.FindAll()
+// GetAllUsers mengambil satu halaman user sesuai filter, urutan, dan paginasi.
+// Dengan urutan default, response juga berisi next_cursor/prev_cursor untuk
+// keyset pagination. Mode cursor tidak menghitung total agar tetap cepat di tabel besar.
+func (s *userServiceImpl) GetAllUsers(ctx context.Context, req dto.ListUsersRequest) (*dto.UserListResponse, error) {
+	query, err := buildUserQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != "" {
+		if req.Page > 0 || req.Offset > 0 || req.Sort != "" {
+			return nil, fmt.Errorf("%w: cursor cannot be combined with page, offset or sort", ErrInvalidQuery)
+		}
+		key, direction, err := decodeCursor(s.cursorSecret, req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if direction == cursorNext {
+			query.After = &key
+		} else {
+			query.Before = &key
+		}
+		query.SkipCount = true
+	}
+
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya
+	limit := query.Limit
+	query.Limit = limit + 1
+
+	users, total, err := s.userRepo.List(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
+	hasMore := len(users) > limit
+	if hasMore {
+		if query.Before != nil {
+			users = users[1:] // baris ekstra ada di ujung terjauh dari cursor
+		} else {
+			users = users[:limit]
+		}
+	}
+
 	// Konversi slice Entity ke slice DTO
-	var responses []dto.UserResponse
+	responses := make([]dto.UserResponse, 0, len(users))
 	for _, user := range users {
 		responses = append(responses, *toUserResponse(&user))
 	}
 
-	return responses, nil
+	result := &dto.UserListResponse{
+		Data:   responses,
+		Limit:  limit,
+		Offset: query.Offset,
+	}
+	if !query.SkipCount {
+		result.Total = &total
+	}
+
+	// Cursor hanya valid untuk urutan default (created_at, id)
+	if len(query.Sort) == 0 && len(users) > 0 {
+		hasNext, hasPrev := hasMore, query.Offset > 0 || query.After != nil
+		if query.Before != nil {
+			hasNext, hasPrev = true, hasMore
+		}
+
+		if hasNext {
+			last := users[len(users)-1]
+			result.NextCursor, err = encodeCursor(s.cursorSecret, repository.UserKeyset{CreatedAt: last.CreatedAt, ID: last.ID}, cursorNext)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if hasPrev {
+			first := users[0]
+			result.PrevCursor, err = encodeCursor(s.cursorSecret, repository.UserKeyset{CreatedAt: first.CreatedAt, ID: first.ID}, cursorPrev)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
 }
 
 // GetUserByID mengambil user berdasarkan ID.
-func (s *userServiceImpl) GetUserByID(id uint) (*dto.UserResponse, error) {
-	user, err := s.userRepo.FindByID(id)
+func (s *userServiceImpl) GetUserByID(ctx context.Context, id uint) (*dto.UserResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -71,45 +190,253 @@ func (s *userServiceImpl) GetUserByID(id uint) (*dto.UserResponse, error) {
 	return toUserResponse(user), nil
 }
 
-// UpdateUser mengupdate data user.
-func (s *userServiceImpl) UpdateUser(id uint, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
-	// Cek apakah user ada
-	user, err := s.userRepo.FindByID(id)
+// ListFreshness menghitung ETag dan Last-Modified untuk listing user dari
+// ringkasan tabel yang murah, sehingga conditional GET tidak perlu menjalankan query listing.
+// ETag berubah setiap ada user yang dibuat, diubah, dihapus, atau di-restore.
+func (s *userServiceImpl) ListFreshness(ctx context.Context) (string, time.Time, error) {
+	stamp, err := s.userRepo.Stamp(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d", stamp.Count, stamp.LastModified.UnixNano())))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`, stamp.LastModified, nil
+}
+
+// UpdateUser mengganti seluruh data user (semantik PUT).
+// Field opsional yang kosong di-reset ke default: age 0 dan role user.
+// expectedVersion 0 berarti tanpa syarat versi (If-Match tidak dikirim).
+func (s *userServiceImpl) UpdateUser(ctx context.Context, id, expectedVersion uint, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
+	// Cek apakah user ada dan versinya masih sesuai harapan client
+	user, err := findForWrite(ctx, s.userRepo, id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return replaceUser(ctx, s.userRepo, user, req)
+}
+
+// PatchUser menerapkan JSON Merge Patch (RFC 7396) ke user: field yang tidak
+// ada di patch dipertahankan, field yang di-set (termasuk null) ditimpa.
+func (s *userServiceImpl) PatchUser(ctx context.Context, id, expectedVersion uint, req dto.PatchUserRequest) (*dto.UserResponse, error) {
+	return patchUser(ctx, s.userRepo, id, expectedVersion, req)
+}
+
+// patchUser adalah implementasi PatchUser untuk repository tertentu,
+// sehingga bisa dipakai juga di dalam transaksi batch.
+func patchUser(ctx context.Context, repo repository.UserRepository, id, expectedVersion uint, req dto.PatchUserRequest) (*dto.UserResponse, error) {
+	user, err := findForWrite(ctx, repo, id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mulai dari representasi saat ini, lalu timpa dengan field dari patch
+	merged := dto.UpdateUserRequest{Name: user.Name, Email: user.Email, Age: user.Age, Role: user.Role}
+	if req.Name.Set {
+		merged.Name = req.Name.Value
+	}
+	if req.Email.Set {
+		merged.Email = req.Email.Value
+	}
+	if req.Age.Set {
+		merged.Age = req.Age.Value
+	}
+	if req.Role.Set {
+		merged.Role = req.Role.Value
+	}
+
+	return replaceUser(ctx, repo, user, merged)
+}
+
+// findForWrite mengambil user yang akan diubah dan memastikan versinya sama
+// dengan expectedVersion (jika diisi).
+func findForWrite(ctx context.Context, repo repository.UserRepository, id, expectedVersion uint) (*entity.User, error) {
+	user, err := repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if expectedVersion != 0 && user.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	return user, nil
+}
 
-	// Update field yang diisi (non-empty)
-	if req.Name != "" {
-		user.Name = req.Name
+// replaceUser memvalidasi data baru, menerapkannya ke user, lalu menyimpannya.
+func replaceUser(ctx context.Context, repo repository.UserRepository, user *entity.User, req dto.UpdateUserRequest) (*dto.UserResponse, error) {
+	if err := validateUserFields(req); err != nil {
+		return nil, err
 	}
-	if req.Email != "" {
+
+	user.Name = req.Name
+	if req.Email != user.Email {
+		// Email baru harus diverifikasi ulang
 		user.Email = req.Email
+		user.EmailVerifiedAt = nil
 	}
-	if req.Age > 0 {
-		user.Age = req.Age
+	user.Age = req.Age
+	user.Role = req.Role
+	if user.Role == "" {
+		user.Role = entity.RoleUser
 	}
 
 	// Simpan perubahan
-	err = s.userRepo.Update(user)
+	if err := repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return toUserResponse(user), nil
+}
+
+// DeleteUser menghapus user berdasarkan ID (soft delete).
+// expectedVersion 0 berarti tanpa syarat versi.
+func (s *userServiceImpl) DeleteUser(ctx context.Context, id, expectedVersion uint) error {
+	return s.userRepo.Delete(ctx, id, expectedVersion)
+}
+
+// RestoreUser mengembalikan user yang sudah di-soft-delete.
+// Gagal dengan ErrEmailTaken jika email-nya sudah dipakai user lain sejak dihapus.
+func (s *userServiceImpl) RestoreUser(ctx context.Context, id uint) (*dto.UserResponse, error) {
+	user, err := s.userRepo.FindDeletedByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if existing, err := s.userRepo.FindByEmail(ctx, user.Email); err == nil && existing.ID != user.ID {
+		return nil, ErrEmailTaken
+	}
+
+	if err := s.userRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+
 	return toUserResponse(user), nil
 }
 
-// DeleteUser menghapus user berdasarkan ID.
-func (s *userServiceImpl) DeleteUser(id uint) error {
-	return s.userRepo.Delete(id)
+// PurgeUser menghapus user secara permanen, baik yang masih aktif maupun yang sudah di-soft-delete.
+// expectedVersion 0 berarti tanpa syarat versi.
+func (s *userServiceImpl) PurgeUser(ctx context.Context, id, expectedVersion uint) error {
+	return s.userRepo.Purge(ctx, id, expectedVersion)
+}
+
+// validateUserFields memvalidasi data user hasil replace/patch.
+// Aturannya sama dengan binding UpdateUserRequest, karena patch dan gRPC tidak melewati binding Gin.
+func validateUserFields(req dto.UpdateUserRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return apperror.Field(ErrInvalidUser, "name", "must not be empty")
+	}
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		return apperror.Field(ErrInvalidUser, "email", "must be a valid email address")
+	}
+	if req.Age < 0 {
+		return apperror.Field(ErrInvalidUser, "age", "must not be negative")
+	}
+	if req.Role != "" && req.Role != entity.RoleAdmin && req.Role != entity.RoleUser {
+		return apperror.Field(ErrInvalidUser, "role", "must be one of: admin, user")
+	}
+	return nil
+}
+
+// buildUserQuery memvalidasi request listing dan mengubahnya menjadi repository.UserQuery.
+func buildUserQuery(req dto.ListUsersRequest) (repository.UserQuery, error) {
+	query := repository.UserQuery{
+		Name:   req.Name,
+		Email:  req.Email,
+		MinAge: req.MinAge,
+		MaxAge: req.MaxAge,
+		Limit:  DefaultPageSize,
+	}
+
+	switch {
+	case req.IncludeDeleted && req.OnlyDeleted:
+		return query, fmt.Errorf("%w: use either include_deleted or only_deleted", ErrInvalidQuery)
+	case req.IncludeDeleted:
+		query.Deleted = repository.IncludeDeleted
+	case req.OnlyDeleted:
+		query.Deleted = repository.OnlyDeleted
+	}
+
+	if req.MinAge != nil && req.MaxAge != nil && *req.MinAge > *req.MaxAge {
+		return query, fmt.Errorf("%w: min_age must not be greater than max_age", ErrInvalidQuery)
+	}
+
+	// Paginasi: page/page_size atau limit/offset, tidak boleh dicampur
+	if (req.Page > 0 || req.PageSize > 0) && (req.Limit > 0 || req.Offset > 0) {
+		return query, fmt.Errorf("%w: use either page/page_size or limit/offset", ErrInvalidQuery)
+	}
+	switch {
+	case req.Page > 0 || req.PageSize > 0:
+		if req.PageSize > 0 {
+			query.Limit = req.PageSize
+		}
+		if req.Page > 1 {
+			query.Offset = (req.Page - 1) * query.Limit
+		}
+	case req.Limit > 0 || req.Offset > 0:
+		if req.Limit > 0 {
+			query.Limit = req.Limit
+		}
+		query.Offset = req.Offset
+	}
+	if query.Limit > MaxPageSize {
+		return query, fmt.Errorf("%w: page size must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if query.Offset < 0 {
+		return query, fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+
+	sort, err := parseSort(req.Sort)
+	if err != nil {
+		return query, err
+	}
+	query.Sort = sort
+
+	return query, nil
+}
+
+// parseSort mengubah string "field:asc,field2:desc" menjadi daftar SortField.
+// Arah default adalah asc.
+func parseSort(raw string) ([]repository.SortField, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []repository.SortField
+	for _, part := range strings.Split(raw, ",") {
+		field, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if !repository.IsSortableUserField(field) {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, field)
+		}
+
+		switch strings.ToLower(dir) {
+		case "", "asc":
+			fields = append(fields, repository.SortField{Field: field})
+		case "desc":
+			fields = append(fields, repository.SortField{Field: field, Desc: true})
+		default:
+			return nil, fmt.Errorf("%w: sort direction must be asc or desc", ErrInvalidQuery)
+		}
+	}
+	return fields, nil
 }
 
 // toUserResponse adalah helper function untuk konversi Entity ke DTO Response.
 func toUserResponse(user *entity.User) *dto.UserResponse {
-	return &dto.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-		Age:   user.Age,
+	resp := &dto.UserResponse{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		Age:           user.Age,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		MFAEnabled:    user.MFAEnabledAt != nil,
+		Version:       user.Version,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		resp.DeletedAt = &deletedAt
 	}
+	return resp
 }
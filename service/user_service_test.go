@@ -1,11 +1,20 @@
 package service_test
 
 import (
+	"api-user-crud-go/apperror"
+	"api-user-crud-go/config"
 	"api-user-crud-go/dto"
 	"api-user-crud-go/entity"
+	"api-user-crud-go/repository"
 	"api-user-crud-go/service"
+	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // ==========================================
@@ -14,74 +23,241 @@ import (
 
 // mockUserRepo adalah implementasi mock dari repository.UserRepository.
 type mockUserRepo struct {
-	users  map[uint]*entity.User
-	nextID uint
+	users   map[uint]*entity.User
+	deleted map[uint]*entity.User // user yang sudah di-soft-delete
+	nextID  uint
 }
 
 func newMockRepo() *mockUserRepo {
-	return &mockUserRepo{users: make(map[uint]*entity.User), nextID: 1}
+	return &mockUserRepo{users: make(map[uint]*entity.User), deleted: make(map[uint]*entity.User), nextID: 1}
 }
 
-func (m *mockUserRepo) Create(user *entity.User) error {
+func (m *mockUserRepo) Create(ctx context.Context, user *entity.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	user.ID = m.nextID
+	user.Version = 1
+	user.UpdatedAt = time.Now()
 	m.nextID++
 	m.users[user.ID] = user
 	return nil
 }
 
-func (m *mockUserRepo) FindAll() ([]entity.User, error) {
+// List pada mock mengurutkan berdasarkan ID, sehingga keyset cukup dibandingkan lewat ID.
+func (m *mockUserRepo) List(ctx context.Context, query repository.UserQuery) ([]entity.User, int64, error) {
 	var result []entity.User
-	for _, u := range m.users {
+	for id := uint(1); id < m.nextID; id++ {
+		u, ok := m.users[id]
+		switch query.Deleted {
+		case repository.IncludeDeleted:
+			if !ok {
+				u, ok = m.deleted[id]
+			}
+		case repository.OnlyDeleted:
+			u, ok = m.deleted[id]
+		}
+		if !ok || (query.Name != "" && !strings.Contains(u.Name, query.Name)) {
+			continue
+		}
 		result = append(result, *u)
 	}
-	return result, nil
+
+	total := int64(len(result))
+	if query.SkipCount {
+		total = 0
+	}
+
+	var keyset []entity.User
+	for _, u := range result {
+		if (query.After != nil && u.ID <= query.After.ID) || (query.Before != nil && u.ID >= query.Before.ID) {
+			continue
+		}
+		keyset = append(keyset, u)
+	}
+	result = keyset
+	if query.Before != nil && query.Limit > 0 && len(result) > query.Limit {
+		result = result[len(result)-query.Limit:]
+	}
+
+	if query.Offset >= len(result) {
+		return nil, total, nil
+	}
+	result = result[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(result) {
+		result = result[:query.Limit]
+	}
+	return result, total, nil
+}
+
+func (m *mockUserRepo) Stream(ctx context.Context, query repository.UserQuery, fn func(user *entity.User) error) error {
+	users, _, err := m.List(ctx, repository.UserQuery{Name: query.Name, Deleted: query.Deleted})
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if err := fn(&users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (m *mockUserRepo) FindByID(id uint) (*entity.User, error) {
+func (m *mockUserRepo) FindByID(ctx context.Context, id uint) (*entity.User, error) {
 	u, ok := m.users[id]
 	if !ok {
-		return nil, errors.New("user not found")
+		return nil, apperror.NotFound("user not found")
 	}
 	return u, nil
 }
 
-func (m *mockUserRepo) FindByEmail(email string) (*entity.User, error) {
+func (m *mockUserRepo) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	for _, u := range m.users {
 		if u.Email == email {
 			return u, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, apperror.NotFound("user not found")
 }
 
-func (m *mockUserRepo) Update(user *entity.User) error {
+func (m *mockUserRepo) Stamp(ctx context.Context) (repository.UserStamp, error) {
+	stamp := repository.UserStamp{Count: int64(len(m.users) + len(m.deleted))}
+	for _, users := range []map[uint]*entity.User{m.users, m.deleted} {
+		for _, u := range users {
+			if u.UpdatedAt.After(stamp.LastModified) {
+				stamp.LastModified = u.UpdatedAt
+			}
+			if u.DeletedAt.Valid && u.DeletedAt.Time.After(stamp.LastModified) {
+				stamp.LastModified = u.DeletedAt.Time
+			}
+		}
+	}
+	return stamp, nil
+}
+
+func (m *mockUserRepo) Update(ctx context.Context, user *entity.User) error {
 	if _, ok := m.users[user.ID]; !ok {
-		return errors.New("user not found")
+		return apperror.NotFound("user not found")
 	}
+	user.Version++
+	user.UpdatedAt = time.Now()
 	m.users[user.ID] = user
 	return nil
 }
 
-func (m *mockUserRepo) Delete(id uint) error {
-	if _, ok := m.users[id]; !ok {
-		return errors.New("user not found")
+func (m *mockUserRepo) AdvanceMFAStep(ctx context.Context, id uint, step int64) (bool, error) {
+	u, ok := m.users[id]
+	if !ok || u.MFALastStep >= step {
+		return false, nil
+	}
+	u.MFALastStep = step
+	return true, nil
+}
+
+func (m *mockUserRepo) Delete(ctx context.Context, id uint, version uint) error {
+	u, ok := m.users[id]
+	if !ok {
+		return apperror.NotFound("user not found")
+	}
+	if version > 0 && u.Version != version {
+		return repository.ErrVersionConflict
+	}
+	u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	m.deleted[id] = u
+	delete(m.users, id)
+	return nil
+}
+
+func (m *mockUserRepo) FindDeletedByID(ctx context.Context, id uint) (*entity.User, error) {
+	u, ok := m.deleted[id]
+	if !ok {
+		return nil, apperror.NotFound("deleted user not found")
+	}
+	return u, nil
+}
+
+func (m *mockUserRepo) Restore(ctx context.Context, id uint) error {
+	u, ok := m.deleted[id]
+	if !ok {
+		return apperror.NotFound("deleted user not found")
+	}
+	u.DeletedAt = gorm.DeletedAt{}
+	m.users[id] = u
+	delete(m.deleted, id)
+	return nil
+}
+
+func (m *mockUserRepo) Purge(ctx context.Context, id uint, version uint) error {
+	u, ok := m.users[id]
+	if !ok {
+		u, ok = m.deleted[id]
+	}
+	if !ok {
+		return apperror.NotFound("user not found")
+	}
+	if version > 0 && u.Version != version {
+		return repository.ErrVersionConflict
 	}
 	delete(m.users, id)
+	delete(m.deleted, id)
+	return nil
+}
+
+func (m *mockUserRepo) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	for id, u := range m.deleted {
+		if u.DeletedAt.Time.Before(cutoff) {
+			delete(m.deleted, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Transaction pada mock menyimpan salinan data dan mengembalikannya jika fn gagal.
+func (m *mockUserRepo) Transaction(ctx context.Context, fn func(repository.UserRepository) error) error {
+	users, deleted, nextID := cloneUsers(m.users), cloneUsers(m.deleted), m.nextID
+	if err := fn(m); err != nil {
+		m.users, m.deleted, m.nextID = users, deleted, nextID
+		return err
+	}
 	return nil
 }
 
+func cloneUsers(users map[uint]*entity.User) map[uint]*entity.User {
+	clone := make(map[uint]*entity.User, len(users))
+	for id, u := range users {
+		copied := *u
+		clone[id] = &copied
+	}
+	return clone
+}
+
 // ==========================================
 // TESTS
 // ==========================================
 
+var testConfig = &config.Config{
+	JWTSecret:                    "test-secret",
+	JWTExpiryMinutes:             15,
+	RefreshTokenExpiryHours:      24,
+	PasswordResetExpiryMinutes:   30,
+	EmailVerificationExpiryHours: 24,
+	OAuthCodeExpiryMinutes:       5,
+	PublicBaseURL:                "http://localhost:8080",
+}
+
 func newService() service.UserService {
-	return service.NewUserService(newMockRepo())
+	return service.NewUserService(newMockRepo(), testConfig)
 }
 
 func TestCreateUser(t *testing.T) {
 	svc := newService()
 
-	resp, err := svc.CreateUser(dto.CreateUserRequest{
+	resp, err := svc.CreateUser(context.Background(), dto.CreateUserRequest{
 		Name:  "Alice",
 		Email: "alice@example.com",
 		Age:   25,
@@ -107,36 +283,159 @@ func TestCreateUser(t *testing.T) {
 func TestGetAllUsers_Empty(t *testing.T) {
 	svc := newService()
 
-	users, err := svc.GetAllUsers()
+	users, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{})
 	if err != nil {
 		t.Fatalf("GetAllUsers returned unexpected error: %v", err)
 	}
-	if len(users) != 0 {
-		t.Errorf("expected 0 users, got %d", len(users))
+	if len(users.Data) != 0 {
+		t.Errorf("expected 0 users, got %d", len(users.Data))
+	}
+	if users.Total == nil || *users.Total != 0 {
+		t.Errorf("expected total 0, got %v", users.Total)
 	}
 }
 
 func TestGetAllUsers_WithData(t *testing.T) {
 	svc := newService()
 
-	svc.CreateUser(dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
-	svc.CreateUser(dto.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+	svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+
+	users, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{})
+	if err != nil {
+		t.Fatalf("GetAllUsers returned unexpected error: %v", err)
+	}
+	if len(users.Data) != 2 {
+		t.Errorf("expected 2 users, got %d", len(users.Data))
+	}
+	if users.Total == nil || *users.Total != 2 {
+		t.Errorf("expected total 2, got %v", users.Total)
+	}
+}
+
+func TestGetAllUsers_Pagination(t *testing.T) {
+	svc := newService()
+
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: name, Email: strings.ToLower(name) + "@example.com", Age: 25})
+	}
+
+	users, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{Page: 2, PageSize: 2})
+	if err != nil {
+		t.Fatalf("GetAllUsers returned unexpected error: %v", err)
+	}
+	if len(users.Data) != 1 || users.Data[0].Name != "Carol" {
+		t.Errorf("expected only 'Carol' on page 2, got %+v", users.Data)
+	}
+	if users.Total == nil || *users.Total != 3 {
+		t.Errorf("expected total 3, got %v", users.Total)
+	}
+	if users.Limit != 2 || users.Offset != 2 {
+		t.Errorf("expected limit 2 offset 2, got limit %d offset %d", users.Limit, users.Offset)
+	}
+}
+
+func TestGetAllUsers_CursorWalk(t *testing.T) {
+	svc := newService()
+
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave", "Eve"} {
+		svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: name, Email: strings.ToLower(name) + "@example.com", Age: 25})
+	}
+
+	// Maju sampai habis
+	var walked []string
+	cursor := ""
+	for {
+		page, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{PageSize: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("GetAllUsers returned unexpected error: %v", err)
+		}
+		if cursor != "" && page.Total != nil {
+			t.Error("expected total to be omitted in cursor mode")
+		}
+		for _, u := range page.Data {
+			walked = append(walked, u.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if strings.Join(walked, ",") != "Alice,Bob,Carol,Dave,Eve" {
+		t.Fatalf("expected all users in order, got %v", walked)
+	}
 
-	users, err := svc.GetAllUsers()
+	// Mundur dari halaman kedua ke halaman pertama
+	first, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{PageSize: 2})
+	second, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{PageSize: 2, Cursor: first.NextCursor})
+	if second.PrevCursor == "" {
+		t.Fatal("expected prev_cursor on second page")
+	}
+	back, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{PageSize: 2, Cursor: second.PrevCursor})
 	if err != nil {
 		t.Fatalf("GetAllUsers returned unexpected error: %v", err)
 	}
-	if len(users) != 2 {
-		t.Errorf("expected 2 users, got %d", len(users))
+	if len(back.Data) != 2 || back.Data[0].Name != "Alice" || back.Data[1].Name != "Bob" {
+		t.Errorf("expected first page when going back, got %+v", back.Data)
+	}
+	if back.PrevCursor != "" {
+		t.Error("expected no prev_cursor on the first page")
+	}
+}
+
+func TestGetAllUsers_TamperedCursor(t *testing.T) {
+	svc := newService()
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: name, Email: strings.ToLower(name) + "@example.com", Age: 25})
+	}
+
+	page, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{PageSize: 1})
+	payload, signature, _ := strings.Cut(page.NextCursor, ".")
+	tampered := payload + "x." + signature
+
+	if _, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{Cursor: tampered}); !errors.Is(err, service.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery for tampered cursor, got %v", err)
+	}
+
+	// Cursor dari server lain (secret berbeda) juga ditolak
+	other := service.NewUserService(newMockRepo(), &config.Config{JWTSecret: "other-secret"})
+	if _, err := other.GetAllUsers(context.Background(), dto.ListUsersRequest{Cursor: page.NextCursor}); !errors.Is(err, service.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery for foreign cursor, got %v", err)
+	}
+}
+
+func TestGetAllUsers_InvalidQuery(t *testing.T) {
+	svc := newService()
+	minAge, maxAge := 30, 20
+
+	tests := []struct {
+		name string
+		req  dto.ListUsersRequest
+	}{
+		{"unknown sort field", dto.ListUsersRequest{Sort: "password:asc"}},
+		{"bad sort direction", dto.ListUsersRequest{Sort: "name:up"}},
+		{"mixed pagination styles", dto.ListUsersRequest{Page: 1, Limit: 10}},
+		{"page size too large", dto.ListUsersRequest{PageSize: 1000}},
+		{"inverted age range", dto.ListUsersRequest{MinAge: &minAge, MaxAge: &maxAge}},
+		{"malformed cursor", dto.ListUsersRequest{Cursor: "not-a-cursor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.GetAllUsers(context.Background(), tt.req)
+			if !errors.Is(err, service.ErrInvalidQuery) {
+				t.Errorf("expected ErrInvalidQuery, got %v", err)
+			}
+		})
 	}
 }
 
 func TestGetUserByID_Found(t *testing.T) {
 	svc := newService()
 
-	created, _ := svc.CreateUser(dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
 
-	user, err := svc.GetUserByID(created.ID)
+	user, err := svc.GetUserByID(context.Background(), created.ID)
 	if err != nil {
 		t.Fatalf("GetUserByID returned unexpected error: %v", err)
 	}
@@ -148,20 +447,21 @@ func TestGetUserByID_Found(t *testing.T) {
 func TestGetUserByID_NotFound(t *testing.T) {
 	svc := newService()
 
-	_, err := svc.GetUserByID(999)
-	if err == nil {
-		t.Error("expected error for non-existent user, got nil")
+	_, err := svc.GetUserByID(context.Background(), 999)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("expected not found error for non-existent user, got %v", err)
 	}
 }
 
 func TestUpdateUser_Success(t *testing.T) {
 	svc := newService()
 
-	created, _ := svc.CreateUser(dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
 
-	updated, err := svc.UpdateUser(created.ID, dto.UpdateUserRequest{
-		Name: "Alice Updated",
-		Age:  30,
+	updated, err := svc.UpdateUser(context.Background(), created.ID, 0, dto.UpdateUserRequest{
+		Name:  "Alice Updated",
+		Email: "alice@example.com",
+		Age:   30,
 	})
 	if err != nil {
 		t.Fatalf("UpdateUser returned unexpected error: %v", err)
@@ -172,33 +472,161 @@ func TestUpdateUser_Success(t *testing.T) {
 	if updated.Age != 30 {
 		t.Errorf("expected age 30, got %d", updated.Age)
 	}
-	// Email should remain unchanged
 	if updated.Email != "alice@example.com" {
 		t.Errorf("expected email unchanged, got '%s'", updated.Email)
 	}
 }
 
+func TestUpdateUser_ReplacesOmittedFields(t *testing.T) {
+	svc := newService()
+
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25, Role: "admin"})
+
+	// PUT adalah full replacement: field opsional yang tidak dikirim kembali ke default
+	updated, err := svc.UpdateUser(context.Background(), created.ID, 0, dto.UpdateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("UpdateUser returned unexpected error: %v", err)
+	}
+	if updated.Age != 0 || updated.Role != "user" {
+		t.Errorf("expected age 0 and role user, got age %d role %s", updated.Age, updated.Role)
+	}
+
+	_, err = svc.UpdateUser(context.Background(), created.ID, 0, dto.UpdateUserRequest{Name: "Alice"})
+	if !errors.Is(err, service.ErrInvalidUser) {
+		t.Fatalf("expected ErrInvalidUser without email, got %v", err)
+	}
+	if fields := apperror.FieldsOf(err); len(fields) != 1 || fields[0].Field != "email" {
+		t.Errorf("expected a violation for email, got %v", fields)
+	}
+}
+
+func TestUpdateUser_IfMatch(t *testing.T) {
+	svc := newService()
+
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	req := dto.UpdateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 26}
+
+	updated, err := svc.UpdateUser(context.Background(), created.ID, created.Version, req)
+	if err != nil {
+		t.Fatalf("UpdateUser returned unexpected error: %v", err)
+	}
+	if updated.Version != created.Version+1 {
+		t.Errorf("expected version to be bumped to %d, got %d", created.Version+1, updated.Version)
+	}
+
+	// Versi lama (admin lain masih memegang ETag sebelumnya) ditolak
+	if _, err := svc.UpdateUser(context.Background(), created.ID, created.Version, req); !errors.Is(err, service.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict for stale PUT, got %v", err)
+	}
+	if _, err := svc.PatchUser(context.Background(), created.ID, created.Version, dto.PatchUserRequest{Age: dto.OptionalOf(30)}); !errors.Is(err, service.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict for stale PATCH, got %v", err)
+	}
+	if err := svc.DeleteUser(context.Background(), created.ID, created.Version); !errors.Is(err, service.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict for stale DELETE, got %v", err)
+	}
+	if err := svc.DeleteUser(context.Background(), created.ID, updated.Version); err != nil {
+		t.Errorf("expected DELETE with current version to succeed, got %v", err)
+	}
+}
+
+func TestListFreshness(t *testing.T) {
+	svc := newService()
+
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	etag, lastModified, err := svc.ListFreshness(context.Background())
+	if err != nil {
+		t.Fatalf("ListFreshness returned unexpected error: %v", err)
+	}
+	if again, _, _ := svc.ListFreshness(context.Background()); again != etag {
+		t.Errorf("expected stable etag without writes, got %s then %s", etag, again)
+	}
+
+	svc.PatchUser(context.Background(), created.ID, 0, dto.PatchUserRequest{Age: dto.OptionalOf(26)})
+	updated, updatedAt, _ := svc.ListFreshness(context.Background())
+	if updated == etag || updatedAt.Before(lastModified) {
+		t.Errorf("expected etag and last-modified to move after update, got %s/%v", updated, updatedAt)
+	}
+}
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    uint
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"*", 0, false},
+		{`"3"`, 3, false},
+		{service.FormatETag(42), 42, false},
+		{`W/"3"`, 0, true},
+		{`"3", "4"`, 0, true},
+		{`"abc"`, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := service.ParseETag(tt.tag)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseETag(%q) = %d, %v; want %d, error %v", tt.tag, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPatchUser_MergeSemantics(t *testing.T) {
+	svc := newService()
+
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25, Role: "admin"})
+
+	var patch dto.PatchUserRequest
+	if err := json.Unmarshal([]byte(`{"name":"Alicia","age":null}`), &patch); err != nil {
+		t.Fatalf("failed to decode patch: %v", err)
+	}
+	patched, err := svc.PatchUser(context.Background(), created.ID, 0, patch)
+	if err != nil {
+		t.Fatalf("PatchUser returned unexpected error: %v", err)
+	}
+	if patched.Name != "Alicia" || patched.Age != 0 {
+		t.Errorf("expected name Alicia and cleared age, got %s/%d", patched.Name, patched.Age)
+	}
+	if patched.Email != "alice@example.com" || patched.Role != "admin" {
+		t.Errorf("expected untouched email and role, got %s/%s", patched.Email, patched.Role)
+	}
+
+	tests := map[string]string{
+		"null name":     `{"name":null}`,
+		"invalid email": `{"email":"not-an-email"}`,
+		"invalid role":  `{"role":"root"}`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			var patch dto.PatchUserRequest
+			json.Unmarshal([]byte(body), &patch)
+			if _, err := svc.PatchUser(context.Background(), created.ID, 0, patch); !errors.Is(err, service.ErrInvalidUser) {
+				t.Errorf("expected ErrInvalidUser, got %v", err)
+			}
+		})
+	}
+}
+
 func TestUpdateUser_NotFound(t *testing.T) {
 	svc := newService()
 
-	_, err := svc.UpdateUser(999, dto.UpdateUserRequest{Name: "Ghost"})
-	if err == nil {
-		t.Error("expected error for non-existent user, got nil")
+	_, err := svc.UpdateUser(context.Background(), 999, 0, dto.UpdateUserRequest{Name: "Ghost"})
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("expected not found error for non-existent user, got %v", err)
 	}
 }
 
 func TestDeleteUser_Success(t *testing.T) {
 	svc := newService()
 
-	created, _ := svc.CreateUser(dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
 
-	err := svc.DeleteUser(created.ID)
+	err := svc.DeleteUser(context.Background(), created.ID, 0)
 	if err != nil {
 		t.Fatalf("DeleteUser returned unexpected error: %v", err)
 	}
 
 	// Verify user is gone
-	_, err = svc.GetUserByID(created.ID)
+	_, err = svc.GetUserByID(context.Background(), created.ID)
 	if err == nil {
 		t.Error("expected error after deletion, got nil")
 	}
@@ -207,8 +635,293 @@ func TestDeleteUser_Success(t *testing.T) {
 func TestDeleteUser_NotFound(t *testing.T) {
 	svc := newService()
 
-	err := svc.DeleteUser(999)
+	err := svc.DeleteUser(context.Background(), 999, 0)
 	if err == nil {
 		t.Error("expected error for non-existent user, got nil")
 	}
 }
+
+func TestRestoreUser(t *testing.T) {
+	svc := newService()
+
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	svc.DeleteUser(context.Background(), created.ID, 0)
+
+	deleted, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{OnlyDeleted: true})
+	if err != nil {
+		t.Fatalf("GetAllUsers returned unexpected error: %v", err)
+	}
+	if len(deleted.Data) != 1 || deleted.Data[0].DeletedAt == nil {
+		t.Fatalf("expected one deleted user with deleted_at, got %+v", deleted.Data)
+	}
+
+	restored, err := svc.RestoreUser(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("RestoreUser returned unexpected error: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Error("expected deleted_at to be cleared after restore")
+	}
+	if _, err := svc.GetUserByID(context.Background(), created.ID); err != nil {
+		t.Errorf("expected restored user to be found, got %v", err)
+	}
+	if _, err := svc.RestoreUser(context.Background(), created.ID); err == nil {
+		t.Error("expected error when restoring an active user")
+	}
+}
+
+func TestRestoreUser_EmailTaken(t *testing.T) {
+	svc := newService()
+
+	old, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	svc.DeleteUser(context.Background(), old.ID, 0)
+	svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "New Alice", Email: "alice@example.com", Age: 30})
+
+	_, err := svc.RestoreUser(context.Background(), old.ID)
+	if !errors.Is(err, service.ErrEmailTaken) {
+		t.Fatalf("expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestGetAllUsers_DeletedFilter(t *testing.T) {
+	svc := newService()
+
+	alice, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+	svc.DeleteUser(context.Background(), alice.ID, 0)
+
+	active, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{})
+	all, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{IncludeDeleted: true})
+	if len(active.Data) != 1 || len(all.Data) != 2 {
+		t.Errorf("expected 1 active and 2 total users, got %d and %d", len(active.Data), len(all.Data))
+	}
+
+	_, err := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{IncludeDeleted: true, OnlyDeleted: true})
+	if !errors.Is(err, service.ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+	repo := newMockRepo()
+	svc := service.NewUserService(repo, testConfig)
+
+	created, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	if err := svc.PurgeUser(context.Background(), created.ID, 0); err != nil {
+		t.Fatalf("PurgeUser returned unexpected error: %v", err)
+	}
+	if _, err := svc.RestoreUser(context.Background(), created.ID); err == nil {
+		t.Error("expected purged user to be unrecoverable")
+	}
+	if err := svc.PurgeUser(context.Background(), created.ID, 0); err == nil {
+		t.Error("expected error when purging a missing user")
+	}
+}
+
+func TestDeletedUserPurger(t *testing.T) {
+	repo := newMockRepo()
+	svc := service.NewUserService(repo, testConfig)
+
+	alice, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	bob, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+	svc.DeleteUser(context.Background(), alice.ID, 0)
+	svc.DeleteUser(context.Background(), bob.ID, 0)
+	repo.deleted[alice.ID].DeletedAt.Time = time.Now().Add(-31 * 24 * time.Hour)
+
+	purged, err := service.NewDeletedUserPurger(repo, 30*24*time.Hour).DeleteExpired(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("DeleteExpired returned unexpected error: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged user, got %d", purged)
+	}
+	if _, ok := repo.deleted[bob.ID]; !ok {
+		t.Error("expected user inside retention window to be kept")
+	}
+}
+
+func TestBatchCreateUsers_Atomic(t *testing.T) {
+	svc := newService()
+
+	reqs := []dto.CreateUserRequest{
+		{Name: "Alice", Email: "alice@example.com", Age: 25},
+		{Name: "Alice Again", Email: "alice@example.com", Age: 26},
+		{Name: "Bob", Email: "bob@example.com", Age: 30},
+	}
+	results, err := svc.BatchCreateUsers(context.Background(), reqs, true)
+	if err != nil {
+		t.Fatalf("BatchCreateUsers returned unexpected error: %v", err)
+	}
+
+	// Email duplikat di dalam batch membatalkan seluruh batch
+	if !errors.Is(results[1].Err, service.ErrEmailTaken) {
+		t.Errorf("expected ErrEmailTaken for duplicate email, got %v", results[1].Err)
+	}
+	for _, i := range []int{0, 2} {
+		if !errors.Is(results[i].Err, service.ErrBatchAborted) || results[i].User != nil {
+			t.Errorf("expected item %d to be aborted, got %+v", i, results[i])
+		}
+	}
+	list, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{})
+	if len(list.Data) != 0 {
+		t.Errorf("expected no users after aborted batch, got %d", len(list.Data))
+	}
+}
+
+func TestBatchCreateUsers_BestEffort(t *testing.T) {
+	svc := newService()
+	svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+
+	reqs := []dto.CreateUserRequest{
+		{Name: "Bob", Email: "bob@example.com", Age: 30},
+		{Name: "Alice", Email: "alice@example.com", Age: 25},
+		{Name: "Carol", Email: "not-an-email", Age: 40},
+		{Name: "Dave", Email: "dave@example.com", Age: 0},
+		{Name: "Erin", Email: "erin@example.com", Age: 35, Role: "admin"},
+	}
+	results, err := svc.BatchCreateUsers(context.Background(), reqs, false)
+	if err != nil {
+		t.Fatalf("BatchCreateUsers returned unexpected error: %v", err)
+	}
+
+	if results[0].Err != nil || results[0].User.Email != "bob@example.com" {
+		t.Errorf("expected Bob to be created, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, service.ErrEmailTaken) {
+		t.Errorf("expected ErrEmailTaken, got %v", results[1].Err)
+	}
+	for _, i := range []int{2, 3} {
+		if !errors.Is(results[i].Err, service.ErrInvalidUser) {
+			t.Errorf("expected ErrInvalidUser for item %d, got %v", i, results[i].Err)
+		}
+	}
+	if results[4].Err != nil || results[4].User.Role != "admin" {
+		t.Errorf("expected Erin to be created as admin, got %+v", results[4])
+	}
+
+	list, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{})
+	if len(list.Data) != 3 {
+		t.Errorf("expected 3 users, got %d", len(list.Data))
+	}
+}
+
+func TestBatchCreateUsers_Size(t *testing.T) {
+	svc := newService()
+
+	if _, err := svc.BatchCreateUsers(context.Background(), nil, true); !errors.Is(err, service.ErrInvalidBatch) {
+		t.Errorf("expected ErrInvalidBatch for empty batch, got %v", err)
+	}
+	if _, err := svc.BatchCreateUsers(context.Background(), make([]dto.CreateUserRequest, service.MaxBatchSize+1), true); !errors.Is(err, service.ErrInvalidBatch) {
+		t.Errorf("expected ErrInvalidBatch for oversized batch, got %v", err)
+	}
+}
+
+func TestBatchCreateUsers_Canceled(t *testing.T) {
+	svc := newService()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reqs := []dto.CreateUserRequest{{Name: "Alice", Email: "alice@example.com", Age: 25}}
+	if _, err := svc.BatchCreateUsers(ctx, reqs, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if list, _ := svc.GetAllUsers(context.Background(), dto.ListUsersRequest{}); len(list.Data) != 0 {
+		t.Errorf("expected no users after canceled batch, got %d", len(list.Data))
+	}
+}
+
+func TestBatchUpdateAndDeleteUsers(t *testing.T) {
+	svc := newService()
+	alice, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Age: 25})
+	bob, _ := svc.CreateUser(context.Background(), dto.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+
+	// Atomic: ETag Bob yang basi membatalkan perubahan Alice juga
+	items := []dto.BatchUpdateUserItem{
+		{ID: alice.ID, PatchUserRequest: dto.PatchUserRequest{Age: dto.OptionalOf(26)}},
+		{ID: bob.ID, ETag: `"9"`, PatchUserRequest: dto.PatchUserRequest{Age: dto.OptionalOf(31)}},
+	}
+	results, err := svc.BatchUpdateUsers(context.Background(), items, true)
+	if err != nil {
+		t.Fatalf("BatchUpdateUsers returned unexpected error: %v", err)
+	}
+	if !errors.Is(results[0].Err, service.ErrBatchAborted) || !errors.Is(results[1].Err, service.ErrVersionConflict) {
+		t.Fatalf("expected aborted + version conflict, got %v / %v", results[0].Err, results[1].Err)
+	}
+	if got, _ := svc.GetUserByID(context.Background(), alice.ID); got.Age != 25 || got.Version != alice.Version {
+		t.Errorf("expected Alice to be unchanged after rollback, got age %d version %d", got.Age, got.Version)
+	}
+
+	// Best effort: Alice tetap diupdate
+	results, _ = svc.BatchUpdateUsers(context.Background(), items, false)
+	if results[0].Err != nil || results[0].User.Age != 26 {
+		t.Errorf("expected Alice to be updated, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, service.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict for Bob, got %v", results[1].Err)
+	}
+
+	deleted, err := svc.BatchDeleteUsers(context.Background(), []dto.BatchDeleteUserItem{{ID: alice.ID}, {ID: 999}, {ID: bob.ID}}, false)
+	if err != nil {
+		t.Fatalf("BatchDeleteUsers returned unexpected error: %v", err)
+	}
+	if deleted[0].Err != nil || deleted[1].Err == nil || deleted[2].Err != nil {
+		t.Errorf("expected only the unknown ID to fail, got %v / %v / %v", deleted[0].Err, deleted[1].Err, deleted[2].Err)
+	}
+	if _, err := svc.GetUserByID(context.Background(), bob.ID); err == nil {
+		t.Error("expected Bob to be deleted")
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	svc := newService()
+	for _, req := range []dto.CreateUserRequest{
+		{Name: "Alice", Email: "alice@example.com", Age: 25},
+		{Name: "=HYPERLINK(\"x\")", Email: "bob@example.com", Age: 30},
+	} {
+		if _, err := svc.CreateUser(context.Background(), req); err != nil {
+			t.Fatalf("CreateUser returned unexpected error: %v", err)
+		}
+	}
+
+	// CSV: header + satu baris per user, sel formula di-escape
+	var buf strings.Builder
+	if err := svc.ExportUsers(context.Background(), dto.ExportUsersRequest{}, &buf); err != nil {
+		t.Fatalf("ExportUsers returned unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "id,name,email,age,role") {
+		t.Fatalf("unexpected CSV export:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[2], `2,"'=HYPERLINK(""x"")",bob@example.com,30,user`) {
+		t.Errorf("expected formula cell to be escaped, got %s", lines[2])
+	}
+
+	// NDJSON dengan filter
+	buf.Reset()
+	if err := svc.ExportUsers(context.Background(), dto.ExportUsersRequest{Format: "ndjson", Name: "Ali"}, &buf); err != nil {
+		t.Fatalf("ExportUsers returned unexpected error: %v", err)
+	}
+	var user dto.UserResponse
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &user) != nil || user.Email != "alice@example.com" {
+		t.Errorf("unexpected NDJSON export: %s", buf.String())
+	}
+
+	// JSON array, termasuk ketika tidak ada user yang cocok
+	for name, want := range map[string]int{"": 2, "nobody": 0} {
+		buf.Reset()
+		if err := svc.ExportUsers(context.Background(), dto.ExportUsersRequest{Format: "json", Name: name}, &buf); err != nil {
+			t.Fatalf("ExportUsers returned unexpected error: %v", err)
+		}
+		var users []dto.UserResponse
+		if err := json.Unmarshal([]byte(buf.String()), &users); err != nil || len(users) != want {
+			t.Errorf("expected JSON array of %d users, got %q (%v)", want, buf.String(), err)
+		}
+	}
+
+	// Query tidak valid ditolak sebelum ada byte yang ditulis
+	buf.Reset()
+	err := svc.ExportUsers(context.Background(), dto.ExportUsersRequest{IncludeDeleted: true, OnlyDeleted: true}, &buf)
+	if !errors.Is(err, service.ErrInvalidQuery) || buf.Len() != 0 {
+		t.Errorf("expected ErrInvalidQuery with empty output, got %v / %q", err, buf.String())
+	}
+}
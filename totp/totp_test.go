@@ -0,0 +1,67 @@
+package totp_test
+
+import (
+	"api-user-crud-go/totp"
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret adalah secret SHA1 dari test vector RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// Kode 8 digit di RFC dipotong menjadi 6 digit terakhir
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code returned unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestValidate_Skew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := totp.Code(rfcSecret, totp.Step(now)-1)
+	old, _ := totp.Code(rfcSecret, totp.Step(now)-2)
+
+	step, ok := totp.Validate(rfcSecret, previous, now, 1)
+	if !ok || step != totp.Step(now)-1 {
+		t.Errorf("expected code from previous step to be accepted, got step=%d ok=%v", step, ok)
+	}
+	if _, ok := totp.Validate(rfcSecret, old, now, 1); ok {
+		t.Error("expected code outside skew window to be rejected")
+	}
+	if _, ok := totp.Validate(rfcSecret, "12345", now, 1); ok {
+		t.Error("expected code with wrong length to be rejected")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := totp.ProvisioningURI("My App", "alice@example.com", "JBSWY3DPEHPK3PXP")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/My%20App:alice@example.com?") {
+		t.Errorf("unexpected label in %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=My+App", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("expected %q in %s", part, uri)
+		}
+	}
+}